@@ -0,0 +1,47 @@
+package commands
+
+import (
+	"context"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/upper-institute/hike/pkg/parameter"
+)
+
+const DiffFoundExitCode = 2
+
+var (
+	diffCmd = &cobra.Command{
+		Use:   "diff <uri-a> <uri-b>",
+		Short: "Compare parameters of two URIs, exits with 0 when equal, 2 when different and 1 on errors",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			ctx := context.Background()
+
+			a, err := restoreSource(ctx, args[0])
+			if err != nil {
+				return err
+			}
+
+			b, err := restoreSource(ctx, args[1])
+			if err != nil {
+				return err
+			}
+
+			diff, err := parameter.NewDiff(ctx, a, b)
+			if err != nil {
+				return err
+			}
+
+			diff.Write(os.Stdout, !viper.GetBool("parameter.diff.showValues"))
+
+			if len(diff.Differences) > 0 {
+				exitCode = DiffFoundExitCode
+			}
+
+			return nil
+		},
+	}
+)
@@ -7,6 +7,12 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/upper-institute/hike/internal"
+	"github.com/upper-institute/hike/pkg/acme"
+	"github.com/upper-institute/hike/pkg/ca"
+	"github.com/upper-institute/hike/pkg/certificate"
+	"github.com/upper-institute/hike/pkg/parameter"
+	"github.com/upper-institute/hike/pkg/servicemesh"
+	paramapi "github.com/upper-institute/hike/proto/api/parameter"
 )
 
 var (
@@ -28,6 +34,69 @@ func init() {
 	xdsServerCmd.PersistentFlags().String("node-id", "hike-node", "Tell envoy which node id to use")
 	viper.BindPFlag("envoy.nodeId", xdsServerCmd.PersistentFlags().Lookup("node-id"))
 
+	xdsServerCmd.PersistentFlags().Bool("parameter-service", false, "Also serve the parameter gRPC service")
+	viper.BindPFlag("envoy.parameterService", xdsServerCmd.PersistentFlags().Lookup("parameter-service"))
+
+	xdsServerCmd.PersistentFlags().Duration("parameter-watch-min-interval", parameter.DefaultWatchInterval, "Minimum interval between source restores of a parameter watch stream")
+	viper.BindPFlag("envoy.parameterWatchMinInterval", xdsServerCmd.PersistentFlags().Lookup("parameter-watch-min-interval"))
+
+	xdsServerCmd.PersistentFlags().Duration("secret-reload-interval", servicemesh.DefaultSecretReloadInterval, "Interval to load again the TLS certificates of services served via SDS")
+	viper.BindPFlag("envoy.secretReloadInterval", xdsServerCmd.PersistentFlags().Lookup("secret-reload-interval"))
+
+	xdsServerCmd.PersistentFlags().Duration("certificate-renew-before", certificate.DefaultRenewBefore, "Window before expiry to renew the certificates of services (ACME), expiring uploaded ones are reported")
+	xdsServerCmd.PersistentFlags().Duration("certificate-check-interval", certificate.DefaultCheckInterval, "Interval to check the expiry of the certificates of services")
+	xdsServerCmd.PersistentFlags().Duration("certificate-check-timeout", certificate.DefaultCheckTimeout, "Max time to load and renew a certificate, e.g. an ACME order")
+	xdsServerCmd.PersistentFlags().Duration("certificate-min-backoff", certificate.DefaultMinBackoff, "Time to wait before checking again a certificate after a failed renewal, doubled on each failure")
+	xdsServerCmd.PersistentFlags().Duration("certificate-max-backoff", certificate.DefaultMaxBackoff, "Max time to wait before checking again a certificate after failed renewals")
+
+	viper.BindPFlag("envoy.certificates.renewBefore", xdsServerCmd.PersistentFlags().Lookup("certificate-renew-before"))
+	viper.BindPFlag("envoy.certificates.checkInterval", xdsServerCmd.PersistentFlags().Lookup("certificate-check-interval"))
+	viper.BindPFlag("envoy.certificates.checkTimeout", xdsServerCmd.PersistentFlags().Lookup("certificate-check-timeout"))
+	viper.BindPFlag("envoy.certificates.minBackoff", xdsServerCmd.PersistentFlags().Lookup("certificate-min-backoff"))
+	viper.BindPFlag("envoy.certificates.maxBackoff", xdsServerCmd.PersistentFlags().Lookup("certificate-max-backoff"))
+
+	xdsServerCmd.PersistentFlags().Bool("internal-ca", false, "Issue SPIFFE identities to the services with an internal CA and require mTLS on their listeners and clusters")
+	xdsServerCmd.PersistentFlags().String("internal-ca-parameter-file-source", "", "Parameter file source with the internal CA certificate chain and private key, a root is generated when the file is empty")
+	xdsServerCmd.PersistentFlags().String("internal-ca-parameter-key", paramapi.WellKnown_WN_MESH_CA.String(), "Parameter key of the internal CA file parameter")
+	xdsServerCmd.PersistentFlags().String("internal-ca-trust-domain", "", "SPIFFE trust domain of the identities, e.g. mesh.example.com")
+	xdsServerCmd.PersistentFlags().Duration("internal-ca-svid-ttl", ca.DefaultSVIDTTL, "Lifetime of the services identity certificates, renewed after half of it")
+
+	viper.BindPFlag("envoy.internalCa.enable", xdsServerCmd.PersistentFlags().Lookup("internal-ca"))
+	viper.BindPFlag("envoy.internalCa.parameterFileSource", xdsServerCmd.PersistentFlags().Lookup("internal-ca-parameter-file-source"))
+	viper.BindPFlag("envoy.internalCa.parameterKey", xdsServerCmd.PersistentFlags().Lookup("internal-ca-parameter-key"))
+	viper.BindPFlag("envoy.internalCa.trustDomain", xdsServerCmd.PersistentFlags().Lookup("internal-ca-trust-domain"))
+	viper.BindPFlag("envoy.internalCa.svidTtl", xdsServerCmd.PersistentFlags().Lookup("internal-ca-svid-ttl"))
+
+	xdsServerCmd.PersistentFlags().Bool("acme", false, "Order the ACME certificates of discovered services, storing them as file parameters")
+	xdsServerCmd.PersistentFlags().String("acme-ca-dir-url", "", "ACME directory URL of certificates without ca_dir_url, required for them (e.g. "+acme.LetsEncryptCADirURL+")")
+	xdsServerCmd.PersistentFlags().String("acme-ca-bundle", "", "PEM file with the CAs trusted to connect to the ACME server (e.g. Pebble)")
+	xdsServerCmd.PersistentFlags().String("acme-file-bucket", "", "Bucket of the file parameters created for ACME accounts and certificates not found in the parameter file source")
+	xdsServerCmd.PersistentFlags().String("acme-file-prefix", "acme", "Object key prefix of the created ACME file parameters")
+	xdsServerCmd.PersistentFlags().String("acme-file-query", "", "Query of the created ACME file parameters, e.g. encryption=kms&encryption_key=alias/hike")
+	xdsServerCmd.PersistentFlags().String("acme-http01-listen-addr", ":80", "Bind address to answer ACME HTTP-01 challenges, disabled when empty")
+	xdsServerCmd.PersistentFlags().Bool("acme-http01-envoy", false, "Answer ACME HTTP-01 challenges through the Envoy virtual hosts of the domains instead of a standalone listener")
+	xdsServerCmd.PersistentFlags().String("acme-http01-envoy-listen-addr", ":8089", "Bind address of the HTTP server answering ACME HTTP-01 challenges routed by Envoy")
+	xdsServerCmd.PersistentFlags().String("acme-http01-envoy-cluster-addr", "", "Address (host:port) Envoy uses to reach the ACME HTTP-01 server (default is the listen address)")
+	xdsServerCmd.PersistentFlags().String("acme-http01-envoy-cluster-name", servicemesh.DefaultAcmeHTTP01Cluster, "Name of the Envoy cluster of the ACME HTTP-01 server")
+	xdsServerCmd.PersistentFlags().Duration("acme-http01-envoy-delay", servicemesh.DefaultAcmeHTTP01Delay, "Time given to Envoy to apply the ACME HTTP-01 challenge route before validation")
+	xdsServerCmd.PersistentFlags().Bool("acme-dns01", false, "Solve ACME DNS-01 challenges (required by wildcard domains) with the enabled domain registry driver")
+	xdsServerCmd.PersistentFlags().StringSlice("acme-dns01-resolvers", []string{}, "Recursive nameservers used to check the propagation of ACME DNS-01 challenge records, default is the system resolver")
+
+	viper.BindPFlag("envoy.acme.enable", xdsServerCmd.PersistentFlags().Lookup("acme"))
+	viper.BindPFlag("envoy.acme.caDirUrl", xdsServerCmd.PersistentFlags().Lookup("acme-ca-dir-url"))
+	viper.BindPFlag("envoy.acme.caBundle", xdsServerCmd.PersistentFlags().Lookup("acme-ca-bundle"))
+	viper.BindPFlag("envoy.acme.fileBucket", xdsServerCmd.PersistentFlags().Lookup("acme-file-bucket"))
+	viper.BindPFlag("envoy.acme.filePrefix", xdsServerCmd.PersistentFlags().Lookup("acme-file-prefix"))
+	viper.BindPFlag("envoy.acme.fileQuery", xdsServerCmd.PersistentFlags().Lookup("acme-file-query"))
+	viper.BindPFlag("envoy.acme.http01ListenAddr", xdsServerCmd.PersistentFlags().Lookup("acme-http01-listen-addr"))
+	viper.BindPFlag("envoy.acme.http01Envoy.enable", xdsServerCmd.PersistentFlags().Lookup("acme-http01-envoy"))
+	viper.BindPFlag("envoy.acme.http01Envoy.listenAddr", xdsServerCmd.PersistentFlags().Lookup("acme-http01-envoy-listen-addr"))
+	viper.BindPFlag("envoy.acme.http01Envoy.clusterAddr", xdsServerCmd.PersistentFlags().Lookup("acme-http01-envoy-cluster-addr"))
+	viper.BindPFlag("envoy.acme.http01Envoy.clusterName", xdsServerCmd.PersistentFlags().Lookup("acme-http01-envoy-cluster-name"))
+	viper.BindPFlag("envoy.acme.http01Envoy.delay", xdsServerCmd.PersistentFlags().Lookup("acme-http01-envoy-delay"))
+	viper.BindPFlag("envoy.acme.dns01.enable", xdsServerCmd.PersistentFlags().Lookup("acme-dns01"))
+	viper.BindPFlag("envoy.acme.dns01.resolvers", xdsServerCmd.PersistentFlags().Lookup("acme-dns01-resolvers"))
+
 	envoyCmd.AddCommand(xdsServerCmd)
 
 }
@@ -0,0 +1,97 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	internal "github.com/upper-institute/hike/internal"
+	"github.com/upper-institute/hike/pkg/parameter"
+)
+
+var (
+	importCmd = &cobra.Command{
+		Use:   "import",
+		Short: "Import parameters from a dotenv, JSON or YAML file, shows the plan and asks for confirmation before writing",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			var (
+				log       = internal.SugaredLogger
+				from      = viper.GetString("parameter.import.from")
+				to        = viper.GetString("parameter.import.to")
+				format    = viper.GetString("parameter.import.format")
+				assumeYes = viper.GetBool("parameter.import.yes")
+			)
+
+			if len(from) == 0 || len(to) == 0 {
+				return fmt.Errorf("Both --from and --to are required")
+			}
+
+			ctx := context.Background()
+
+			if len(format) == 0 {
+				format = parameter.FormatFromFileName(from)
+			}
+
+			data, err := os.ReadFile(from)
+			if err != nil {
+				return err
+			}
+
+			values, err := parameter.ParseImportFile(format, data)
+			if err != nil {
+				return err
+			}
+
+			log.Infow("Parsed import file", "from", from, "format", format, "value_count", len(values))
+
+			target, err := restoreSource(ctx, to)
+			if err != nil {
+				return err
+			}
+
+			plan, err := parameter.NewImportPlan(ctx, values, target, &parameter.ImportOptions{
+				FileBucket:        viper.GetString("parameter.import.fileBucket"),
+				FilePrefix:        viper.GetString("parameter.import.filePrefix"),
+				FileKeys:          viper.GetStringSlice("parameter.import.fileKey"),
+				FileSizeThreshold: viper.GetInt("parameter.import.fileSizeThreshold"),
+			})
+			if err != nil {
+				return err
+			}
+
+			plan.Write(os.Stdout)
+
+			if !plan.HasChanges() {
+				return nil
+			}
+
+			if !assumeYes && !confirm("Apply these changes?") {
+				fmt.Println("Import cancelled")
+				return nil
+			}
+
+			return plan.Apply(ctx)
+		},
+	}
+)
+
+func confirm(question string) bool {
+
+	fmt.Printf("%s [y/N] ", question)
+
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+
+	return answer == "y" || answer == "yes"
+
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/upper-institute/hike/internal"
+	"github.com/upper-institute/hike/pkg/parameter"
 )
 
 var (
@@ -29,10 +30,73 @@ func init() {
 
 	viper.BindPFlag("parameter.load.processEnvs", pullCmd.PersistentFlags().Lookup("load-process-envs"))
 
+	pullCmd.PersistentFlags().StringArray("process-envs-include-prefix", []string{}, "Only load process envs with this prefix")
+	pullCmd.PersistentFlags().StringArray("process-envs-include-regex", []string{}, "Only load process envs matching this regular expression")
+	pullCmd.PersistentFlags().StringArray("process-envs-exclude-prefix", []string{}, "Don't load process envs with this prefix")
+	pullCmd.PersistentFlags().StringArray("process-envs-exclude-regex", []string{}, "Don't load process envs matching this regular expression")
+	pullCmd.PersistentFlags().String("process-envs-strip-prefix", "", "Remove this prefix from process env names to build parameter keys")
+	pullCmd.PersistentFlags().StringArray("process-envs-parse-url", []string{}, "Parse the value of this key (after stripping) as a parameter URL, e.g. file://bucket/key#destination ('*' parses all)")
+
+	viper.BindPFlag("parameter.load.processEnvFilter.includePrefix", pullCmd.PersistentFlags().Lookup("process-envs-include-prefix"))
+	viper.BindPFlag("parameter.load.processEnvFilter.includeRegex", pullCmd.PersistentFlags().Lookup("process-envs-include-regex"))
+	viper.BindPFlag("parameter.load.processEnvFilter.excludePrefix", pullCmd.PersistentFlags().Lookup("process-envs-exclude-prefix"))
+	viper.BindPFlag("parameter.load.processEnvFilter.excludeRegex", pullCmd.PersistentFlags().Lookup("process-envs-exclude-regex"))
+	viper.BindPFlag("parameter.load.processEnvFilter.stripPrefix", pullCmd.PersistentFlags().Lookup("process-envs-strip-prefix"))
+	viper.BindPFlag("parameter.load.processEnvFilter.parseUrl", pullCmd.PersistentFlags().Lookup("process-envs-parse-url"))
+
 	pullCmd.PersistentFlags().StringArray("save-file-from-key", []string{}, "Save files only in the specified key")
 
 	viper.BindPFlag("parameter.saveFileFromKey", pullCmd.PersistentFlags().Lookup("save-file-from-key"))
 
+	pullCmd.PersistentFlags().StringArray("save-dir-from-key", []string{}, "Materialise dir parameters of the key into the directory of their URL fragment")
+
+	viper.BindPFlag("parameter.saveDirFromKey", pullCmd.PersistentFlags().Lookup("save-dir-from-key"))
+
+	pushCmd.PersistentFlags().StringArray("include", []string{}, "Only push files of the dir parameter matching this glob (kept in the URL query for pulls)")
+	pushCmd.PersistentFlags().StringArray("exclude", []string{}, "Don't push files of the dir parameter matching this glob (kept in the URL query for pulls)")
+
+	viper.BindPFlag("parameter.push.include", pushCmd.PersistentFlags().Lookup("include"))
+	viper.BindPFlag("parameter.push.exclude", pushCmd.PersistentFlags().Lookup("exclude"))
+
+	parameterServerCmd.PersistentFlags().Duration("watch-min-interval", parameter.DefaultWatchInterval, "Minimum interval between source restores of a watch stream")
+
+	viper.BindPFlag("parameter.server.watchMinInterval", parameterServerCmd.PersistentFlags().Lookup("watch-min-interval"))
+
+	syncCmd.PersistentFlags().Bool("apply", false, "Apply the plan, otherwise only shows it")
+	syncCmd.PersistentFlags().Bool("prune", false, "Delete target parameters not found in the source")
+	syncCmd.PersistentFlags().String("target-bucket", "", "Copy file parameters to this bucket, rewriting their URLs")
+
+	viper.BindPFlag("parameter.sync.apply", syncCmd.PersistentFlags().Lookup("apply"))
+	viper.BindPFlag("parameter.sync.prune", syncCmd.PersistentFlags().Lookup("prune"))
+	viper.BindPFlag("parameter.sync.targetBucket", syncCmd.PersistentFlags().Lookup("target-bucket"))
+
+	importCmd.PersistentFlags().String("from", "", "File to import (dotenv, JSON or YAML)")
+	importCmd.PersistentFlags().String("to", "", "Parameter source URI to import to (e.g. /prod/app)")
+	importCmd.PersistentFlags().String("format", "", "File format (env, json or yaml), default is detected from the file extension")
+	importCmd.PersistentFlags().String("file-bucket", "", "Bucket to upload values imported as file parameters")
+	importCmd.PersistentFlags().String("file-prefix", "", "Object key prefix of values imported as file parameters")
+	importCmd.PersistentFlags().StringArray("file-key", []string{}, "Import the key as a file parameter (requires --file-bucket)")
+	importCmd.PersistentFlags().Int("file-size-threshold", 0, "Import values bigger than this size in bytes as file parameters (requires --file-bucket), 0 disables")
+	importCmd.PersistentFlags().Bool("yes", false, "Apply without asking for confirmation")
+
+	viper.BindPFlag("parameter.import.from", importCmd.PersistentFlags().Lookup("from"))
+	viper.BindPFlag("parameter.import.to", importCmd.PersistentFlags().Lookup("to"))
+	viper.BindPFlag("parameter.import.format", importCmd.PersistentFlags().Lookup("format"))
+	viper.BindPFlag("parameter.import.fileBucket", importCmd.PersistentFlags().Lookup("file-bucket"))
+	viper.BindPFlag("parameter.import.filePrefix", importCmd.PersistentFlags().Lookup("file-prefix"))
+	viper.BindPFlag("parameter.import.fileKey", importCmd.PersistentFlags().Lookup("file-key"))
+	viper.BindPFlag("parameter.import.fileSizeThreshold", importCmd.PersistentFlags().Lookup("file-size-threshold"))
+	viper.BindPFlag("parameter.import.yes", importCmd.PersistentFlags().Lookup("yes"))
+
+	diffCmd.PersistentFlags().Bool("show-values", false, "Show var values and file checksums, redacted by default")
+
+	viper.BindPFlag("parameter.diff.showValues", diffCmd.PersistentFlags().Lookup("show-values"))
+
 	parameterCmd.AddCommand(pullCmd)
+	parameterCmd.AddCommand(pushCmd)
+	parameterCmd.AddCommand(diffCmd)
+	parameterCmd.AddCommand(importCmd)
+	parameterCmd.AddCommand(syncCmd)
+	parameterCmd.AddCommand(parameterServerCmd)
 
 }
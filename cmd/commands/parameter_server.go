@@ -0,0 +1,32 @@
+package commands
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/upper-institute/hike/pkg/parameter"
+)
+
+var (
+	parameterServerOptions *parameter.ServerOptions
+
+	parameterServerCmd = &cobra.Command{
+		Use:   "server",
+		Short: "Run parameter gRPC service",
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			enableParameterServer(viper.GetDuration("parameter.server.watchMinInterval"))
+
+			return nil
+		},
+	}
+)
+
+func enableParameterServer(watchMinInterval time.Duration) {
+
+	parameterServerOptions = &parameter.ServerOptions{
+		WatchMinInterval: watchMinInterval,
+	}
+
+}
@@ -4,10 +4,12 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"regexp"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	internal "github.com/upper-institute/hike/internal"
+	"github.com/upper-institute/hike/pkg/parameter"
 	paramapi "github.com/upper-institute/hike/proto/api/parameter"
 )
 
@@ -34,7 +36,12 @@ var (
 
 			if loadProcessEnvs {
 
-				err = paramCache.RestoreFromProcessEnvs()
+				processEnvOptions, err := newProcessEnvOptions()
+				if err != nil {
+					return err
+				}
+
+				err = paramCache.RestoreFromProcessEnvs(processEnvOptions)
 				if err != nil {
 					return err
 				}
@@ -76,6 +83,21 @@ var (
 
 			}
 
+			for _, dirKey := range viper.GetStringSlice("parameter.saveDirFromKey") {
+
+				log.Infow("Saving directory from key", "dir_key", dirKey)
+
+				if !paramCache.Has(dirKey) {
+					return fmt.Errorf("Directory not found by key: %s", dirKey)
+				}
+
+				err = paramCache.Get(dirKey).LoadDir(ctx)
+				if err != nil {
+					return fmt.Errorf("Unable to load directory from parameter %s: %w", dirKey, err)
+				}
+
+			}
+
 			if len(args) == 1 {
 
 				log.Infow("Writing env export file", "target", args[0])
@@ -96,6 +118,7 @@ var (
 					envFile.WriteString(param.GetKey())
 					envFile.WriteString("=")
 					envFile.WriteString(param.GetFragment())
+					envFile.WriteString("\n")
 				}
 
 				err = envFile.Close()
@@ -109,3 +132,45 @@ var (
 		},
 	}
 )
+
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+
+	compiled := []*regexp.Regexp{}
+
+	for _, pattern := range patterns {
+
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		compiled = append(compiled, re)
+
+	}
+
+	return compiled, nil
+
+}
+
+func newProcessEnvOptions() (*parameter.ProcessEnvOptions, error) {
+
+	includePatterns, err := compilePatterns(viper.GetStringSlice("parameter.load.processEnvFilter.includeRegex"))
+	if err != nil {
+		return nil, err
+	}
+
+	excludePatterns, err := compilePatterns(viper.GetStringSlice("parameter.load.processEnvFilter.excludeRegex"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &parameter.ProcessEnvOptions{
+		IncludePrefixes: viper.GetStringSlice("parameter.load.processEnvFilter.includePrefix"),
+		IncludePatterns: includePatterns,
+		ExcludePrefixes: viper.GetStringSlice("parameter.load.processEnvFilter.excludePrefix"),
+		ExcludePatterns: excludePatterns,
+		StripPrefix:     viper.GetString("parameter.load.processEnvFilter.stripPrefix"),
+		ParseURLKeys:    viper.GetStringSlice("parameter.load.processEnvFilter.parseUrl"),
+	}, nil
+
+}
@@ -0,0 +1,85 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	internal "github.com/upper-institute/hike/internal"
+	"github.com/upper-institute/hike/pkg/parameter"
+	paramapi "github.com/upper-institute/hike/proto/api/parameter"
+)
+
+var (
+	pushCmd = &cobra.Command{
+		Use:   "push <key> <url>",
+		Short: "Write a parameter to the --parameter-uri path, file and dir parameters upload the local file or directory of the URL fragment (e.g. dir://bucket/lua/#./lua)",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			var (
+				log          = internal.SugaredLogger
+				parameterUri = viper.GetString("parameter.uri")
+				includes     = viper.GetStringSlice("parameter.push.include")
+				excludes     = viper.GetStringSlice("parameter.push.exclude")
+			)
+
+			ctx := context.Background()
+
+			if internal.ParameterSourceOptions.Writer == nil {
+				return parameter.StoreNotEnabledErr
+			}
+
+			sourceUri, err := url.Parse(parameterUri)
+			if err != nil {
+				return err
+			}
+
+			param, err := internal.ParameterSourceOptions.ParameterOptions.NewFromURLString(args[0], args[1])
+			if err != nil {
+				return err
+			}
+
+			if len(includes) > 0 || len(excludes) > 0 {
+
+				if param.GetType() != paramapi.ParameterType_PT_DIR {
+					return fmt.Errorf("Include and exclude globs apply only to dir parameters")
+				}
+
+				query := param.GetQuery()
+
+				for _, include := range includes {
+					query.Add(parameter.IncludeQuery, include)
+				}
+
+				for _, exclude := range excludes {
+					query.Add(parameter.ExcludeQuery, exclude)
+				}
+
+				param.SetQuery(query)
+
+			}
+
+			param.Metadata.Set(parameter.PathPrefixMetadata, sourceUri.Path)
+			param.Metadata.Set(parameter.SourceSchemeMetadata, sourceUri.Scheme)
+
+			if param.GetType() == paramapi.ParameterType_PT_FILE {
+
+				content, err := os.ReadFile(param.GetFragment())
+				if err != nil {
+					return err
+				}
+
+				param.GetFile().Write(content)
+
+			}
+
+			log.Infow("Pushing parameter", "key", param.GetKey(), "url", parameter.RedactedURLString(param))
+
+			return param.Push(ctx)
+		},
+	}
+)
@@ -8,13 +8,20 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
 	grpc_zap "github.com/grpc-ecosystem/go-grpc-middleware/logging/zap"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/upper-institute/hike/internal"
+	"github.com/upper-institute/hike/pkg/authz"
+	"github.com/upper-institute/hike/pkg/gateway"
+	"github.com/upper-institute/hike/pkg/tlsconfig"
+	paramapi "github.com/upper-institute/hike/proto/api/parameter"
 	otelgrpc "go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
 )
@@ -24,6 +31,9 @@ const rootCmdUse = "hike"
 var (
 	cfgFile string
 
+	// Set by commands that need to report a result without an error
+	exitCode int
+
 	serverListener net.Listener
 	grpcServer     *grpc.Server
 	serverMux      = http.NewServeMux()
@@ -37,50 +47,58 @@ var (
 
 			server := &http.Server{Handler: &grpcMatcher{}}
 
-			opts := []grpc.ServerOption{
-				grpc.StreamInterceptor(
-					grpc_middleware.ChainStreamServer(
-						otelgrpc.StreamServerInterceptor(),
-						grpc_zap.StreamServerInterceptor(internal.Logger),
-					),
-				),
-				grpc.UnaryInterceptor(
-					grpc_middleware.ChainUnaryServer(
-						otelgrpc.UnaryServerInterceptor(),
-						grpc_zap.UnaryServerInterceptor(internal.Logger),
-					),
-				),
+			streamInterceptors := []grpc.StreamServerInterceptor{
+				otelgrpc.StreamServerInterceptor(),
+				grpc_zap.StreamServerInterceptor(internal.Logger),
 			}
 
-			tlsCert := viper.GetString("grpcServer.tls.cert")
-			tlsKey := viper.GetString("grpcServer.tls.key")
-
-			cert, err := tls.LoadX509KeyPair(tlsCert, tlsKey)
-			if err != nil {
-				log.Fatalln("failed load TLS certificate (", tlsCert, ") or key (", tlsKey, ") because", err)
+			unaryInterceptors := []grpc.UnaryServerInterceptor{
+				otelgrpc.UnaryServerInterceptor(),
+				grpc_zap.UnaryServerInterceptor(internal.Logger),
 			}
 
-			log.Infow("TLS configuration enabled")
+			authzPolicyPath := viper.GetString("grpcServer.authz.policy")
 
-			config := &tls.Config{
-				Certificates: []tls.Certificate{cert},
-				ClientAuth:   tls.VerifyClientCertIfGiven,
-				NextProtos:   []string{"h2"},
-			}
+			if len(authzPolicyPath) > 0 {
 
-			server.TLSConfig = config
+				// The policy matches the identities of verified client
+				// certificates, plaintext calls would all be denied
+				if serverTLSMode() == tlsconfig.PlaintextMode {
+					log.Fatalln("authorization policy", authzPolicyPath, "requires the tls or mtls mode")
+				}
 
-			listenAddr := viper.GetString("grpcServer.listenAddr")
+				authzOptions := &authz.AuthorizerOptions{
+					PolicyPath:     authzPolicyPath,
+					ReloadInterval: viper.GetDuration("grpcServer.authz.reloadInterval"),
+					DefaultNodeID:  viper.GetString("envoy.nodeId"),
+				}
+
+				authorizer, err := authzOptions.NewAuthorizer(internal.SugaredLogger)
+				if err != nil {
+					log.Fatalln("failed to load authorization policy", authzPolicyPath, "because", err)
+				}
+
+				authorizer.StartReloadCycle()
+
+				streamInterceptors = append(streamInterceptors, authorizer.StreamServerInterceptor())
+				unaryInterceptors = append(unaryInterceptors, authorizer.UnaryServerInterceptor())
+
+				log.Infow("Authorization policy enabled", "policy_path", authzPolicyPath)
 
-			lis, err := tls.Listen("tcp", listenAddr, config)
-			if err != nil {
-				log.Fatalln("failed to listen to store address", listenAddr, "because", err)
 			}
 
-			serverListener = lis
+			streamInterceptor := grpc_middleware.ChainStreamServer(streamInterceptors...)
+			unaryInterceptor := grpc_middleware.ChainUnaryServer(unaryInterceptors...)
+
+			opts := []grpc.ServerOption{
+				grpc.StreamInterceptor(streamInterceptor),
+				grpc.UnaryInterceptor(unaryInterceptor),
+			}
 
 			grpcServer = grpc.NewServer(opts...)
 
+			jsonGateway := gateway.NewGateway(serverMux, unaryInterceptor, streamInterceptor, internal.SugaredLogger)
+
 			isGrpcServer := false
 
 			if discoveryOptions != nil {
@@ -98,11 +116,84 @@ var (
 				discoveryServer.StartDiscoveryCycle()
 
 				discoveryServer.Register(grpcServer)
+				discoveryServer.RegisterSnapshotService(jsonGateway)
+
+				if certificateMonitor != nil {
+
+					certificateServer := certificateMonitor.NewServer()
+
+					certificateServer.Register(grpcServer)
+					certificateServer.Register(jsonGateway)
+
+				}
+
+			}
+
+			if parameterServerOptions != nil {
+
+				isGrpcServer = true
+
+				parameterServerOptions.SourceOptions = internal.ParameterSourceOptions
+
+				parameterServer, err := parameterServerOptions.NewServer(internal.SugaredLogger)
+
+				if err != nil {
+					log.Fatalln(err)
+				}
+
+				parameterServer.Register(grpcServer)
+				parameterServer.Register(jsonGateway)
 
 			}
 
 			if isGrpcServer {
 
+				listenAddr := viper.GetString("grpcServer.listenAddr")
+
+				lis, err := net.Listen("tcp", listenAddr)
+				if err != nil {
+					log.Fatalln("failed to listen to store address", listenAddr, "because", err)
+				}
+
+				tlsMode := serverTLSMode()
+
+				if tlsMode == tlsconfig.PlaintextMode {
+
+					log.Warnw("TLS disabled, serving plaintext HTTP/2")
+
+					server.Handler = h2c.NewHandler(server.Handler, &http2.Server{})
+
+				} else {
+
+					reloaderOptions := &tlsconfig.ReloaderOptions{
+						Mode:                    tlsMode,
+						CertPath:                viper.GetString("grpcServer.tls.cert"),
+						KeyPath:                 viper.GetString("grpcServer.tls.key"),
+						SourceOptions:           internal.ParameterSourceOptions,
+						ParameterFileSource:     viper.GetString("grpcServer.tls.parameterFileSource"),
+						CertificateParameterKey: viper.GetString("grpcServer.tls.certificateParameterKey"),
+						KeyParameterKey:         viper.GetString("grpcServer.tls.keyParameterKey"),
+						ClientCAPath:            viper.GetString("grpcServer.tls.clientCa"),
+						ReloadInterval:          viper.GetDuration("grpcServer.tls.reloadInterval"),
+					}
+
+					reloader, err := reloaderOptions.NewReloader(internal.SugaredLogger)
+					if err != nil {
+						log.Fatalln("failed to load TLS certificate because", err)
+					}
+
+					reloader.StartReloadCycle()
+
+					server.TLSConfig = reloader.TLSConfig()
+
+					lis = tls.NewListener(lis, server.TLSConfig)
+
+					log.Infow("TLS configuration enabled", "mode", tlsMode)
+
+				}
+
+				serverListener = lis
+
 				reflection.Register(grpcServer)
 
 				log.Infow("Server listening", "address", serverListener.Addr())
@@ -113,6 +204,7 @@ var (
 
 			}
 
+			internal.FlushAudit()
 			internal.FlushLogger()
 
 			return nil
@@ -126,6 +218,10 @@ func Execute() {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
+
+	if exitCode != 0 {
+		os.Exit(exitCode)
+	}
 }
 
 func init() {
@@ -133,19 +229,36 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/."+rootCmdUse+".yaml)")
 
 	rootCmd.PersistentFlags().String("listen-addr", "0.0.0.0:7070", "Bind address to store gRPC server")
-	rootCmd.PersistentFlags().Bool("tls", false, "Enable TLS protocol only on gRPC server")
+	rootCmd.PersistentFlags().Bool("tls", false, "Enable TLS protocol only on gRPC server (default, kept for compatibility, see --tls-mode)")
+	rootCmd.PersistentFlags().String("tls-mode", "", "TLS mode of the gRPC server: plaintext, tls (client certificates verified if given) or mtls (client certificates required), default is tls which requires a certificate")
 	rootCmd.PersistentFlags().String("tls-key", "", "PEM encoded private key file path")
 	rootCmd.PersistentFlags().String("tls-cert", "", "PEM encoded certificate file path")
+	rootCmd.PersistentFlags().String("tls-parameter-file-source", "", "Parameter file source with the certificate, used instead of the certificate and key files")
+	rootCmd.PersistentFlags().String("tls-certificate-parameter-key", paramapi.WellKnown_WN_TLS_CERTIFICATE.String(), "File parameter with the PEM certificate chain, followed by the private key when --tls-key-parameter-key is empty")
+	rootCmd.PersistentFlags().String("tls-key-parameter-key", "", "File parameter with the PEM private key")
+	rootCmd.PersistentFlags().String("tls-client-ca", "", "PEM encoded CA bundle file path verifying client certificates, required by mtls mode")
+	rootCmd.PersistentFlags().Duration("tls-reload-interval", tlsconfig.DefaultReloadInterval, "Interval to reload the certificate and client CA bundle when changed, 0 disables reload")
+	rootCmd.PersistentFlags().String("authz-policy", "", "Authorization policy file mapping client certificate identities to allowed methods and xDS node ids (deny by default), disabled when empty")
+	rootCmd.PersistentFlags().Duration("authz-policy-reload-interval", 10*time.Second, "Interval to reload the authorization policy file when changed, 0 disables reload")
 	rootCmd.PersistentFlags().Int("grpc-max-concurrent-streams", 1000000, "Max concurrent streams for gRPC server")
 
 	viper.BindPFlag("grpcServer.listenAddr", rootCmd.PersistentFlags().Lookup("listen-addr"))
 	viper.BindPFlag("grpcServer.tls.enable", rootCmd.PersistentFlags().Lookup("tls"))
 	viper.BindPFlag("grpcServer.tls.key", rootCmd.PersistentFlags().Lookup("tls-key"))
+	viper.BindPFlag("grpcServer.tls.mode", rootCmd.PersistentFlags().Lookup("tls-mode"))
 	viper.BindPFlag("grpcServer.tls.cert", rootCmd.PersistentFlags().Lookup("tls-cert"))
+	viper.BindPFlag("grpcServer.tls.parameterFileSource", rootCmd.PersistentFlags().Lookup("tls-parameter-file-source"))
+	viper.BindPFlag("grpcServer.tls.certificateParameterKey", rootCmd.PersistentFlags().Lookup("tls-certificate-parameter-key"))
+	viper.BindPFlag("grpcServer.tls.keyParameterKey", rootCmd.PersistentFlags().Lookup("tls-key-parameter-key"))
+	viper.BindPFlag("grpcServer.tls.clientCa", rootCmd.PersistentFlags().Lookup("tls-client-ca"))
+	viper.BindPFlag("grpcServer.tls.reloadInterval", rootCmd.PersistentFlags().Lookup("tls-reload-interval"))
+	viper.BindPFlag("grpcServer.authz.policy", rootCmd.PersistentFlags().Lookup("authz-policy"))
+	viper.BindPFlag("grpcServer.authz.reloadInterval", rootCmd.PersistentFlags().Lookup("authz-policy-reload-interval"))
 	viper.BindPFlag("grpcServer.grpc.maxConcurrentStreams", rootCmd.PersistentFlags().Lookup("grpc-max-concurrent-streams"))
 
 	internal.AttachLoggingOptions(rootCmd.PersistentFlags(), viper.GetViper())
 	internal.AttachDriversOptions(rootCmd.PersistentFlags(), viper.GetViper())
+	internal.AttachAuditOptions(rootCmd.PersistentFlags(), viper.GetViper())
 
 	rootCmd.AddCommand(envoyCmd)
 	rootCmd.AddCommand(parameterCmd)
@@ -160,15 +273,35 @@ func (g *grpcMatcher) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	ct := r.Header.Get("Content-Type")
 
-	internal.SugaredLogger.Debugw("New request", "headers", r.Header, "protocol", r.ProtoMajor, "tls", r.TLS.NegotiatedProtocol)
+	negotiatedProtocol := ""
 
-	if strings.Contains(ct, "application/json") {
-		serverMux.ServeHTTP(w, r)
-	} else {
+	if r.TLS != nil {
+		negotiatedProtocol = r.TLS.NegotiatedProtocol
+	}
+
+	internal.SugaredLogger.Debugw("New request", "headers", r.Header, "protocol", r.ProtoMajor, "tls", negotiatedProtocol)
+
+	if r.ProtoMajor == 2 && strings.HasPrefix(ct, "application/grpc") {
 		internal.SugaredLogger.Debugw("gRPC Request")
 		grpcServer.ServeHTTP(w, r)
+	} else {
+		serverMux.ServeHTTP(w, r)
+	}
+
+}
+
+// serverTLSMode returns the configured mode, tls by default so a missing
+// certificate fails instead of serving plaintext
+func serverTLSMode() string {
+
+	mode := viper.GetString("grpcServer.tls.mode")
+
+	if len(mode) > 0 {
+		return mode
 	}
 
+	return tlsconfig.TLSMode
+
 }
 
 func initConfig() {
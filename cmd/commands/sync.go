@@ -0,0 +1,72 @@
+package commands
+
+import (
+	"context"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	internal "github.com/upper-institute/hike/internal"
+	"github.com/upper-institute/hike/pkg/parameter"
+)
+
+var (
+	syncCmd = &cobra.Command{
+		Use:   "sync <source-uri> <target-uri>",
+		Short: "Copy parameters from a source URI to a target URI (e.g. ssm:///staging/app local:///prod/app), shows the plan and only writes with --apply",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			var (
+				log   = internal.SugaredLogger
+				apply = viper.GetBool("parameter.sync.apply")
+			)
+
+			ctx := context.Background()
+
+			source, err := restoreSource(ctx, args[0])
+			if err != nil {
+				return err
+			}
+
+			target, err := restoreSource(ctx, args[1])
+			if err != nil {
+				return err
+			}
+
+			plan, err := parameter.NewSyncPlan(ctx, source, target, &parameter.SyncOptions{
+				TargetBucket: viper.GetString("parameter.sync.targetBucket"),
+				Prune:        viper.GetBool("parameter.sync.prune"),
+			})
+			if err != nil {
+				return err
+			}
+
+			plan.Write(os.Stdout)
+
+			if !apply || !plan.HasChanges() {
+				return nil
+			}
+
+			log.Infow("Applying sync plan", "source_uri", args[0], "target_uri", args[1])
+
+			return plan.Apply(ctx)
+		},
+	}
+)
+
+func restoreSource(ctx context.Context, uri string) (*parameter.Source, error) {
+
+	source, err := internal.ParameterSourceOptions.NewFromURLString(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	err = source.Restore(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return source, nil
+
+}
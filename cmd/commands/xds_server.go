@@ -1,13 +1,23 @@
 package commands
 
 import (
+	"fmt"
+	"net"
+	"net/url"
+
+	"github.com/go-acme/lego/v4/challenge/http01"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"github.com/upper-institute/hike/internal"
+	"github.com/upper-institute/hike/pkg/acme"
+	"github.com/upper-institute/hike/pkg/ca"
+	"github.com/upper-institute/hike/pkg/certificate"
 	"github.com/upper-institute/hike/pkg/servicemesh"
 )
 
 var (
-	discoveryOptions *servicemesh.EnvoyDiscoveryOptions
+	discoveryOptions   *servicemesh.EnvoyDiscoveryOptions
+	certificateMonitor *certificate.Monitor
 
 	xdsServerCmd = &cobra.Command{
 		Use:   "xds-server",
@@ -24,7 +34,159 @@ var (
 				WatchInterval: discoveryMinInterval,
 			}
 
+			secretLoaderOptions := &servicemesh.SecretLoaderOptions{
+				SourceOptions:  internal.ParameterSourceOptions,
+				ReloadInterval: viper.GetDuration("envoy.secretReloadInterval"),
+			}
+
+			secretLoader := secretLoaderOptions.NewSecretLoader(internal.SugaredLogger)
+
+			discoveryOptions.Mutators = append(discoveryOptions.Mutators, secretLoader)
+
+			monitorOptions := &certificate.MonitorOptions{
+				SourceOptions: internal.ParameterSourceOptions,
+				RenewBefore:   viper.GetDuration("envoy.certificates.renewBefore"),
+				CheckInterval: viper.GetDuration("envoy.certificates.checkInterval"),
+				CheckTimeout:  viper.GetDuration("envoy.certificates.checkTimeout"),
+				MinBackoff:    viper.GetDuration("envoy.certificates.minBackoff"),
+				MaxBackoff:    viper.GetDuration("envoy.certificates.maxBackoff"),
+				OnRenewed: func(cert *certificate.Certificate) {
+					secretLoader.Invalidate(cert.Name)
+					discoveryOptions.Refresh()
+				},
+			}
+
+			if viper.GetBool("envoy.acme.enable") {
+
+				acmeManager, err := newAcmeManager()
+				if err != nil {
+					return err
+				}
+
+				monitorOptions.Providers = append(monitorOptions.Providers, acmeManager)
+
+			}
+
+			if viper.GetBool("envoy.internalCa.enable") {
+
+				authorityOptions := &ca.AuthorityOptions{
+					SourceOptions:       internal.ParameterSourceOptions,
+					ParameterFileSource: viper.GetString("envoy.internalCa.parameterFileSource"),
+					ParameterKey:        viper.GetString("envoy.internalCa.parameterKey"),
+					TrustDomain:         viper.GetString("envoy.internalCa.trustDomain"),
+					SVIDTTL:             viper.GetDuration("envoy.internalCa.svidTtl"),
+					NodeID:              nodeId,
+				}
+
+				authority, err := authorityOptions.NewAuthority(internal.SugaredLogger)
+				if err != nil {
+					return err
+				}
+
+				monitorOptions.Providers = append(monitorOptions.Providers, authority)
+
+				discoveryOptions.Mutators = append(discoveryOptions.Mutators, authority)
+
+			}
+
+			monitor, err := monitorOptions.NewMonitor(internal.SugaredLogger)
+			if err != nil {
+				return err
+			}
+
+			certificateMonitor = monitor
+
+			certificateMonitor.StartCycle()
+
+			discoveryOptions.Observers = append(discoveryOptions.Observers, certificateMonitor)
+
+			serverMux.Handle("/metrics", certificateMonitor)
+
+			if viper.GetBool("envoy.parameterService") {
+				enableParameterServer(viper.GetDuration("envoy.parameterWatchMinInterval"))
+			}
+
 			return nil
 		},
 	}
 )
+
+func newHTTP01Responder() (*servicemesh.HTTP01Responder, error) {
+
+	responderOptions := &servicemesh.HTTP01ResponderOptions{
+		ListenAddr:  viper.GetString("envoy.acme.http01Envoy.listenAddr"),
+		ClusterAddr: viper.GetString("envoy.acme.http01Envoy.clusterAddr"),
+		ClusterName: viper.GetString("envoy.acme.http01Envoy.clusterName"),
+		Refresh:     discoveryOptions.Refresh,
+		Timeout:     servicemesh.DefaultAcmeHTTP01Timeout,
+		Delay:       viper.GetDuration("envoy.acme.http01Envoy.delay"),
+	}
+
+	if len(responderOptions.ClusterAddr) == 0 {
+		responderOptions.ClusterAddr = responderOptions.ListenAddr
+	}
+
+	responder, err := responderOptions.NewResponder(internal.SugaredLogger)
+	if err != nil {
+		return nil, err
+	}
+
+	return responder, responder.StartServer()
+
+}
+
+func newAcmeManager() (*acme.Manager, error) {
+
+	fileQuery, err := url.ParseQuery(viper.GetString("envoy.acme.fileQuery"))
+	if err != nil {
+		return nil, err
+	}
+
+	acmeOptions := &acme.ManagerOptions{
+		SourceOptions:   internal.ParameterSourceOptions,
+		FileBucket:      viper.GetString("envoy.acme.fileBucket"),
+		FilePrefix:      viper.GetString("envoy.acme.filePrefix"),
+		FileQuery:       fileQuery,
+		DefaultCADirURL: viper.GetString("envoy.acme.caDirUrl"),
+		CABundlePath:    viper.GetString("envoy.acme.caBundle"),
+	}
+
+	http01ListenAddr := viper.GetString("envoy.acme.http01ListenAddr")
+
+	if viper.GetBool("envoy.acme.http01Envoy.enable") {
+
+		responder, err := newHTTP01Responder()
+		if err != nil {
+			return nil, err
+		}
+
+		acmeOptions.HTTP01Provider = responder
+
+		discoveryOptions.Mutators = append(discoveryOptions.Mutators, responder)
+
+	} else if len(http01ListenAddr) > 0 {
+
+		host, port, err := net.SplitHostPort(http01ListenAddr)
+		if err != nil {
+			return nil, err
+		}
+
+		acmeOptions.HTTP01Provider = http01.NewProviderServer(host, port)
+
+	}
+
+	if viper.GetBool("envoy.acme.dns01.enable") {
+
+		acmeOptions.DNS01Provider = internal.DNS01Provider()
+
+		if acmeOptions.DNS01Provider == nil {
+			return nil, fmt.Errorf("No driver to solve ACME DNS-01 challenges, enable a domain registry (e.g. --drivers-aws-route53-domain-registry-enable or --drivers-rfc2136-domain-registry-enable)")
+		}
+
+		acmeOptions.DNS01Resolvers = viper.GetStringSlice("envoy.acme.dns01.resolvers")
+
+	}
+
+	return acmeOptions.NewManager(internal.SugaredLogger)
+
+}
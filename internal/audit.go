@@ -0,0 +1,85 @@
+package internal
+
+import (
+	"context"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"github.com/upper-institute/hike/pkg/audit"
+)
+
+var (
+	Auditor *audit.Auditor
+
+	auditViper *viper.Viper
+)
+
+func AttachAuditOptions(flagSet *pflag.FlagSet, viperInstance *viper.Viper) {
+
+	flagSet.String("audit-sink", "", "Audit log of parameter access: stdout, stderr, a local file path or a file parameter URL prefix (e.g. file://bucket/audit), disabled when empty")
+	flagSet.Duration("audit-flush-interval", audit.DefaultFlushInterval, "Interval to upload audit records when the sink is a file parameter URL prefix")
+
+	viperInstance.BindPFlag("audit.sink", flagSet.Lookup("audit-sink"))
+	viperInstance.BindPFlag("audit.flushInterval", flagSet.Lookup("audit-flush-interval"))
+
+	auditViper = viperInstance
+
+}
+
+func LoadAudit(ctx context.Context) {
+
+	sink := auditViper.GetString("audit.sink")
+
+	if len(sink) == 0 {
+		return
+	}
+
+	principal := ""
+
+	for _, driver := range Drivers {
+
+		driverPrincipal, err := driver.GetPrincipal(ctx)
+		if err != nil {
+			SugaredLogger.Fatalw("Error loading driver principal for audit", "error", err)
+		}
+
+		if len(driverPrincipal) > 0 {
+			principal = driverPrincipal
+		}
+
+	}
+
+	// Audit sink must upload through the storage without being audited
+	parameterOptions := *ParameterSourceOptions.ParameterOptions
+
+	auditorOptions := &audit.AuditorOptions{
+		Sink:             sink,
+		Principal:        principal,
+		ParameterOptions: &parameterOptions,
+		FlushInterval:    auditViper.GetDuration("audit.flushInterval"),
+	}
+
+	auditor, err := auditorOptions.NewAuditor(SugaredLogger)
+	if err != nil {
+		SugaredLogger.Fatalw("Error loading audit", "error", err)
+	}
+
+	auditor.Wrap(ParameterSourceOptions)
+
+	Auditor = auditor
+
+	SugaredLogger.Infow("Parameter audit enabled", "sink", sink, "principal", principal)
+
+}
+
+func FlushAudit() {
+
+	if Auditor == nil {
+		return
+	}
+
+	if err := Auditor.Sync(); err != nil {
+		SugaredLogger.Errorw("Unable to flush audit records", "error", err)
+	}
+
+}
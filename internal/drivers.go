@@ -2,7 +2,9 @@ package internal
 
 import (
 	"context"
+	"errors"
 
+	"github.com/go-acme/lego/v4/challenge"
 	"github.com/upper-institute/hike/pkg/drivers"
 	"github.com/upper-institute/hike/pkg/parameter"
 	"github.com/upper-institute/hike/pkg/servicemesh"
@@ -12,8 +14,12 @@ import (
 	"github.com/spf13/viper"
 )
 
+var MultipleDomainRegistriesErr = errors.New("Only one domain registry can be enabled, found more than one driver managing DNS")
+
 var (
-	AWSDriver = &drivers.AWSDriver{}
+	AWSDriver     = &drivers.AWSDriver{}
+	LocalDriver   = &drivers.LocalDriver{}
+	RFC2136Driver = &drivers.RFC2136Driver{}
 
 	ParameterSourceOptions = &parameter.SourceOptions{
 		ParameterOptions: &parameter.ParameterOptions{},
@@ -21,6 +27,8 @@ var (
 
 	Drivers = []Driver{
 		AWSDriver,
+		LocalDriver,
+		RFC2136Driver,
 	}
 
 	EnvoyDiscoveryServices = []servicemesh.EnvoyDiscoveryService{}
@@ -48,19 +56,82 @@ func LoadDrivers(ctx context.Context) {
 
 	}
 
+	LoadAudit(ctx)
+
+	domainRegistry, err := DomainRegistry()
+	if err != nil {
+		SugaredLogger.Fatalw("Error loading domain registry", "error", err)
+	}
+
 	for _, driver := range Drivers {
 		EnvoyDiscoveryServices = append(
 			EnvoyDiscoveryServices,
-			driver.GetEnvoyDiscoveryServices(ParameterSourceOptions)...,
+			driver.GetEnvoyDiscoveryServices(ParameterSourceOptions, domainRegistry)...,
 		)
 	}
 
 }
 
+// DNS01Provider returns the ACME DNS-01 challenge solver of the driver
+// managing DNS, nil when none does
+func DNS01Provider() challenge.Provider {
+
+	for _, driver := range Drivers {
+
+		if provider := driver.GetDNS01Provider(); provider != nil {
+			return provider
+		}
+
+	}
+
+	return nil
+
+}
+
+// DomainRegistry returns the domain registry of the driver managing DNS, nil
+// when none does, fails when several drivers do (e.g. Route53 and RFC 2136)
+// instead of silently ignoring all but one
+func DomainRegistry() (servicemesh.DomainRegistry, error) {
+
+	var domainRegistry servicemesh.DomainRegistry
+
+	for _, driver := range Drivers {
+
+		registry := driver.GetDomainRegistry()
+
+		if registry == nil {
+			continue
+		}
+
+		if domainRegistry != nil {
+			return nil, MultipleDomainRegistriesErr
+		}
+
+		domainRegistry = registry
+
+	}
+
+	return domainRegistry, nil
+
+}
+
 type Driver interface {
 	ApplyParameterSourceOptions(opts *parameter.SourceOptions)
 
-	GetEnvoyDiscoveryServices(opts *parameter.SourceOptions) []servicemesh.EnvoyDiscoveryService
+	// Discovery services registering the DNS records of their services in the
+	// domain registry, which may be nil
+	GetEnvoyDiscoveryServices(opts *parameter.SourceOptions, domainRegistry servicemesh.DomainRegistry) []servicemesh.EnvoyDiscoveryService
+
+	// Registry of the DNS records of the services, nil when the driver doesn't
+	// manage DNS
+	GetDomainRegistry() servicemesh.DomainRegistry
+
+	// Solver of ACME DNS-01 challenges, nil when the driver doesn't manage DNS
+	GetDNS01Provider() challenge.Provider
+
+	// Identity used by the driver on the cloud provider (e.g. IAM principal),
+	// empty when the driver doesn't access parameters
+	GetPrincipal(ctx context.Context) (string, error)
 
 	Bind(flagSet *pflag.FlagSet, cfg *viper.Viper)
 	Load(ctx context.Context, logger *zap.SugaredLogger) error
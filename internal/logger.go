@@ -1,6 +1,8 @@
 package internal
 
 import (
+	"os"
+
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
@@ -46,7 +48,7 @@ func LoadLogger(viperInstance *viper.Viper) {
 
 	Configuration.EncoderConfig.EncodeTime = zapcore.RFC3339TimeEncoder
 
-	logger, err := Configuration.Build()
+	logger, err := Configuration.Build(zap.WithFatalHook(fatalHook{}))
 	if err != nil {
 		panic(err)
 	}
@@ -60,3 +62,16 @@ func FlushLogger() {
 	Logger.Sync()
 
 }
+
+// fatalHook flushes the audit records and the logger before exiting, Fatal
+// calls would otherwise lose the records still buffered by the audit sink
+type fatalHook struct{}
+
+func (h fatalHook) OnWrite(entry *zapcore.CheckedEntry, fields []zapcore.Field) {
+
+	FlushAudit()
+	FlushLogger()
+
+	os.Exit(1)
+
+}
@@ -0,0 +1,65 @@
+package acme
+
+import (
+	"crypto"
+	"encoding/json"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/registration"
+)
+
+// account is the ACME account stored in the account file parameter
+type account struct {
+	Email        string                 `json:"email"`
+	CADirURL     string                 `json:"ca_dir_url"`
+	Registration *registration.Resource `json:"registration"`
+	Key          string                 `json:"key"`
+
+	privateKey crypto.PrivateKey
+}
+
+func newAccount(email string, caDirURL string) (*account, error) {
+
+	privateKey, err := certcrypto.GeneratePrivateKey(certcrypto.EC256)
+	if err != nil {
+		return nil, err
+	}
+
+	return &account{
+		Email:      email,
+		CADirURL:   caDirURL,
+		Key:        string(certcrypto.PEMEncode(privateKey)),
+		privateKey: privateKey,
+	}, nil
+
+}
+
+func parseAccount(data []byte) (*account, error) {
+
+	acc := &account{}
+
+	err := json.Unmarshal(data, acc)
+	if err != nil {
+		return nil, err
+	}
+
+	acc.privateKey, err = certcrypto.ParsePEMPrivateKey([]byte(acc.Key))
+	if err != nil {
+		return nil, err
+	}
+
+	return acc, nil
+
+}
+
+func (a *account) GetEmail() string {
+	return a.Email
+}
+
+func (a *account) GetRegistration() *registration.Resource {
+	return a.Registration
+}
+
+func (a *account) GetPrivateKey() crypto.PrivateKey {
+	return a.privateKey
+}
@@ -0,0 +1,14 @@
+package acme
+
+import "errors"
+
+var (
+	UnknownKeyTypeErr         = errors.New("Unknown certificate key type, use EC256, EC384, RSA2048, RSA4096 or RSA8192")
+	NoChallengeProviderErr    = errors.New("No ACME challenge provider enabled")
+	MissingParameterSourceErr = errors.New("ACME certificate without parameter file source")
+	MissingDomainsErr         = errors.New("ACME certificate without domains")
+	NotFileParameterErr       = errors.New("ACME account and certificate parameters must be a file type")
+	MissingFileBucketErr      = errors.New("No file bucket to create ACME parameters")
+	InvalidCABundleErr        = errors.New("No certificate found in the ACME CA bundle")
+	MissingCADirURLErr        = errors.New("ACME certificate without ca_dir_url and no default ACME directory URL")
+)
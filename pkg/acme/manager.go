@@ -0,0 +1,422 @@
+package acme
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+	legocertificate "github.com/go-acme/lego/v4/certificate"
+	"github.com/go-acme/lego/v4/challenge"
+	"github.com/go-acme/lego/v4/challenge/dns01"
+	"github.com/go-acme/lego/v4/lego"
+	"github.com/go-acme/lego/v4/registration"
+	"github.com/upper-institute/hike/pkg/certificate"
+	"github.com/upper-institute/hike/pkg/helpers"
+	"github.com/upper-institute/hike/pkg/parameter"
+	"github.com/upper-institute/hike/pkg/servicemesh"
+	paramapi "github.com/upper-institute/hike/proto/api/parameter"
+	sdapi "github.com/upper-institute/hike/proto/api/service-discovery"
+	"go.uber.org/zap"
+)
+
+const (
+	// There is no default ACME directory, certificates without ca_dir_url
+	// need ManagerOptions.DefaultCADirURL, e.g. one of these
+	LetsEncryptCADirURL        = lego.LEDirectoryProduction
+	LetsEncryptStagingCADirURL = lego.LEDirectoryStaging
+
+	DefaultUserAgent = "hike/latest"
+	DefaultKeyType   = "RSA4096"
+
+	Issuer = "acme"
+)
+
+var keyTypes = map[string]certcrypto.KeyType{
+	"EC256":   certcrypto.EC256,
+	"EC384":   certcrypto.EC384,
+	"RSA2048": certcrypto.RSA2048,
+	"RSA4096": certcrypto.RSA4096,
+	"RSA8192": certcrypto.RSA8192,
+}
+
+type ManagerOptions struct {
+	SourceOptions *parameter.SourceOptions
+	// Bucket and object key prefix of the file parameters created for accounts
+	// and certificates not found in the parameter file source
+	FileBucket string
+	FilePrefix string
+	// Query of the created file parameters, e.g. encryption=kms&encryption_key=alias/hike
+	FileQuery url.Values
+	// Used by certificates without ca_dir_url, empty requires ca_dir_url in
+	// every certificate
+	DefaultCADirURL string
+	// PEM file with the CAs trusted to connect to the ACME server (e.g. Pebble)
+	CABundlePath   string
+	HTTP01Provider challenge.Provider
+	DNS01Provider  challenge.Provider
+	// Recursive nameservers (host:port) used to find the authoritative ones
+	// of DNS-01 challenges, default is the system resolver
+	DNS01Resolvers []string
+}
+
+func (options *ManagerOptions) NewManager(logger *zap.SugaredLogger) (*Manager, error) {
+
+	if options.HTTP01Provider == nil && options.DNS01Provider == nil {
+		return nil, NoChallengeProviderErr
+	}
+
+	m := &Manager{
+		options: options,
+		logger:  logger.With("part", "acme/manager"),
+	}
+
+	if len(options.CABundlePath) > 0 {
+
+		bundle, err := os.ReadFile(options.CABundlePath)
+		if err != nil {
+			return nil, err
+		}
+
+		pool := x509.NewCertPool()
+
+		if !pool.AppendCertsFromPEM(bundle) {
+			return nil, InvalidCABundleErr
+		}
+
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+
+		m.httpClient = &http.Client{
+			Timeout:   2 * time.Minute,
+			Transport: transport,
+		}
+
+	}
+
+	return m, nil
+
+}
+
+// Manager registers ACME accounts and orders the certificates of the
+// discovered services, storing them as file parameters. The certificate
+// monitor decides when to order them.
+type Manager struct {
+	options *ManagerOptions
+
+	logger *zap.SugaredLogger
+
+	httpClient *http.Client
+
+	// Orders are serialized, challenge providers are shared
+	mu sync.Mutex
+}
+
+func certificateKey(cert *sdapi.AcmeProtocolCertificate) string {
+
+	if len(cert.CertificateParameterKey) > 0 {
+		return cert.CertificateParameterKey
+	}
+
+	return paramapi.WellKnown_WN_TLS_CERTIFICATE.String()
+
+}
+
+func accountKey(cert *sdapi.AcmeProtocolCertificate) string {
+
+	if len(cert.AccountParameterKey) > 0 {
+		return cert.AccountParameterKey
+	}
+
+	return paramapi.WellKnown_WN_TLS_ACCOUNT.String()
+
+}
+
+// Certificates implements certificate.Provider with the ACME certificates
+// of the services
+func (m *Manager) Certificates(services []*sdapi.Service) []*certificate.Certificate {
+
+	certs := []*certificate.Certificate{}
+
+	for _, svc := range services {
+
+		for _, acmeCert := range svc.AcmeProtocolCertificates {
+
+			acmeCert := acmeCert
+
+			certs = append(certs, &certificate.Certificate{
+				Name: servicemesh.TlsSecretName(&sdapi.TlsCertificate{
+					ParameterFileSource:     acmeCert.ParameterFileSource,
+					CertificateParameterKey: acmeCert.CertificateParameterKey,
+				}),
+				Issuer:                  Issuer,
+				ParameterFileSource:     acmeCert.ParameterFileSource,
+				CertificateParameterKey: certificateKey(acmeCert),
+				Domains:                 acmeCert.Domains,
+				Renew: func(ctx context.Context) error {
+					return m.Obtain(ctx, acmeCert)
+				},
+			})
+
+		}
+
+	}
+
+	return certs
+
+}
+
+// Obtain orders the certificate, registering the account when needed, and
+// stores it with its private key in the certificate file parameter
+func (m *Manager) Obtain(ctx context.Context, cert *sdapi.AcmeProtocolCertificate) error {
+
+	if len(cert.ParameterFileSource) == 0 {
+		return MissingParameterSourceErr
+	}
+
+	if len(cert.Domains) == 0 {
+		return MissingDomainsErr
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	logger := m.logger.With("certificate_parameter_key", certificateKey(cert), "domains", cert.Domains)
+
+	source, err := m.options.SourceOptions.NewFromURLString(cert.ParameterFileSource)
+	if err != nil {
+		return err
+	}
+
+	err = source.Restore(ctx)
+	if err != nil {
+		return err
+	}
+
+	certParam, _, err := m.getFileParameter(ctx, source, certificateKey(cert))
+	if err != nil {
+		return err
+	}
+
+	acc, accParam, err := m.loadAccount(ctx, source, cert)
+	if err != nil {
+		return err
+	}
+
+	client, err := m.newClient(acc, cert)
+	if err != nil {
+		return err
+	}
+
+	if acc.Registration == nil {
+
+		logger.Infow("Registering ACME account", "account_email", acc.Email, "ca_dir_url", acc.CADirURL)
+
+		acc.Registration, err = client.Registration.Register(registration.RegisterOptions{TermsOfServiceAgreed: true})
+		if err != nil {
+			return err
+		}
+
+		data, err := json.Marshal(acc)
+		if err != nil {
+			return err
+		}
+
+		accParam.GetFile().Reset()
+		accParam.GetFile().Write(data)
+
+		err = accParam.Push(ctx)
+		if err != nil {
+			return err
+		}
+
+	}
+
+	logger.Infow("Obtaining ACME certificate")
+
+	res, err := client.Certificate.Obtain(legocertificate.ObtainRequest{
+		Domains: cert.Domains,
+		Bundle:  true,
+	})
+	if err != nil {
+		return err
+	}
+
+	leaf, err := helpers.ParseLeafCertificate(res.Certificate)
+	if err != nil {
+		return err
+	}
+
+	certParam.GetFile().Reset()
+	certParam.GetFile().Write(res.Certificate)
+	certParam.GetFile().Write(res.PrivateKey)
+
+	err = certParam.Push(ctx)
+	if err != nil {
+		return err
+	}
+
+	logger.Infow("ACME certificate stored", "not_after", leaf.NotAfter)
+
+	return nil
+
+}
+
+// getFileParameter loads the file parameter of the source, or creates one
+// (without pushing it) inside the file bucket when not found
+func (m *Manager) getFileParameter(ctx context.Context, source *parameter.Source, key string) (*parameter.Parameter, bool, error) {
+
+	if source.Has(key) {
+
+		param := source.Get(key)
+
+		if param.GetType() != paramapi.ParameterType_PT_FILE {
+			return nil, false, NotFileParameterErr
+		}
+
+		err := param.Load(ctx)
+
+		if errors.Is(err, parameter.FileNotFoundErr) {
+			return param, false, nil
+		}
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		return param, param.GetFile().Len() > 0, nil
+
+	}
+
+	if len(m.options.FileBucket) == 0 {
+		return nil, false, MissingFileBucketErr
+	}
+
+	sourceUri := source.GetURI()
+
+	param, err := m.options.SourceOptions.ParameterOptions.NewFromURI(key, &url.URL{
+		Scheme:   parameter.FileScheme,
+		Host:     m.options.FileBucket,
+		Path:     path.Join("/", m.options.FilePrefix, sourceUri.Path, key),
+		RawQuery: m.options.FileQuery.Encode(),
+		Fragment: key,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	param.Metadata.Set(parameter.PathPrefixMetadata, sourceUri.Path)
+	param.Metadata.Set(parameter.SourceSchemeMetadata, sourceUri.Scheme)
+
+	return param, false, nil
+
+}
+
+// loadAccount returns the stored account, or a new one without registration
+// when not found or registered with other e-mail or CA
+func (m *Manager) loadAccount(ctx context.Context, source *parameter.Source, cert *sdapi.AcmeProtocolCertificate) (*account, *parameter.Parameter, error) {
+
+	caDirURL := cert.CaDirUrl
+
+	if len(caDirURL) == 0 {
+		caDirURL = m.options.DefaultCADirURL
+	}
+
+	if len(caDirURL) == 0 {
+		return nil, nil, MissingCADirURLErr
+	}
+
+	accParam, found, err := m.getFileParameter(ctx, source, accountKey(cert))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if found {
+
+		acc, err := parseAccount(accParam.GetFile().Bytes())
+
+		if err == nil && acc.Email == cert.AccountEmail && acc.CADirURL == caDirURL {
+			return acc, accParam, nil
+		}
+
+		m.logger.Warnw("Stored ACME account is invalid or for other e-mail or CA, registering a new one", "account_parameter_key", accountKey(cert))
+
+	}
+
+	acc, err := newAccount(cert.AccountEmail, caDirURL)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return acc, accParam, nil
+
+}
+
+func (m *Manager) newClient(acc *account, cert *sdapi.AcmeProtocolCertificate) (*lego.Client, error) {
+
+	keyTypeName := cert.KeyType
+
+	if len(keyTypeName) == 0 {
+		keyTypeName = DefaultKeyType
+	}
+
+	keyType, ok := keyTypes[strings.ToUpper(keyTypeName)]
+	if !ok {
+		return nil, UnknownKeyTypeErr
+	}
+
+	config := lego.NewConfig(acc)
+
+	config.CADirURL = acc.CADirURL
+	config.Certificate.KeyType = keyType
+	config.UserAgent = cert.UserAgent
+
+	if len(config.UserAgent) == 0 {
+		config.UserAgent = DefaultUserAgent
+	}
+
+	if m.httpClient != nil {
+		config.HTTPClient = m.httpClient
+	}
+
+	client, err := lego.NewClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.options.HTTP01Provider != nil {
+
+		err = client.Challenge.SetHTTP01Provider(m.options.HTTP01Provider)
+		if err != nil {
+			return nil, err
+		}
+
+	}
+
+	if m.options.DNS01Provider != nil {
+
+		err = client.Challenge.SetDNS01Provider(
+			m.options.DNS01Provider,
+			dns01.CondOption(
+				len(m.options.DNS01Resolvers) > 0,
+				dns01.AddRecursiveNameservers(dns01.ParseNameservers(m.options.DNS01Resolvers)),
+			),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+	}
+
+	return client, nil
+
+}
@@ -0,0 +1,187 @@
+//go:build integration
+
+package acme
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/go-acme/lego/v4/challenge/http01"
+	localdriver "github.com/upper-institute/hike/pkg/drivers/local"
+	"github.com/upper-institute/hike/pkg/helpers"
+	"github.com/upper-institute/hike/pkg/parameter"
+	sdapi "github.com/upper-institute/hike/proto/api/service-discovery"
+	"go.uber.org/zap"
+)
+
+// Runs against a Pebble server (https://github.com/letsencrypt/pebble), e.g.
+//
+//	PEBBLE_VA_ALWAYS_VALID=1 pebble -config test/config/pebble-config.json
+//	HIKE_PEBBLE_CA_BUNDLE=test/certs/pebble.minica.pem go test -tags integration ./pkg/acme/
+//
+// Without PEBBLE_VA_ALWAYS_VALID the domain must resolve to this host, the
+// HTTP-01 challenge is served on HIKE_PEBBLE_HTTP01_PORT (Pebble httpPort)
+const (
+	pebbleDirURLEnv      = "HIKE_PEBBLE_DIR_URL"
+	pebbleCABundleEnv    = "HIKE_PEBBLE_CA_BUNDLE"
+	pebbleDomainEnv      = "HIKE_PEBBLE_DOMAIN"
+	pebbleHTTP01PortEnv  = "HIKE_PEBBLE_HTTP01_PORT"
+	defaultPebbleDirURL  = "https://localhost:14000/dir"
+	defaultPebbleDomain  = "hike.test"
+	defaultPebbleHTTP01  = "5002"
+	pebbleFileBucket     = "pebble"
+	pebbleParameterStore = "local:///acme"
+)
+
+func pebbleEnv(name string, defaultValue string) string {
+
+	if value := os.Getenv(name); len(value) > 0 {
+		return value
+	}
+
+	return defaultValue
+
+}
+
+// memStorage keeps the file parameters in memory, by bucket and path
+type memStorage struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+func (s *memStorage) Download(ctx context.Context, param *parameter.Parameter) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	content, ok := s.files[param.GetHost()+param.GetPath()]
+	if !ok {
+		return parameter.FileNotFoundErr
+	}
+
+	_, err := param.GetFile().Write(content)
+
+	return err
+
+}
+
+func (s *memStorage) Upload(ctx context.Context, param *parameter.Parameter) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.files[param.GetHost()+param.GetPath()] = append([]byte(nil), param.GetFile().Bytes()...)
+
+	return nil
+
+}
+
+func newPebbleManager(t *testing.T, caBundlePath string) (*Manager, *memStorage) {
+
+	storage := &memStorage{files: make(map[string][]byte)}
+
+	sourceOptions := &parameter.SourceOptions{
+		ParameterOptions: &parameter.ParameterOptions{
+			Downloader: storage,
+			Uploader:   storage,
+		},
+	}
+
+	sourceOptions.RegisterStore(
+		localdriver.FSParameterStoreScheme,
+		localdriver.NewFSParameterStore(t.TempDir(), zap.NewNop().Sugar()),
+	)
+
+	options := &ManagerOptions{
+		SourceOptions:   sourceOptions,
+		FileBucket:      pebbleFileBucket,
+		DefaultCADirURL: pebbleEnv(pebbleDirURLEnv, defaultPebbleDirURL),
+		CABundlePath:    caBundlePath,
+		HTTP01Provider:  http01.NewProviderServer("", pebbleEnv(pebbleHTTP01PortEnv, defaultPebbleHTTP01)),
+	}
+
+	m, err := options.NewManager(zap.NewExample().Sugar())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return m, storage
+
+}
+
+func TestPebbleObtain(t *testing.T) {
+
+	caBundlePath := os.Getenv(pebbleCABundleEnv)
+
+	if len(caBundlePath) == 0 {
+		t.Skipf("%s not set, no Pebble server to test against", pebbleCABundleEnv)
+	}
+
+	ctx := context.Background()
+	domain := pebbleEnv(pebbleDomainEnv, defaultPebbleDomain)
+
+	cert := &sdapi.AcmeProtocolCertificate{
+		ParameterFileSource: pebbleParameterStore,
+		AccountEmail:        "ops@" + domain,
+		Domains:             []string{domain},
+		KeyType:             "EC256",
+	}
+
+	t.Run("untrusted without CA bundle", func(t *testing.T) {
+
+		m, _ := newPebbleManager(t, "")
+
+		if err := m.Obtain(ctx, cert); err == nil {
+			t.Fatal("expected an error connecting to Pebble without its CA bundle")
+		}
+
+	})
+
+	t.Run("obtain and reuse account", func(t *testing.T) {
+
+		m, storage := newPebbleManager(t, caBundlePath)
+
+		if err := m.Obtain(ctx, cert); err != nil {
+			t.Fatal(err)
+		}
+
+		certPath := pebbleFileBucket + "/acme/" + certificateKey(cert)
+		accountPath := pebbleFileBucket + "/acme/" + accountKey(cert)
+
+		leaf, err := helpers.ParseLeafCertificate(storage.files[certPath])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(leaf.DNSNames) != 1 || leaf.DNSNames[0] != domain {
+			t.Fatalf("unexpected certificate names %v", leaf.DNSNames)
+		}
+
+		if !bytes.Contains(storage.files[certPath], []byte("PRIVATE KEY")) {
+			t.Fatal("certificate parameter without private key")
+		}
+
+		acc, err := parseAccount(storage.files[accountPath])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if acc.Registration == nil || acc.CADirURL != m.options.DefaultCADirURL {
+			t.Fatalf("unexpected stored account %+v", acc)
+		}
+
+		// The second order reuses the registered account
+		if err := m.Obtain(ctx, cert); err != nil {
+			t.Fatal(err)
+		}
+
+		if reused, _ := parseAccount(storage.files[accountPath]); reused == nil || reused.Registration.URI != acc.Registration.URI {
+			t.Fatal("account registered again")
+		}
+
+	})
+
+}
@@ -0,0 +1,189 @@
+package audit
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/upper-institute/hike/pkg/authz"
+	"github.com/upper-institute/hike/pkg/parameter"
+	paramapi "github.com/upper-institute/hike/proto/api/parameter"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	StdoutSink = "stdout"
+	StderrSink = "stderr"
+
+	PullOperation     = "pull"
+	PutOperation      = "put"
+	DeleteOperation   = "delete"
+	DownloadOperation = "download"
+	UploadOperation   = "upload"
+)
+
+type AuditorOptions struct {
+	// stdout, stderr, a local file path or a file parameter URL prefix
+	// (e.g. file://bucket/audit) where chunks are uploaded
+	Sink string
+	// Used when the caller has no client certificate identity (e.g. IAM principal)
+	Principal string
+
+	ParameterOptions *parameter.ParameterOptions
+	FlushInterval    time.Duration
+}
+
+func (options *AuditorOptions) NewAuditor(logger *zap.SugaredLogger) (*Auditor, error) {
+
+	sink, err := options.openSink(logger)
+	if err != nil {
+		return nil, err
+	}
+
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoderConfig.TimeKey = "time"
+	encoderConfig.EncodeTime = zapcore.RFC3339NanoTimeEncoder
+
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(encoderConfig), sink, zap.InfoLevel)
+
+	return &Auditor{
+		options: options,
+		logger:  zap.New(core),
+		sink:    sink,
+	}, nil
+
+}
+
+func (options *AuditorOptions) openSink(logger *zap.SugaredLogger) (zapcore.WriteSyncer, error) {
+
+	switch options.Sink {
+
+	case StdoutSink:
+		return zapcore.Lock(os.Stdout), nil
+
+	case StderrSink:
+		return zapcore.Lock(os.Stderr), nil
+
+	}
+
+	if strings.HasPrefix(options.Sink, parameter.FileScheme+"://") {
+		return newParameterSink(options, logger)
+	}
+
+	file, err := os.OpenFile(options.Sink, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	return zapcore.Lock(file), nil
+
+}
+
+// Auditor records who accessed which parameters, values are never recorded
+type Auditor struct {
+	options *AuditorOptions
+
+	logger *zap.Logger
+	sink   zapcore.WriteSyncer
+}
+
+type Event struct {
+	Operation string
+	Key       string
+	// Source path or parameter URL without fragment, fragments may hold values
+	Path string
+	Keys []string
+}
+
+func (a *Auditor) identity(ctx context.Context) []string {
+
+	identities := authz.PeerIdentities(ctx)
+
+	if len(identities) == 0 && len(a.options.Principal) > 0 {
+		identities = append(identities, a.options.Principal)
+	}
+
+	return identities
+
+}
+
+func (a *Auditor) Record(ctx context.Context, event *Event, err error) {
+
+	fields := []zap.Field{
+		zap.String("operation", event.Operation),
+		zap.Strings("identity", a.identity(ctx)),
+		zap.Bool("success", err == nil),
+	}
+
+	if len(event.Key) > 0 {
+		fields = append(fields, zap.String("key", event.Key))
+	}
+
+	if len(event.Path) > 0 {
+		fields = append(fields, zap.String("path", event.Path))
+	}
+
+	if event.Keys != nil {
+		fields = append(fields, zap.Strings("keys", event.Keys))
+	}
+
+	if err != nil {
+		fields = append(fields, zap.String("error", err.Error()))
+	}
+
+	a.logger.Info("Parameter access", fields...)
+
+}
+
+func (a *Auditor) Sync() error {
+	return a.sink.Sync()
+}
+
+// Wrap replaces stores and storages of the options by audited ones
+func (a *Auditor) Wrap(opts *parameter.SourceOptions) {
+
+	if opts.Store != nil {
+		opts.Store = &auditStore{
+			&auditReader{opts.Store, a},
+			&auditWriter{opts.Store, a},
+			&auditDeleter{opts.Store, a},
+		}
+	}
+
+	if opts.Writer != nil {
+		opts.Writer = &auditWriter{opts.Writer, a}
+	}
+
+	if opts.Deleter != nil {
+		opts.Deleter = &auditDeleter{opts.Deleter, a}
+	}
+
+	if opts.Downloader != nil {
+		opts.Downloader = &auditDownloader{opts.Downloader, a}
+	}
+
+	if opts.Uploader != nil {
+		opts.Uploader = &auditUploader{opts.Uploader, a}
+	}
+
+}
+
+// parameterPath never includes the URL fragment, which holds var values
+func parameterPath(param *parameter.Parameter) string {
+
+	if param.GetType() == paramapi.ParameterType_PT_VAR {
+		return param.Metadata.Get(parameter.PathPrefixMetadata)
+	}
+
+	u := &url.URL{
+		Scheme: param.GetScheme(),
+		Host:   param.GetHost(),
+		Path:   param.GetPath(),
+	}
+
+	return u.String()
+
+}
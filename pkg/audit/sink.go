@@ -0,0 +1,121 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultFlushInterval = time.Minute
+
+	parameterSinkKey = "audit"
+)
+
+// parameterSink buffers audit records and uploads them as a new object
+// under the sink prefix on every flush, storages can't append to objects
+type parameterSink struct {
+	options *AuditorOptions
+
+	prefix   string
+	hostname string
+
+	buf  *bytes.Buffer
+	lock sync.Mutex
+
+	logger *zap.SugaredLogger
+}
+
+func newParameterSink(options *AuditorOptions, logger *zap.SugaredLogger) (*parameterSink, error) {
+
+	if options.ParameterOptions == nil || options.ParameterOptions.Uploader == nil {
+		return nil, fmt.Errorf("Audit sink %s requires a parameter storage driver", options.Sink)
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &parameterSink{
+		options:  options,
+		prefix:   strings.TrimRight(options.Sink, "/"),
+		hostname: hostname,
+		buf:      bytes.NewBuffer(nil),
+		logger:   logger.With("part", "audit/parameter-sink", "sink", options.Sink),
+	}
+
+	flushInterval := options.FlushInterval
+
+	if flushInterval <= 0 {
+		flushInterval = DefaultFlushInterval
+	}
+
+	go s.flushCycle(flushInterval)
+
+	return s, nil
+
+}
+
+func (s *parameterSink) flushCycle(interval time.Duration) {
+
+	for {
+
+		time.Sleep(interval)
+
+		err := s.Sync()
+		if err != nil {
+			s.logger.Errorw("Unable to flush audit records", "error", err)
+		}
+
+	}
+
+}
+
+func (s *parameterSink) Write(p []byte) (int, error) {
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.buf.Write(p)
+
+}
+
+func (s *parameterSink) Sync() error {
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.buf.Len() == 0 {
+		return nil
+	}
+
+	now := time.Now().UTC()
+
+	urlStr := fmt.Sprintf("%s/%s/%s-%d.jsonl", s.prefix, now.Format("2006/01/02"), s.hostname, now.UnixNano())
+
+	param, err := s.options.ParameterOptions.NewFromURLString(parameterSinkKey, urlStr)
+	if err != nil {
+		return err
+	}
+
+	param.GetFile().Write(s.buf.Bytes())
+
+	err = s.options.ParameterOptions.Uploader.Upload(context.Background(), param)
+	if err != nil {
+		return err
+	}
+
+	s.logger.Debugw("Audit records uploaded", "url", urlStr)
+
+	s.buf.Reset()
+
+	return nil
+
+}
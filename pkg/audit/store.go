@@ -0,0 +1,150 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/upper-institute/hike/pkg/parameter"
+)
+
+type auditReader struct {
+	reader  parameter.Reader
+	auditor *Auditor
+}
+
+func (r *auditReader) Pull(ctx context.Context, options *parameter.PullRequest) error {
+
+	event := &Event{
+		Operation: PullOperation,
+		Path:      options.Url.Path,
+		Keys:      []string{},
+	}
+
+	proxy := &parameter.PullRequest{
+		ParameterOptions: options.ParameterOptions,
+		Url:              options.Url,
+		Result:           make(chan *parameter.Parameter),
+	}
+
+	stopCh := make(chan struct{})
+	doneCh := make(chan struct{})
+
+	// Forward results to the caller, keeping only the keys
+	go func() {
+
+		defer close(doneCh)
+
+		for {
+
+			select {
+
+			case param, ok := <-proxy.Result:
+				if !ok {
+					close(options.Result)
+					return
+				}
+				event.Keys = append(event.Keys, param.GetKey())
+				options.Result <- param
+
+			case <-stopCh:
+				return
+
+			}
+
+		}
+
+	}()
+
+	err := r.reader.Pull(ctx, proxy)
+	if err != nil {
+		close(stopCh)
+	}
+
+	<-doneCh
+
+	r.auditor.Record(ctx, event, err)
+
+	return err
+
+}
+
+type auditWriter struct {
+	writer  parameter.Writer
+	auditor *Auditor
+}
+
+func (w *auditWriter) Put(ctx context.Context, param *parameter.Parameter) error {
+
+	err := w.writer.Put(ctx, param)
+
+	w.auditor.Record(ctx, &Event{
+		Operation: PutOperation,
+		Key:       param.GetKey(),
+		Path:      parameterPath(param),
+	}, err)
+
+	return err
+
+}
+
+type auditDeleter struct {
+	deleter parameter.Deleter
+	auditor *Auditor
+}
+
+func (d *auditDeleter) Delete(ctx context.Context, param *parameter.Parameter) error {
+
+	err := d.deleter.Delete(ctx, param)
+
+	d.auditor.Record(ctx, &Event{
+		Operation: DeleteOperation,
+		Key:       param.GetKey(),
+		Path:      parameterPath(param),
+	}, err)
+
+	return err
+
+}
+
+type auditStore struct {
+	*auditReader
+	*auditWriter
+	*auditDeleter
+}
+
+type auditDownloader struct {
+	downloader parameter.Downloader
+	auditor    *Auditor
+}
+
+func (d *auditDownloader) Download(ctx context.Context, param *parameter.Parameter) error {
+
+	err := d.downloader.Download(ctx, param)
+
+	d.auditor.Record(ctx, &Event{
+		Operation: DownloadOperation,
+		Key:       param.GetKey(),
+		Path:      parameterPath(param),
+	}, err)
+
+	return err
+
+}
+
+type auditUploader struct {
+	uploader parameter.Uploader
+	auditor  *Auditor
+}
+
+func (u *auditUploader) Upload(ctx context.Context, param *parameter.Parameter) error {
+
+	err := u.uploader.Upload(ctx, param)
+
+	u.auditor.Record(ctx, &Event{
+		Operation: UploadOperation,
+		Key:       param.GetKey(),
+		Path:      parameterPath(param),
+	}, err)
+
+	return err
+
+}
@@ -0,0 +1,271 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/upper-institute/hike/pkg/parameter"
+	"go.uber.org/zap"
+)
+
+var testStoreErr = errors.New("Test store error")
+
+type testStore struct {
+	urls map[string]string
+	err  error
+}
+
+func (s *testStore) Pull(ctx context.Context, options *parameter.PullRequest) error {
+
+	if s.err != nil {
+		return s.err
+	}
+
+	defer close(options.Result)
+
+	for key, urlStr := range s.urls {
+
+		param, err := options.ParameterOptions.NewFromURLString(key, urlStr)
+		if err != nil {
+			return err
+		}
+
+		options.Result <- param
+
+	}
+
+	return nil
+
+}
+
+func (s *testStore) Put(ctx context.Context, param *parameter.Parameter) error {
+	return s.err
+}
+
+func (s *testStore) Delete(ctx context.Context, param *parameter.Parameter) error {
+	return s.err
+}
+
+type testRecord struct {
+	Operation string   `json:"operation"`
+	Identity  []string `json:"identity"`
+	Success   bool     `json:"success"`
+	Key       string   `json:"key"`
+	Path      string   `json:"path"`
+	Keys      []string `json:"keys"`
+	Error     string   `json:"error"`
+}
+
+func newTestAuditor(t *testing.T) (*Auditor, string) {
+
+	sink := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	auditor, err := (&AuditorOptions{Sink: sink, Principal: "arn:aws:iam::1:role/test"}).NewAuditor(zap.NewNop().Sugar())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return auditor, sink
+
+}
+
+func readTestRecords(t *testing.T, auditor *Auditor, sink string) []*testRecord {
+
+	if err := auditor.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.Open(sink)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	records := []*testRecord{}
+
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+
+		record := &testRecord{}
+
+		if err := json.Unmarshal(scanner.Bytes(), record); err != nil {
+			t.Fatal(err)
+		}
+
+		records = append(records, record)
+
+	}
+
+	return records
+
+}
+
+func TestAuditStore(t *testing.T) {
+
+	auditor, sink := newTestAuditor(t)
+
+	store := &testStore{urls: map[string]string{
+		"TOKEN": "var://#s3cr3t",
+		"CERT":  "file://bucket/cert.pem#/etc/cert.pem",
+	}}
+
+	opts := &parameter.SourceOptions{ParameterOptions: &parameter.ParameterOptions{}, Store: store}
+
+	auditor.Wrap(opts)
+
+	ctx := context.Background()
+
+	result := make(chan *parameter.Parameter)
+	keys := make(chan int)
+
+	go func() {
+
+		count := 0
+
+		for range result {
+			count++
+		}
+
+		keys <- count
+
+	}()
+
+	err := opts.Store.Pull(ctx, &parameter.PullRequest{
+		ParameterOptions: opts.ParameterOptions,
+		Url:              &url.URL{Scheme: "mem", Path: "/app"},
+		Result:           result,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count := <-keys; count != 2 {
+		t.Fatalf("expected 2 pulled parameters, got %d", count)
+	}
+
+	token, err := opts.ParameterOptions.NewFromURLString("TOKEN", "var://#s3cr3t")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token.Metadata.Set(parameter.PathPrefixMetadata, "/app")
+
+	cert, err := opts.ParameterOptions.NewFromURLString("CERT", "file://bucket/cert.pem#/etc/cert.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := opts.Store.Put(ctx, token); err != nil {
+		t.Fatal(err)
+	}
+
+	store.err = testStoreErr
+
+	if err := opts.Store.Delete(ctx, cert); err != testStoreErr {
+		t.Fatalf("expected store error, got %v", err)
+	}
+
+	records := readTestRecords(t, auditor, sink)
+
+	if len(records) != 3 {
+		t.Fatalf("expected 3 records, got %d", len(records))
+	}
+
+	pull, put, del := records[0], records[1], records[2]
+
+	if pull.Operation != PullOperation || pull.Path != "/app" || len(pull.Keys) != 2 || !pull.Success {
+		t.Errorf("unexpected pull record %+v", pull)
+	}
+
+	if put.Operation != PutOperation || put.Key != "TOKEN" || put.Path != "/app" || !put.Success {
+		t.Errorf("unexpected put record %+v", put)
+	}
+
+	if del.Operation != DeleteOperation || del.Key != "CERT" || del.Path != "file://bucket/cert.pem" || del.Success || del.Error != testStoreErr.Error() {
+		t.Errorf("unexpected delete record %+v", del)
+	}
+
+	for _, record := range records {
+
+		if len(record.Identity) != 1 || record.Identity[0] != "arn:aws:iam::1:role/test" {
+			t.Errorf("unexpected identity %v", record.Identity)
+		}
+
+	}
+
+	content, err := os.ReadFile(sink)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(content), "s3cr3t") || strings.Contains(string(content), "/etc/cert.pem") {
+		t.Fatalf("fragments recorded in %s", content)
+	}
+
+}
+
+func TestAuditWriterDeleter(t *testing.T) {
+
+	auditor, sink := newTestAuditor(t)
+
+	writer := &testStore{}
+
+	opts := &parameter.SourceOptions{ParameterOptions: &parameter.ParameterOptions{Writer: writer, Deleter: writer}}
+
+	auditor.Wrap(opts)
+
+	param, err := opts.ParameterOptions.NewFromURLString("CERT", "file://bucket/cert.pem#/etc/cert.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := opts.Writer.Put(context.Background(), param); err != nil {
+		t.Fatal(err)
+	}
+
+	writer.err = testStoreErr
+
+	if err := opts.Deleter.Delete(context.Background(), param); err != testStoreErr {
+		t.Fatalf("expected store error, got %v", err)
+	}
+
+	records := readTestRecords(t, auditor, sink)
+
+	if len(records) != 2 || records[0].Operation != PutOperation || !records[0].Success || records[1].Operation != DeleteOperation || records[1].Success {
+		t.Fatalf("unexpected records %+v", records)
+	}
+
+}
+
+func TestAuditReaderError(t *testing.T) {
+
+	auditor, sink := newTestAuditor(t)
+
+	opts := &parameter.SourceOptions{ParameterOptions: &parameter.ParameterOptions{}, Store: &testStore{err: testStoreErr}}
+
+	auditor.Wrap(opts)
+
+	err := opts.Store.Pull(context.Background(), &parameter.PullRequest{
+		ParameterOptions: opts.ParameterOptions,
+		Url:              &url.URL{Scheme: "mem", Path: "/app"},
+		Result:           make(chan *parameter.Parameter),
+	})
+	if err != testStoreErr {
+		t.Fatalf("expected store error, got %v", err)
+	}
+
+	records := readTestRecords(t, auditor, sink)
+
+	if len(records) != 1 || records[0].Operation != PullOperation || records[0].Success || records[0].Error != testStoreErr.Error() {
+		t.Fatalf("unexpected records %+v", records)
+	}
+
+}
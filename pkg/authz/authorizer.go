@@ -0,0 +1,280 @@
+package authz
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"os"
+	"sync/atomic"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type AuthorizerOptions struct {
+	PolicyPath     string
+	ReloadInterval time.Duration
+	// Node ID of requests with an empty node_id, e.g. GetSnapshot of the
+	// default node
+	DefaultNodeID string
+}
+
+func (options *AuthorizerOptions) NewAuthorizer(logger *zap.SugaredLogger) (*Authorizer, error) {
+
+	a := &Authorizer{
+		options: options,
+		logger:  logger.With("part", "authz/authorizer", "policy_path", options.PolicyPath),
+	}
+
+	err := a.load()
+	if err != nil {
+		return nil, err
+	}
+
+	return a, nil
+
+}
+
+// Authorizer allows gRPC calls based on the client certificate of the
+// caller, everything not allowed by the policy is denied
+type Authorizer struct {
+	options *AuthorizerOptions
+
+	logger *zap.SugaredLogger
+
+	policy atomic.Value
+	hash   []byte
+}
+
+type nodeRequest interface {
+	GetNode() *corev3.Node
+}
+
+type nodeIdRequest interface {
+	GetNodeId() string
+}
+
+func (a *Authorizer) load() error {
+
+	data, err := os.ReadFile(a.options.PolicyPath)
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.Sum256(data)
+
+	if bytes.Equal(a.hash, hash[:]) {
+		return nil
+	}
+
+	policy, err := ParsePolicy(data)
+	if err != nil {
+		return err
+	}
+
+	a.policy.Store(policy)
+	a.hash = hash[:]
+
+	a.logger.Infow("Authorization policy loaded", "rule_count", len(policy.Rules))
+
+	return nil
+
+}
+
+func (a *Authorizer) reload() {
+
+	for {
+
+		time.Sleep(a.options.ReloadInterval)
+
+		err := a.load()
+		if err != nil {
+			a.logger.Errorw("Unable to reload authorization policy, keeping the previous one", "error", err)
+		}
+
+	}
+
+}
+
+func (a *Authorizer) StartReloadCycle() error {
+
+	if a.options.ReloadInterval <= 0 {
+		return nil
+	}
+
+	go a.reload()
+
+	return nil
+
+}
+
+func PeerIdentities(ctx context.Context) []string {
+
+	identities := []string{}
+
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.AuthInfo == nil {
+		return identities
+	}
+
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return identities
+	}
+
+	cert := tlsInfo.State.VerifiedChains[0][0]
+
+	for _, uri := range cert.URIs {
+		identities = append(identities, uri.String())
+	}
+
+	identities = append(identities, cert.DNSNames...)
+
+	return identities
+
+}
+
+func (a *Authorizer) authorize(ctx context.Context, fullMethod string) ([]*Rule, error) {
+
+	policy, ok := a.policy.Load().(*Policy)
+	if !ok {
+		return nil, status.Error(codes.Unavailable, PolicyNotLoadedErr.Error())
+	}
+
+	identities := PeerIdentities(ctx)
+
+	if len(identities) == 0 {
+		a.logger.Infow("Denied call without verified client certificate", "method", fullMethod)
+		return nil, status.Error(codes.Unauthenticated, "Verified client certificate required")
+	}
+
+	rules := policy.AllowMethod(identities, fullMethod)
+
+	if len(rules) == 0 {
+		a.logger.Infow("Denied call", "method", fullMethod, "identities", identities)
+		return nil, status.Errorf(codes.PermissionDenied, "Method %s not allowed", fullMethod)
+	}
+
+	return rules, nil
+
+}
+
+// requestNodeId returns the xDS node id of discovery and snapshot requests,
+// false for requests without node
+func requestNodeId(req interface{}) (string, bool) {
+
+	switch nodeReq := req.(type) {
+
+	case nodeRequest:
+		return nodeReq.GetNode().GetId(), true
+
+	case nodeIdRequest:
+		return nodeReq.GetNodeId(), true
+
+	}
+
+	return "", false
+
+}
+
+// authorizeNode denies node ids not allowed by the rules, empty node ids are
+// the default one and denied when there is none
+func (a *Authorizer) authorizeNode(rules []*Rule, nodeId string) error {
+
+	if len(nodeId) == 0 {
+		nodeId = a.options.DefaultNodeID
+	}
+
+	if len(nodeId) == 0 {
+		a.logger.Infow("Denied request without xDS node id")
+		return status.Error(codes.PermissionDenied, "Node id required")
+	}
+
+	if !AllowNodeId(rules, nodeId) {
+		a.logger.Infow("Denied xDS node id", "node_id", nodeId)
+		return status.Errorf(codes.PermissionDenied, "Node id %s not allowed", nodeId)
+	}
+
+	return nil
+
+}
+
+func (a *Authorizer) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+
+		rules, err := a.authorize(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+
+		if nodeId, ok := requestNodeId(req); ok {
+
+			err = a.authorizeNode(rules, nodeId)
+			if err != nil {
+				return nil, err
+			}
+
+		}
+
+		return handler(ctx, req)
+
+	}
+
+}
+
+func (a *Authorizer) StreamServerInterceptor() grpc.StreamServerInterceptor {
+
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+
+		rules, err := a.authorize(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &authorizedStream{ServerStream: ss, authorizer: a, rules: rules})
+
+	}
+
+}
+
+// authorizedStream checks the node id of every received discovery request,
+// Envoy only sends the node in the first request of the stream so the later
+// ones without node belong to the node authorized then
+type authorizedStream struct {
+	grpc.ServerStream
+	authorizer *Authorizer
+	rules      []*Rule
+
+	nodeAuthorized bool
+}
+
+func (s *authorizedStream) RecvMsg(m interface{}) error {
+
+	err := s.ServerStream.RecvMsg(m)
+	if err != nil {
+		return err
+	}
+
+	nodeId, ok := requestNodeId(m)
+
+	if !ok || (len(nodeId) == 0 && s.nodeAuthorized) {
+		return nil
+	}
+
+	err = s.authorizer.authorizeNode(s.rules, nodeId)
+	if err != nil {
+		return err
+	}
+
+	s.nodeAuthorized = true
+
+	return nil
+
+}
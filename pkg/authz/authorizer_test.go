@@ -0,0 +1,321 @@
+package authz
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	discoveryv3 "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	sdapi "github.com/upper-institute/hike/proto/api/service-discovery"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	adsMethod      = "/envoy.service.discovery.v3.AggregatedDiscoveryService/StreamAggregatedResources"
+	snapshotMethod = "/servicediscovery.ServiceDiscovery/GetSnapshot"
+	frontProxy     = "spiffe://mesh.internal/ns/front-proxy"
+)
+
+func newTestAuthorizer(t *testing.T, policy string, defaultNodeId string) (*Authorizer, string) {
+
+	policyPath := filepath.Join(t.TempDir(), "policy.yaml")
+
+	if err := os.WriteFile(policyPath, []byte(policy), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	options := &AuthorizerOptions{
+		PolicyPath:    policyPath,
+		DefaultNodeID: defaultNodeId,
+	}
+
+	a, err := options.NewAuthorizer(zap.NewNop().Sugar())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return a, policyPath
+
+}
+
+// peerContext is the context of a call with a verified client certificate
+// of the SPIFFE ID, none when empty
+func peerContext(t *testing.T, spiffeId string) context.Context {
+
+	state := tls.ConnectionState{}
+
+	if len(spiffeId) > 0 {
+
+		uri, err := url.Parse(spiffeId)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		state.VerifiedChains = [][]*x509.Certificate{{{URIs: []*url.URL{uri}}}}
+
+	}
+
+	return peer.NewContext(context.Background(), &peer.Peer{
+		AuthInfo: credentials.TLSInfo{State: state},
+	})
+
+}
+
+func unaryCall(a *Authorizer, ctx context.Context, method string, req interface{}) error {
+
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return req, nil
+	}
+
+	_, err := a.UnaryServerInterceptor()(ctx, req, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+
+	return err
+
+}
+
+type testServerStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	requests []proto.Message
+}
+
+func (s *testServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *testServerStream) RecvMsg(m interface{}) error {
+
+	if len(s.requests) == 0 {
+		return io.EOF
+	}
+
+	proto.Merge(m.(proto.Message), s.requests[0])
+
+	s.requests = s.requests[1:]
+
+	return nil
+
+}
+
+// streamCall receives the requests in the handler, returns the error of the
+// stream and the count of requests received
+func streamCall(a *Authorizer, ctx context.Context, requests ...proto.Message) (int, error) {
+
+	received := 0
+
+	handler := func(srv interface{}, ss grpc.ServerStream) error {
+
+		for {
+
+			err := ss.RecvMsg(&discoveryv3.DiscoveryRequest{})
+			if err == io.EOF {
+				return nil
+			}
+
+			if err != nil {
+				return err
+			}
+
+			received++
+
+		}
+
+	}
+
+	ss := &testServerStream{ctx: ctx, requests: requests}
+
+	err := a.StreamServerInterceptor()(nil, ss, &grpc.StreamServerInfo{FullMethod: adsMethod}, handler)
+
+	return received, err
+
+}
+
+func discoveryRequest(nodeId string) proto.Message {
+
+	req := &discoveryv3.DiscoveryRequest{TypeUrl: "type.googleapis.com/envoy.config.cluster.v3.Cluster"}
+
+	if len(nodeId) > 0 {
+		req.Node = &corev3.Node{Id: nodeId}
+	}
+
+	return req
+
+}
+
+func TestAuthorizeMethod(t *testing.T) {
+
+	a, _ := newTestAuthorizer(t, testPolicy, "")
+
+	tests := []struct {
+		name     string
+		identity string
+		method   string
+		code     codes.Code
+	}{
+		{"allowed", "spiffe://mesh.internal/ns/ci", "/parameter.ParameterService/Get", codes.OK},
+		{"method not allowed", "spiffe://mesh.internal/ns/ci", "/parameter.ParameterService/Delete", codes.PermissionDenied},
+		{"unknown identity", "spiffe://mesh.internal/ns/other", "/parameter.ParameterService/Get", codes.PermissionDenied},
+		{"no client certificate", "", "/parameter.ParameterService/Get", codes.Unauthenticated},
+	}
+
+	for _, test := range tests {
+		if err := unaryCall(a, peerContext(t, test.identity), test.method, nil); status.Code(err) != test.code {
+			t.Errorf("%s: expected %s, got %v", test.name, test.code, err)
+		}
+	}
+
+}
+
+func TestAuthorizeSnapshotNode(t *testing.T) {
+
+	policy := testPolicy + `
+  - name: snapshot
+    identities:
+      - spiffe://mesh.internal/ns/front-proxy
+    methods:
+      - /servicediscovery.ServiceDiscovery/*
+    nodeIds:
+      - front-proxy-*
+`
+
+	ctx := peerContext(t, frontProxy)
+
+	t.Run("node id", func(t *testing.T) {
+
+		a, _ := newTestAuthorizer(t, policy, "")
+
+		if err := unaryCall(a, ctx, snapshotMethod, &sdapi.GetSnapshotRequest{NodeId: "front-proxy-1"}); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := unaryCall(a, ctx, snapshotMethod, &sdapi.GetSnapshotRequest{NodeId: "back-proxy-1"}); status.Code(err) != codes.PermissionDenied {
+			t.Fatalf("expected permission denied, got %v", err)
+		}
+
+	})
+
+	t.Run("default node id", func(t *testing.T) {
+
+		a, _ := newTestAuthorizer(t, policy, "front-proxy-default")
+
+		if err := unaryCall(a, ctx, snapshotMethod, &sdapi.GetSnapshotRequest{}); err != nil {
+			t.Fatal(err)
+		}
+
+	})
+
+	t.Run("no default node id", func(t *testing.T) {
+
+		a, _ := newTestAuthorizer(t, policy, "")
+
+		if err := unaryCall(a, ctx, snapshotMethod, &sdapi.GetSnapshotRequest{}); status.Code(err) != codes.PermissionDenied {
+			t.Fatalf("expected permission denied, got %v", err)
+		}
+
+	})
+
+}
+
+func TestAuthorizeStreamNode(t *testing.T) {
+
+	ctx := peerContext(t, frontProxy)
+
+	tests := []struct {
+		name          string
+		defaultNodeId string
+		requests      []proto.Message
+		received      int
+		code          codes.Code
+	}{
+		{"node of the first request", "", []proto.Message{discoveryRequest("front-proxy-1"), discoveryRequest(""), discoveryRequest("")}, 3, codes.OK},
+		{"node not allowed", "", []proto.Message{discoveryRequest("back-proxy-1"), discoveryRequest("")}, 0, codes.PermissionDenied},
+		{"first request without node", "", []proto.Message{discoveryRequest(""), discoveryRequest("front-proxy-1")}, 0, codes.PermissionDenied},
+		{"first request of the default node", "front-proxy-default", []proto.Message{discoveryRequest(""), discoveryRequest("")}, 2, codes.OK},
+		{"first request of a default node not allowed", "back-proxy-default", []proto.Message{discoveryRequest("")}, 0, codes.PermissionDenied},
+		{"later node not allowed", "", []proto.Message{discoveryRequest("front-proxy-1"), discoveryRequest("back-proxy-1")}, 1, codes.PermissionDenied},
+	}
+
+	for _, test := range tests {
+
+		a, _ := newTestAuthorizer(t, testPolicy, test.defaultNodeId)
+
+		received, err := streamCall(a, ctx, test.requests...)
+
+		if status.Code(err) != test.code || received != test.received {
+			t.Errorf("%s: expected %s after %d requests, got %v after %d", test.name, test.code, test.received, err, received)
+		}
+
+	}
+
+}
+
+func TestReload(t *testing.T) {
+
+	a, policyPath := newTestAuthorizer(t, testPolicy, "")
+
+	ctx := peerContext(t, "spiffe://mesh.internal/ns/ci")
+
+	if err := unaryCall(a, ctx, "/parameter.ParameterService/Put", nil); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected permission denied, got %v", err)
+	}
+
+	policy := `
+rules:
+  - name: ci
+    identities:
+      - spiffe://mesh.internal/ns/ci
+    methods:
+      - /parameter.ParameterService/*
+`
+
+	if err := os.WriteFile(policyPath, []byte(policy), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.load(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := unaryCall(a, ctx, "/parameter.ParameterService/Put", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// An invalid policy keeps the previous one
+	if err := os.WriteFile(policyPath, []byte("rules:\n  - name: broken\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.load(); err == nil {
+		t.Fatal("expected an error loading an invalid policy")
+	}
+
+	if err := unaryCall(a, ctx, "/parameter.ParameterService/Put", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// A removed policy keeps the previous one too
+	if err := os.Remove(policyPath); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.load(); err == nil {
+		t.Fatal("expected an error loading a removed policy")
+	}
+
+	if err := unaryCall(a, ctx, "/parameter.ParameterService/Get", nil); err != nil {
+		t.Fatal(err)
+	}
+
+}
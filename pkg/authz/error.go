@@ -0,0 +1,9 @@
+package authz
+
+import "errors"
+
+var (
+	PolicyNotLoadedErr   = errors.New("Authorization policy not loaded")
+	EmptyRuleIdentityErr = errors.New("Authorization rule without identities")
+	EmptyRuleMethodErr   = errors.New("Authorization rule without methods")
+)
@@ -0,0 +1,143 @@
+package authz
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const wildcard = "*"
+
+// Example (YAML or JSON):
+//
+//	rules:
+//	  - name: front-proxy
+//	    identities:
+//	      - spiffe://mesh.internal/ns/front-proxy
+//	      - "*.front.internal"
+//	    methods:
+//	      - /envoy.service.discovery.v3.AggregatedDiscoveryService/*
+//	    nodeIds:
+//	      - front-proxy-*
+type Policy struct {
+	Rules []*Rule `yaml:"rules"`
+}
+
+type Rule struct {
+	Name string `yaml:"name"`
+	// SPIFFE IDs (URI SANs) or DNS SANs of the client certificate
+	Identities []string `yaml:"identities"`
+	// Full gRPC methods (/package.Service/Method)
+	Methods []string `yaml:"methods"`
+	// xDS node IDs allowed in discovery and snapshot requests, empty allows none
+	NodeIds []string `yaml:"nodeIds"`
+}
+
+func ParsePolicy(data []byte) (*Policy, error) {
+
+	policy := &Policy{}
+
+	err := yaml.Unmarshal(data, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, rule := range policy.Rules {
+
+		if len(rule.Identities) == 0 {
+			return nil, fmt.Errorf("%w (rule %d: %s)", EmptyRuleIdentityErr, i, rule.Name)
+		}
+
+		if len(rule.Methods) == 0 {
+			return nil, fmt.Errorf("%w (rule %d: %s)", EmptyRuleMethodErr, i, rule.Name)
+		}
+
+	}
+
+	return policy, nil
+
+}
+
+func (r *Rule) matchIdentity(identities []string) bool {
+
+	for _, identity := range identities {
+		if matchAny(r.Identities, identity) {
+			return true
+		}
+	}
+
+	return false
+
+}
+
+// AllowMethod returns the rules that allow some identity to call the method,
+// no rules means the call is denied
+func (p *Policy) AllowMethod(identities []string, fullMethod string) []*Rule {
+
+	rules := []*Rule{}
+
+	for _, rule := range p.Rules {
+		if rule.matchIdentity(identities) && matchAny(rule.Methods, fullMethod) {
+			rules = append(rules, rule)
+		}
+	}
+
+	return rules
+
+}
+
+func AllowNodeId(rules []*Rule, nodeId string) bool {
+
+	for _, rule := range rules {
+		if matchAny(rule.NodeIds, nodeId) {
+			return true
+		}
+	}
+
+	return false
+
+}
+
+func matchAny(patterns []string, value string) bool {
+
+	for _, pattern := range patterns {
+		if match(pattern, value) {
+			return true
+		}
+	}
+
+	return false
+
+}
+
+// match compares value with a pattern where '*' matches any sequence of
+// characters, including separators
+func match(pattern string, value string) bool {
+
+	parts := strings.Split(pattern, wildcard)
+
+	if len(parts) == 1 {
+		return pattern == value
+	}
+
+	if !strings.HasPrefix(value, parts[0]) {
+		return false
+	}
+
+	value = value[len(parts[0]):]
+
+	for _, part := range parts[1 : len(parts)-1] {
+
+		i := strings.Index(value, part)
+		if i < 0 {
+			return false
+		}
+
+		value = value[i+len(part):]
+
+	}
+
+	return strings.HasSuffix(value, parts[len(parts)-1])
+
+}
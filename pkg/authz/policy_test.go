@@ -0,0 +1,121 @@
+package authz
+
+import (
+	"errors"
+	"testing"
+)
+
+const testPolicy = `
+rules:
+  - name: front-proxy
+    identities:
+      - spiffe://mesh.internal/ns/front-proxy
+      - "*.front.internal"
+    methods:
+      - /envoy.service.discovery.v3.AggregatedDiscoveryService/*
+    nodeIds:
+      - front-proxy-*
+  - name: ci
+    identities:
+      - spiffe://mesh.internal/ns/ci
+    methods:
+      - /parameter.ParameterService/Get
+`
+
+func TestMatch(t *testing.T) {
+
+	tests := []struct {
+		pattern string
+		value   string
+		match   bool
+	}{
+		{"front-proxy", "front-proxy", true},
+		{"front-proxy", "front-proxy-1", false},
+		{"front-proxy-*", "front-proxy-1", true},
+		{"front-proxy-*", "front-proxy-", true},
+		{"front-proxy-*", "back-proxy-1", false},
+		{"*.front.internal", "a.b.front.internal", true},
+		{"*.front.internal", "front.internal", false},
+		{"/svc/*", "/svc/a/b", true},
+		{"a*b*c", "axxbyyc", true},
+		{"a*b*c", "axxcyyb", false},
+		{"*", "", true},
+	}
+
+	for _, test := range tests {
+		if match(test.pattern, test.value) != test.match {
+			t.Errorf("match(%q, %q) should be %v", test.pattern, test.value, test.match)
+		}
+	}
+
+}
+
+func TestParsePolicy(t *testing.T) {
+
+	if _, err := ParsePolicy([]byte("rules:\n  - name: a\n    methods: [\"*\"]\n")); !errors.Is(err, EmptyRuleIdentityErr) {
+		t.Fatalf("expected empty identity error, got %v", err)
+	}
+
+	if _, err := ParsePolicy([]byte("rules:\n  - name: a\n    identities: [\"*\"]\n")); !errors.Is(err, EmptyRuleMethodErr) {
+		t.Fatalf("expected empty method error, got %v", err)
+	}
+
+	if _, err := ParsePolicy([]byte("rules: [")); err == nil {
+		t.Fatal("expected syntax error")
+	}
+
+}
+
+func TestAllowMethod(t *testing.T) {
+
+	policy, err := ParsePolicy([]byte(testPolicy))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ads := "/envoy.service.discovery.v3.AggregatedDiscoveryService/StreamAggregatedResources"
+
+	tests := []struct {
+		name       string
+		identities []string
+		method     string
+		rules      int
+	}{
+		{"spiffe id", []string{"spiffe://mesh.internal/ns/front-proxy"}, ads, 1},
+		{"dns name", []string{"spiffe://other", "edge.front.internal"}, ads, 1},
+		{"method not allowed", []string{"spiffe://mesh.internal/ns/front-proxy"}, "/parameter.ParameterService/Get", 0},
+		{"unknown identity", []string{"spiffe://mesh.internal/ns/other"}, ads, 0},
+		{"no identity", []string{}, ads, 0},
+		{"exact method", []string{"spiffe://mesh.internal/ns/ci"}, "/parameter.ParameterService/Get", 1},
+		{"other method", []string{"spiffe://mesh.internal/ns/ci"}, "/parameter.ParameterService/Put", 0},
+	}
+
+	for _, test := range tests {
+		if rules := policy.AllowMethod(test.identities, test.method); len(rules) != test.rules {
+			t.Errorf("%s: expected %d rules, got %d", test.name, test.rules, len(rules))
+		}
+	}
+
+}
+
+func TestAllowNodeId(t *testing.T) {
+
+	policy, err := ParsePolicy([]byte(testPolicy))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !AllowNodeId(policy.Rules, "front-proxy-1") {
+		t.Fatal("node id of the rule denied")
+	}
+
+	if AllowNodeId(policy.Rules, "back-proxy-1") {
+		t.Fatal("node id of no rule allowed")
+	}
+
+	// Rules without node ids allow none
+	if AllowNodeId(policy.Rules[1:], "front-proxy-1") {
+		t.Fatal("node id allowed by a rule without node ids")
+	}
+
+}
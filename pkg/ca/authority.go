@@ -0,0 +1,394 @@
+package ca
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/upper-institute/hike/pkg/helpers"
+	"github.com/upper-institute/hike/pkg/parameter"
+	paramapi "github.com/upper-institute/hike/proto/api/parameter"
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultSVIDTTL = 24 * time.Hour
+	DefaultRootTTL = 10 * 365 * 24 * time.Hour
+
+	Issuer = "internal-ca"
+
+	// Tolerated clock skew between the mesh proxies
+	notBeforeSkew = 5 * time.Minute
+)
+
+type AuthorityOptions struct {
+	SourceOptions *parameter.SourceOptions
+	// Parameter file source with the CA, a self-signed root is generated and
+	// pushed when the file parameter is empty
+	ParameterFileSource string
+	// Default: WN_MESH_CA
+	ParameterKey string
+	// Example: mesh.example.com
+	TrustDomain string
+	SVIDTTL     time.Duration
+	// Identity of the proxies served by hike when connecting to clusters
+	NodeID string
+}
+
+func (options *AuthorityOptions) NewAuthority(logger *zap.SugaredLogger) (*Authority, error) {
+
+	if len(options.TrustDomain) == 0 {
+		return nil, MissingTrustDomainErr
+	}
+
+	if len(options.ParameterFileSource) == 0 {
+		return nil, MissingParameterSourceErr
+	}
+
+	return &Authority{
+		options: options,
+		logger:  logger.With("part", "ca/authority"),
+		svids:   make(map[string]*svid),
+	}, nil
+
+}
+
+type svid struct {
+	leaf  *x509.Certificate
+	chain []byte
+	key   []byte
+}
+
+// Authority issues short-lived SPIFFE identities (SVIDs) to the mesh
+// services, signed by a CA kept in a file parameter
+type Authority struct {
+	options *AuthorityOptions
+
+	logger *zap.SugaredLogger
+
+	mu sync.Mutex
+
+	// Serializes loads, the discovery cycle and the certificate monitor may
+	// both find the CA not loaded
+	loadMu sync.Mutex
+
+	caCert  *x509.Certificate
+	caKey   crypto.Signer
+	caChain []byte
+	bundle  []byte
+
+	svids map[string]*svid
+}
+
+func (a *Authority) parameterKey() string {
+
+	if len(a.options.ParameterKey) > 0 {
+		return a.options.ParameterKey
+	}
+
+	return paramapi.WellKnown_WN_MESH_CA.String()
+
+}
+
+// SpiffeID returns the identity of a service in the trust domain
+func (a *Authority) SpiffeID(name string) string {
+
+	return (&url.URL{
+		Scheme: "spiffe",
+		Host:   a.options.TrustDomain,
+		Path:   "/ns/" + name,
+	}).String()
+
+}
+
+// TrustDomainID is the prefix of every identity in the trust domain
+func (a *Authority) TrustDomainID() string {
+	return "spiffe://" + a.options.TrustDomain + "/"
+}
+
+func (a *Authority) isLoaded() bool {
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.caCert != nil
+
+}
+
+// Load loads the CA from its file parameter, generating a self-signed root
+// when the file is empty or not found. A CA already loaded is kept.
+func (a *Authority) Load(ctx context.Context) error {
+
+	a.loadMu.Lock()
+	defer a.loadMu.Unlock()
+
+	// Loaded while waiting for a concurrent load, generating another root
+	// would overwrite the pushed one
+	if a.isLoaded() {
+		return nil
+	}
+
+	source, err := a.options.SourceOptions.NewFromURLString(a.options.ParameterFileSource)
+	if err != nil {
+		return err
+	}
+
+	err = source.Restore(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !source.Has(a.parameterKey()) {
+		return CAParameterNotFoundErr
+	}
+
+	param := source.Get(a.parameterKey())
+
+	if param.GetType() != paramapi.ParameterType_PT_FILE {
+		return NotFileParameterErr
+	}
+
+	err = param.Load(ctx)
+	if err != nil && !errors.Is(err, parameter.FileNotFoundErr) {
+		return err
+	}
+
+	if param.GetFile().Len() == 0 {
+
+		a.logger.Infow("Generating internal CA root", "trust_domain", a.options.TrustDomain, "parameter_key", a.parameterKey())
+
+		data, err := a.generateRoot()
+		if err != nil {
+			return err
+		}
+
+		param.GetFile().Reset()
+		param.GetFile().Write(data)
+
+		err = param.Push(ctx)
+		if err != nil {
+			return err
+		}
+
+	}
+
+	return a.parse(param.GetFile().Bytes())
+
+}
+
+func (a *Authority) generateRoot() ([]byte, error) {
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	serialNumber, err := newSerialNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	trustDomainURI, err := url.Parse("spiffe://" + a.options.TrustDomain)
+	if err != nil {
+		return nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{Organization: []string{a.options.TrustDomain}, CommonName: "hike mesh CA"},
+		URIs:                  []*url.URL{trustDomainURI},
+		NotBefore:             now.Add(-notBeforeSkew),
+		NotAfter:              now.Add(DefaultRootTTL),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		return nil, err
+	}
+
+	keyDer, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	data = append(data, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDer})...)
+
+	return data, nil
+
+}
+
+// parse loads the CA certificate, followed by its parents up to the root,
+// and its private key. The root is the trust bundle, the others are sent
+// with the SVIDs.
+func (a *Authority) parse(data []byte) error {
+
+	certs, err := helpers.ParseCertificates(data)
+	if err != nil {
+		return err
+	}
+
+	key, err := helpers.ParsePrivateKey(data)
+	if err != nil {
+		return err
+	}
+
+	caCert := certs[0]
+
+	if !caCert.IsCA {
+		return NotCACertificateErr
+	}
+
+	publicKey, ok := key.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !publicKey.Equal(caCert.PublicKey) {
+		return CAKeyMismatchErr
+	}
+
+	caChain := bytes.NewBuffer(nil)
+
+	for _, cert := range certs[:len(certs)-1] {
+		pem.Encode(caChain, &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	}
+
+	root := certs[len(certs)-1]
+
+	// Intermediates without their root are trusted as is
+	if !bytes.Equal(root.RawIssuer, root.RawSubject) {
+		pem.Encode(caChain, &pem.Block{Type: "CERTIFICATE", Bytes: root.Raw})
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.caCert = caCert
+	a.caKey = key
+	a.caChain = caChain.Bytes()
+	a.bundle = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: root.Raw})
+
+	a.logger.Infow("Internal CA loaded", "subject", caCert.Subject.String(), "not_after", caCert.NotAfter)
+
+	return nil
+
+}
+
+func newSerialNumber() (*big.Int, error) {
+
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+
+}
+
+// Issue signs a new SVID for the identity, valid for the SVID TTL (bounded
+// by the CA expiry)
+func (a *Authority) Issue(name string) error {
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.caCert == nil {
+		return CANotLoadedErr
+	}
+
+	id, err := url.Parse(a.SpiffeID(name))
+	if err != nil {
+		return err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	serialNumber, err := newSerialNumber()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	notAfter := now.Add(a.options.SVIDTTL)
+
+	if notAfter.After(a.caCert.NotAfter) {
+		notAfter = a.caCert.NotAfter
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{Organization: []string{a.options.TrustDomain}},
+		URIs:                  []*url.URL{id},
+		NotBefore:             now.Add(-notBeforeSkew),
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment | x509.KeyUsageKeyAgreement,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, a.caCert, key.Public(), a.caKey)
+	if err != nil {
+		return err
+	}
+
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return err
+	}
+
+	keyDer, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	chain := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	chain = append(chain, a.caChain...)
+
+	a.svids[name] = &svid{
+		leaf:  leaf,
+		chain: chain,
+		key:   pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDer}),
+	}
+
+	a.logger.Debugw("SVID issued", "spiffe_id", id.String(), "not_after", notAfter)
+
+	return nil
+
+}
+
+func (a *Authority) getSVID(name string) (*svid, bool) {
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	s, ok := a.svids[name]
+
+	return s, ok
+
+}
+
+// retain drops the SVIDs of identities no longer in the mesh
+func (a *Authority) retain(names map[string]bool) {
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for name := range a.svids {
+		if !names[name] {
+			delete(a.svids, name)
+		}
+	}
+
+}
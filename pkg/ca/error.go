@@ -0,0 +1,14 @@
+package ca
+
+import "errors"
+
+var (
+	MissingTrustDomainErr     = errors.New("Internal CA without trust domain")
+	MissingParameterSourceErr = errors.New("Internal CA without parameter file source")
+	CAParameterNotFoundErr    = errors.New("Internal CA parameter not found in the parameter file source")
+	NotFileParameterErr       = errors.New("Internal CA parameter must be a file type")
+	NotCACertificateErr       = errors.New("Internal CA certificate is not a CA")
+	CAKeyMismatchErr          = errors.New("Internal CA private key doesn't match the certificate")
+	CANotLoadedErr            = errors.New("Internal CA not loaded")
+	SVIDNotIssuedErr          = errors.New("SVID not issued")
+)
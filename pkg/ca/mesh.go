@@ -0,0 +1,284 @@
+package ca
+
+import (
+	"context"
+	"crypto/x509"
+	"time"
+
+	clusterv3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	listenerv3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	tlsv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	matcherv3 "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"github.com/upper-institute/hike/pkg/certificate"
+	"github.com/upper-institute/hike/pkg/servicemesh"
+	sdapi "github.com/upper-institute/hike/proto/api/service-discovery"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// Certificates implements certificate.Provider with the CA and the SVIDs of
+// the services, renewed after half of their lifetime
+func (a *Authority) Certificates(services []*sdapi.Service) []*certificate.Certificate {
+
+	certs := []*certificate.Certificate{{
+		Name:                    a.options.ParameterFileSource + "#" + a.parameterKey(),
+		Issuer:                  Issuer,
+		ParameterFileSource:     a.options.ParameterFileSource,
+		CertificateParameterKey: a.parameterKey(),
+	}}
+
+	for _, name := range a.identities(services) {
+
+		name := name
+
+		certs = append(certs, &certificate.Certificate{
+			Name:        a.SpiffeID(name),
+			Issuer:      Issuer,
+			RenewBefore: a.options.SVIDTTL / 2,
+			Load: func(ctx context.Context) (*x509.Certificate, error) {
+
+				s, ok := a.getSVID(name)
+				if !ok {
+					return nil, certificate.CertificateNotFoundErr
+				}
+
+				return s.leaf, nil
+
+			},
+			Renew: func(ctx context.Context) error {
+
+				if !a.isLoaded() {
+
+					err := a.Load(ctx)
+					if err != nil {
+						return err
+					}
+
+				}
+
+				return a.Issue(name)
+
+			},
+		})
+
+	}
+
+	return certs
+
+}
+
+// identities returns the node and the services requiring mTLS
+func (a *Authority) identities(services []*sdapi.Service) []string {
+
+	names := []string{a.options.NodeID}
+	seen := map[string]bool{a.options.NodeID: true}
+
+	for _, svc := range services {
+
+		if svc.DisableMutualTls || seen[svc.ServiceName] {
+			continue
+		}
+
+		if svc.EnvoyCluster == nil && svc.EnvoyHttpConnectionManager == nil {
+			continue
+		}
+
+		seen[svc.ServiceName] = true
+
+		names = append(names, svc.ServiceName)
+
+	}
+
+	return names
+
+}
+
+// BundleSecretName is the name of the SDS secret validating the trust domain
+func (a *Authority) BundleSecretName() string {
+	return "spiffe://" + a.options.TrustDomain
+}
+
+func (a *Authority) sdsSecretConfig(name string, xdsClusterName string) *tlsv3.SdsSecretConfig {
+
+	return &tlsv3.SdsSecretConfig{
+		Name:      name,
+		SdsConfig: servicemesh.XdsConfigSource(xdsClusterName),
+	}
+
+}
+
+// validationContext validates peers against the trust bundle, accepting the
+// SPIFFE IDs matched by the matcher
+func (a *Authority) validationContext(xdsClusterName string, matcher *matcherv3.StringMatcher) *tlsv3.CommonTlsContext_CombinedValidationContext {
+
+	return &tlsv3.CommonTlsContext_CombinedValidationContext{
+		CombinedValidationContext: &tlsv3.CommonTlsContext_CombinedCertificateValidationContext{
+			DefaultValidationContext: &tlsv3.CertificateValidationContext{
+				MatchTypedSubjectAltNames: []*tlsv3.SubjectAltNameMatcher{{
+					SanType: tlsv3.SubjectAltNameMatcher_URI,
+					Matcher: matcher,
+				}},
+			},
+			ValidationContextSdsSecretConfig: a.sdsSecretConfig(a.BundleSecretName(), xdsClusterName),
+		},
+	}
+
+}
+
+func (a *Authority) downstreamTransportSocket(svc *sdapi.Service) *corev3.TransportSocket {
+
+	return servicemesh.TlsTransportSocket(&tlsv3.DownstreamTlsContext{
+		RequireClientCertificate: wrapperspb.Bool(true),
+		CommonTlsContext: &tlsv3.CommonTlsContext{
+			AlpnProtocols: []string{"h2", "http/1.1"},
+			TlsCertificateSdsSecretConfigs: []*tlsv3.SdsSecretConfig{
+				a.sdsSecretConfig(a.SpiffeID(svc.ServiceName), svc.XdsClusterName),
+			},
+			ValidationContextType: a.validationContext(svc.XdsClusterName, &matcherv3.StringMatcher{
+				MatchPattern: &matcherv3.StringMatcher_Prefix{Prefix: a.TrustDomainID()},
+			}),
+		},
+	})
+
+}
+
+func (a *Authority) upstreamTransportSocket(svc *sdapi.Service) *corev3.TransportSocket {
+
+	return servicemesh.TlsTransportSocket(&tlsv3.UpstreamTlsContext{
+		Sni: svc.ServiceName,
+		CommonTlsContext: &tlsv3.CommonTlsContext{
+			AlpnProtocols: []string{"h2", "http/1.1"},
+			TlsCertificateSdsSecretConfigs: []*tlsv3.SdsSecretConfig{
+				a.sdsSecretConfig(a.SpiffeID(a.options.NodeID), svc.XdsClusterName),
+			},
+			ValidationContextType: a.validationContext(svc.XdsClusterName, &matcherv3.StringMatcher{
+				MatchPattern: &matcherv3.StringMatcher_Exact{Exact: a.SpiffeID(svc.ServiceName)},
+			}),
+		},
+	})
+
+}
+
+func (a *Authority) secret(name string, s *svid) *tlsv3.Secret {
+
+	return &tlsv3.Secret{
+		Name: a.SpiffeID(name),
+		Type: &tlsv3.Secret_TlsCertificate{
+			TlsCertificate: &tlsv3.TlsCertificate{
+				CertificateChain: &corev3.DataSource{
+					Specifier: &corev3.DataSource_InlineBytes{InlineBytes: s.chain},
+				},
+				PrivateKey: &corev3.DataSource{
+					Specifier: &corev3.DataSource_InlineBytes{InlineBytes: s.key},
+				},
+			},
+		},
+	}
+
+}
+
+func (a *Authority) bundleSecret() *tlsv3.Secret {
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return &tlsv3.Secret{
+		Name: a.BundleSecretName(),
+		Type: &tlsv3.Secret_ValidationContext{
+			ValidationContext: &tlsv3.CertificateValidationContext{
+				TrustedCa: &corev3.DataSource{
+					Specifier: &corev3.DataSource_InlineBytes{InlineBytes: a.bundle},
+				},
+			},
+		},
+	}
+
+}
+
+// MutateResources requires mTLS on the listeners and clusters of the
+// services, adding the SVIDs and the trust bundle secrets. Listeners already
+// terminating the services certificates are left as is. When the CA can't be
+// loaded, the secrets are missing and Envoy doesn't accept plaintext.
+func (a *Authority) MutateResources(ctx context.Context, res *servicemesh.Resources) {
+
+	if !a.isLoaded() {
+
+		err := a.Load(ctx)
+		if err != nil {
+			a.logger.Errorw("Unable to load internal CA", "error", err)
+		}
+
+	}
+
+	names := make(map[string]bool)
+
+	for _, name := range a.identities(res.GetServices()) {
+
+		names[name] = true
+
+		s, ok := a.getSVID(name)
+
+		if !ok || time.Now().After(s.leaf.NotAfter) {
+
+			err := a.Issue(name)
+			if err != nil {
+				a.logger.Errorw("Unable to issue SVID", "spiffe_id", a.SpiffeID(name), "error", err)
+				continue
+			}
+
+			s, _ = a.getSVID(name)
+
+		}
+
+		res.AddResource(resource.SecretType, a.secret(name, s))
+
+	}
+
+	a.retain(names)
+
+	if a.isLoaded() {
+		res.AddResource(resource.SecretType, a.bundleSecret())
+	}
+
+	services := make(map[string]*sdapi.Service)
+
+	for _, svc := range res.GetServices() {
+		if !svc.DisableMutualTls {
+			services[svc.ServiceName] = svc
+		}
+	}
+
+	for _, r := range res.GetResources(resource.ListenerType) {
+
+		listener := r.(*listenerv3.Listener)
+
+		svc, ok := services[listener.Name]
+		if !ok {
+			continue
+		}
+
+		for _, filterChain := range listener.FilterChains {
+			if filterChain.TransportSocket == nil {
+				filterChain.TransportSocket = a.downstreamTransportSocket(svc)
+			}
+		}
+
+	}
+
+	for _, r := range res.GetResources(resource.ClusterType) {
+
+		cluster := r.(*clusterv3.Cluster)
+
+		svc, ok := services[cluster.Name]
+		if !ok {
+			continue
+		}
+
+		if cluster.TransportSocket == nil {
+			cluster.TransportSocket = a.upstreamTransportSocket(svc)
+		}
+
+	}
+
+}
@@ -0,0 +1,10 @@
+package certificate
+
+import "errors"
+
+var (
+	CertificateNotFoundErr  = errors.New("Certificate not found in the parameter file source")
+	NotRenewableErr         = errors.New("Certificate must be renewed but its issuer is not managed by hike")
+	InvalidCheckIntervalErr = errors.New("Certificate check interval and timeout must be positive")
+	InvalidBackoffErr       = errors.New("Certificate min backoff must be positive and not greater than the max backoff")
+)
@@ -0,0 +1,52 @@
+package certificate
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+var metricLabelReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func writeMetricHeader(w io.Writer, name string, help string) {
+
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s gauge\n", name)
+
+}
+
+// ServeHTTP writes the certificates metrics in the Prometheus text format
+func (m *Monitor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	statuses := m.Statuses()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	writeMetricHeader(w, "hike_certificate_expiry_days", "Days until the certificate expires, negative when expired")
+
+	for _, status := range statuses {
+
+		if status.NotAfter == nil {
+			continue
+		}
+
+		fmt.Fprintf(
+			w, "hike_certificate_expiry_days{name=\"%s\",issuer=\"%s\"} %g\n",
+			metricLabelReplacer.Replace(status.Name), metricLabelReplacer.Replace(status.Issuer), status.DaysToExpiry,
+		)
+
+	}
+
+	writeMetricHeader(w, "hike_certificate_failures", "Consecutive failures to load or renew the certificate")
+
+	for _, status := range statuses {
+
+		fmt.Fprintf(
+			w, "hike_certificate_failures{name=\"%s\",issuer=\"%s\"} %d\n",
+			metricLabelReplacer.Replace(status.Name), metricLabelReplacer.Replace(status.Issuer), status.Failures,
+		)
+
+	}
+
+}
@@ -0,0 +1,451 @@
+package certificate
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/upper-institute/hike/pkg/helpers"
+	"github.com/upper-institute/hike/pkg/parameter"
+	"github.com/upper-institute/hike/pkg/servicemesh"
+	sdapi "github.com/upper-institute/hike/proto/api/service-discovery"
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultRenewBefore   = 30 * 24 * time.Hour
+	DefaultCheckInterval = time.Hour
+	DefaultCheckTimeout  = 10 * time.Minute
+	DefaultMinBackoff    = time.Minute
+	DefaultMaxBackoff    = 6 * time.Hour
+
+	UploadedIssuer = "uploaded"
+)
+
+// Certificate stored as a file parameter, the leaf is the first PEM block
+// of the certificate parameter
+type Certificate struct {
+	// Name of the SDS secret serving the certificate
+	Name                    string
+	Issuer                  string
+	ParameterFileSource     string
+	CertificateParameterKey string
+	// Optional, the certificate is renewed when its DNS names differ
+	Domains []string
+	// Optional, window before expiry to renew the certificate, default is
+	// the monitor one, e.g. shorter for short-lived certificates
+	RenewBefore time.Duration
+	// Optional, loads a certificate not stored as a file parameter
+	Load func(ctx context.Context) (*x509.Certificate, error)
+	// Issues the certificate again and stores it, nil when the issuer is
+	// not managed by hike
+	Renew func(ctx context.Context) error
+}
+
+// Provider returns the certificates it issues for the services
+type Provider interface {
+	Certificates(services []*sdapi.Service) []*Certificate
+}
+
+type MonitorOptions struct {
+	SourceOptions *parameter.SourceOptions
+	Providers     []Provider
+	// Window before expiry to renew the certificates
+	RenewBefore   time.Duration
+	CheckInterval time.Duration
+	// Max time to load and renew a certificate, e.g. a stuck ACME order
+	CheckTimeout time.Duration
+	// Bounds of the exponential backoff after failures
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// Called after a certificate is renewed, e.g. to serve it right away
+	OnRenewed func(cert *Certificate)
+}
+
+func (options *MonitorOptions) NewMonitor(logger *zap.SugaredLogger) (*Monitor, error) {
+
+	// The cycle would spin without waiting between checks
+	if options.CheckInterval <= 0 || options.CheckTimeout <= 0 {
+		return nil, InvalidCheckIntervalErr
+	}
+
+	if options.MinBackoff <= 0 || options.MaxBackoff < options.MinBackoff {
+		return nil, InvalidBackoffErr
+	}
+
+	return &Monitor{
+		options:  options,
+		logger:   logger.With("part", "certificate/monitor"),
+		statuses: make(map[string]*certificateStatus),
+		wakeCh:   make(chan struct{}, 1),
+	}, nil
+
+}
+
+type certificateStatus struct {
+	cert      *Certificate
+	leaf      *x509.Certificate
+	failures  uint32
+	lastError error
+	lastCheck time.Time
+	nextCheck time.Time
+	renewedAt time.Time
+}
+
+// Monitor checks the expiry of the services certificates, renewing the ones
+// within the renew window, missing or issued for other domains
+type Monitor struct {
+	options *MonitorOptions
+
+	logger *zap.SugaredLogger
+
+	mu       sync.Mutex
+	statuses map[string]*certificateStatus
+
+	wakeCh chan struct{}
+}
+
+// ObserveServices replaces the monitored certificates by the ones of the
+// services and wakes up the cycle, it doesn't block the discovery
+func (m *Monitor) ObserveServices(ctx context.Context, services []*sdapi.Service) {
+
+	certs := make(map[string]*Certificate)
+
+	for _, svc := range services {
+
+		for _, tlsCert := range svc.TlsCertificates {
+
+			name := servicemesh.TlsSecretName(tlsCert)
+
+			certs[name] = &Certificate{
+				Name:                    name,
+				Issuer:                  UploadedIssuer,
+				ParameterFileSource:     tlsCert.ParameterFileSource,
+				CertificateParameterKey: tlsCert.CertificateParameterKey,
+			}
+
+		}
+
+	}
+
+	for _, provider := range m.options.Providers {
+		for _, cert := range provider.Certificates(services) {
+			certs[cert.Name] = cert
+		}
+	}
+
+	m.mu.Lock()
+
+	statuses := make(map[string]*certificateStatus)
+
+	for name, cert := range certs {
+
+		status, ok := m.statuses[name]
+
+		if !ok || !sameCertificate(status.cert, cert) {
+			status = &certificateStatus{}
+		}
+
+		status.cert = cert
+
+		statuses[name] = status
+
+	}
+
+	m.statuses = statuses
+
+	m.mu.Unlock()
+
+	select {
+	case m.wakeCh <- struct{}{}:
+	default:
+	}
+
+}
+
+func sameCertificate(a *Certificate, b *Certificate) bool {
+
+	return a.Issuer == b.Issuer &&
+		a.RenewBefore == b.RenewBefore &&
+		a.ParameterFileSource == b.ParameterFileSource &&
+		a.CertificateParameterKey == b.CertificateParameterKey &&
+		matchDomains(a.Domains, b.Domains)
+
+}
+
+func matchDomains(dnsNames []string, domains []string) bool {
+
+	if len(dnsNames) != len(domains) {
+		return false
+	}
+
+	dnsNames = append([]string{}, dnsNames...)
+	domains = append([]string{}, domains...)
+
+	sort.Strings(dnsNames)
+	sort.Strings(domains)
+
+	for i := range dnsNames {
+		if !strings.EqualFold(dnsNames[i], domains[i]) {
+			return false
+		}
+	}
+
+	return true
+
+}
+
+func (m *Monitor) cycle() {
+
+	for {
+
+		now := time.Now()
+		wait := m.options.CheckInterval
+
+		m.mu.Lock()
+
+		due := []*certificateStatus{}
+
+		for _, status := range m.statuses {
+
+			if !now.Before(status.nextCheck) {
+				due = append(due, status)
+			} else if status.nextCheck.Sub(now) < wait {
+				wait = status.nextCheck.Sub(now)
+			}
+
+		}
+
+		m.mu.Unlock()
+
+		if len(due) > 0 {
+
+			for _, status := range due {
+
+				ctx, cancel := context.WithTimeout(context.Background(), m.options.CheckTimeout)
+
+				m.check(ctx, status)
+
+				cancel()
+
+			}
+
+			continue
+
+		}
+
+		select {
+		case <-m.wakeCh:
+		case <-time.After(wait):
+		}
+
+	}
+
+}
+
+func (m *Monitor) StartCycle() {
+
+	go m.cycle()
+
+}
+
+func (m *Monitor) load(ctx context.Context, cert *Certificate) (*x509.Certificate, error) {
+
+	if cert.Load != nil {
+		return cert.Load(ctx)
+	}
+
+	source, err := m.options.SourceOptions.NewFromURLString(cert.ParameterFileSource)
+	if err != nil {
+		return nil, err
+	}
+
+	err = source.Restore(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !source.Has(certificateParameterKey(cert)) {
+		return nil, CertificateNotFoundErr
+	}
+
+	param := source.Get(certificateParameterKey(cert))
+
+	err = param.Load(ctx)
+
+	if errors.Is(err, parameter.FileNotFoundErr) {
+		return nil, CertificateNotFoundErr
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return helpers.ParseLeafCertificate(param.GetFile().Bytes())
+
+}
+
+func certificateParameterKey(cert *Certificate) string {
+
+	return servicemesh.TlsCertificateParameterKey(&sdapi.TlsCertificate{
+		CertificateParameterKey: cert.CertificateParameterKey,
+	})
+
+}
+
+func (m *Monitor) needsRenewal(cert *Certificate, leaf *x509.Certificate) bool {
+
+	if leaf == nil {
+		return true
+	}
+
+	if len(cert.Domains) > 0 && !matchDomains(leaf.DNSNames, cert.Domains) {
+		return true
+	}
+
+	return time.Until(leaf.NotAfter) <= m.renewBefore(cert)
+
+}
+
+func (m *Monitor) renewBefore(cert *Certificate) time.Duration {
+
+	if cert.RenewBefore > 0 {
+		return cert.RenewBefore
+	}
+
+	return m.options.RenewBefore
+
+}
+
+// check loads the certificate and renews it when needed, then schedules
+// the next check
+func (m *Monitor) check(ctx context.Context, status *certificateStatus) {
+
+	m.mu.Lock()
+	cert := status.cert
+	m.mu.Unlock()
+
+	logger := m.logger.With("certificate", cert.Name, "issuer", cert.Issuer)
+
+	leaf, err := m.load(ctx, cert)
+
+	if err != nil && !errors.Is(err, CertificateNotFoundErr) {
+		m.fail(logger, status, nil, err)
+		return
+	}
+
+	renewed := false
+
+	if m.needsRenewal(cert, leaf) {
+
+		if cert.Renew == nil {
+
+			if err == nil {
+				err = NotRenewableErr
+			}
+
+			m.fail(logger, status, leaf, err)
+			return
+
+		}
+
+		logger.Infow("Renewing certificate")
+
+		err = cert.Renew(ctx)
+		if err != nil {
+			m.fail(logger, status, leaf, err)
+			return
+		}
+
+		leaf, err = m.load(ctx, cert)
+		if err != nil {
+			m.fail(logger, status, nil, err)
+			return
+		}
+
+		renewed = true
+
+		logger.Infow("Certificate renewed", "not_after", leaf.NotAfter)
+
+	}
+
+	now := time.Now()
+
+	nextCheck := now.Add(m.options.CheckInterval)
+
+	if renewAt := leaf.NotAfter.Add(-m.renewBefore(cert)); renewAt.Before(nextCheck) {
+		nextCheck = renewAt
+	}
+
+	// A renew window longer than the certificate lifetime would renew it
+	// again right away
+	if minCheck := now.Add(m.options.MinBackoff); nextCheck.Before(minCheck) {
+		nextCheck = minCheck
+	}
+
+	m.mu.Lock()
+
+	status.leaf = leaf
+	status.failures = 0
+	status.lastError = nil
+	status.lastCheck = now
+	status.nextCheck = nextCheck
+
+	if renewed {
+		status.renewedAt = now
+	}
+
+	m.mu.Unlock()
+
+	if renewed && m.options.OnRenewed != nil {
+		m.options.OnRenewed(cert)
+	}
+
+}
+
+// fail records the error and backs off exponentially before the next check
+func (m *Monitor) fail(logger *zap.SugaredLogger, status *certificateStatus, leaf *x509.Certificate, err error) {
+
+	now := time.Now()
+
+	m.mu.Lock()
+
+	if leaf != nil {
+		status.leaf = leaf
+	}
+
+	status.failures++
+	status.lastError = err
+	status.lastCheck = now
+
+	backoff := m.options.MinBackoff
+
+	for i := uint32(1); i < status.failures && backoff < m.options.MaxBackoff; i++ {
+		backoff *= 2
+	}
+
+	if backoff > m.options.MaxBackoff {
+		backoff = m.options.MaxBackoff
+	}
+
+	status.nextCheck = now.Add(backoff)
+
+	failures := status.failures
+	nextCheck := status.nextCheck
+	leaf = status.leaf
+
+	m.mu.Unlock()
+
+	fields := []interface{}{"failures", failures, "next_check", nextCheck, "error", err}
+
+	if leaf != nil {
+		fields = append(fields, "not_after", leaf.NotAfter)
+	}
+
+	logger.Errorw("Certificate check failed", fields...)
+
+}
@@ -0,0 +1,103 @@
+package certificate
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	certapi "github.com/upper-institute/hike/proto/api/certificate"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func timestampOrNil(t time.Time) *timestamppb.Timestamp {
+
+	if t.IsZero() {
+		return nil
+	}
+
+	return timestamppb.New(t)
+
+}
+
+// Statuses returns the status of the monitored certificates sorted by name
+func (m *Monitor) Statuses() []*certapi.CertificateStatus {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+
+	statuses := make([]*certapi.CertificateStatus, 0, len(m.statuses))
+
+	for name, status := range m.statuses {
+
+		certStatus := &certapi.CertificateStatus{
+			Name:                name,
+			Issuer:              status.cert.Issuer,
+			ParameterFileSource: status.cert.ParameterFileSource,
+			Domains:             status.cert.Domains,
+			Renewable:           status.cert.Renew != nil,
+			Failures:            status.failures,
+			LastCheck:           timestampOrNil(status.lastCheck),
+			NextCheck:           timestampOrNil(status.nextCheck),
+			RenewedAt:           timestampOrNil(status.renewedAt),
+		}
+
+		if len(status.cert.ParameterFileSource) > 0 {
+			certStatus.CertificateParameterKey = certificateParameterKey(status.cert)
+		}
+
+		if status.lastError != nil {
+			certStatus.LastError = status.lastError.Error()
+		}
+
+		if status.leaf != nil {
+			certStatus.DnsNames = status.leaf.DNSNames
+			certStatus.NotBefore = timestamppb.New(status.leaf.NotBefore)
+			certStatus.NotAfter = timestamppb.New(status.leaf.NotAfter)
+			certStatus.DaysToExpiry = daysToExpiry(now, status.leaf.NotAfter)
+		}
+
+		statuses = append(statuses, certStatus)
+
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+
+	return statuses
+
+}
+
+func daysToExpiry(now time.Time, notAfter time.Time) float64 {
+	return notAfter.Sub(now).Hours() / 24
+}
+
+// CertificateServer serves the monitor status through the admin API
+type CertificateServer struct {
+	certapi.UnimplementedCertificateServiceServer
+
+	monitor *Monitor
+}
+
+func (m *Monitor) NewServer() *CertificateServer {
+
+	return &CertificateServer{monitor: m}
+
+}
+
+func (s *CertificateServer) Register(registrar grpc.ServiceRegistrar) {
+
+	certapi.RegisterCertificateServiceServer(registrar, s)
+
+}
+
+func (s *CertificateServer) ListCertificates(ctx context.Context, req *certapi.ListCertificatesRequest) (*certapi.ListCertificatesResponse, error) {
+
+	return &certapi.ListCertificatesResponse{
+		Certificates: s.monitor.Statuses(),
+	}, nil
+
+}
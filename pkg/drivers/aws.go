@@ -5,10 +5,12 @@ import (
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
 	"github.com/aws/aws-sdk-go-v2/service/route53"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
-	"github.com/aws/aws-sdk-go-v2/service/servicediscovery"
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/go-acme/lego/v4/challenge"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 	awsdriver "github.com/upper-institute/hike/pkg/drivers/aws"
@@ -19,12 +21,27 @@ import (
 )
 
 const (
+	DriversAwsRegion                         = "drivers.aws.region"
+	DriversAwsProfile                        = "drivers.aws.profile"
+	DriversAwsEndpointUrl                    = "drivers.aws.endpoint.url"
+	DriversAwsCaBundle                       = "drivers.aws.ca.bundle"
 	DriversAwsSsmParameterStoreEnable        = "drivers.aws.ssm.parameter.store.enable"
+	DriversAwsSsmSecureString                = "drivers.aws.ssm.secure.string"
 	DriversAwsS3ParameterStorageEnable       = "drivers.aws.s3.parameter.storage.enable"
+	DriversAwsS3PathStyle                    = "drivers.aws.s3.path.style"
+	DriversAwsKmsKeyProviderEnable           = "drivers.aws.kms.key.provider.enable"
 	DriversAwsRoute53DomainRegistryEnable    = "drivers.aws.route53.domain.registry.enable"
+	DriversAwsRoute53Dns01PropagationTimeout = "drivers.aws.route53.dns01.propagation.timeout"
+	DriversAwsRoute53Dns01PollingInterval    = "drivers.aws.route53.dns01.polling.interval"
+	DriversAwsRoute53OwnerId                 = "drivers.aws.route53.owner.id"
+	DriversAwsRoute53AdoptRecords            = "drivers.aws.route53.adopt.records"
+	DriversAwsRoute53GcEnable                = "drivers.aws.route53.gc.enable"
+	DriversAwsRoute53GcGracePeriod           = "drivers.aws.route53.gc.grace.period"
+	DriversAwsRoute53GcDryRun                = "drivers.aws.route53.gc.dry.run"
 	DriversAwsCloudMapServiceDiscoveryEnable = "drivers.aws.cloudmap.service.discovery.enable"
 	DriversAwsCloudMapNamespacesNames        = "drivers.aws.cloudmap.namespaces.names"
 	DriversAwsCloudMapParameterUriTag        = "drivers.aws.cloudmap.parameter.uri.tag"
+	DriversAwsCloudMapTargets                = "drivers.aws.cloudmap.targets"
 )
 
 type AWSDriver struct {
@@ -32,19 +49,44 @@ type AWSDriver struct {
 
 	config aws.Config
 
+	// Config of each subsystem, with its own credentials, region and endpoint
+	configs map[string]aws.Config
+
+	domainRegistry *awsdriver.Route53DomainRegistry
+
+	// Parsed on load, invalid targets fail before serving an empty mesh
+	cloudMapTargets []*awsdriver.CloudMapDiscoveryTarget
+
 	binder *helpers.FlagBinder
 }
 
 func (d *AWSDriver) Bind(flagSet *pflag.FlagSet, cfg *viper.Viper) {
 
-	d.binder = &helpers.FlagBinder{cfg, flagSet}
+	d.binder = &helpers.FlagBinder{Viper: cfg, FlagSet: flagSet}
 
+	d.binder.BindString(DriversAwsRegion, "", "AWS region, overrides the region from the environment and shared config")
+	d.binder.BindString(DriversAwsProfile, "", "AWS shared config profile")
+	d.binder.BindString(DriversAwsEndpointUrl, "", "Custom endpoint URL of every AWS client, for LocalStack style stand-ins")
+	d.binder.BindString(DriversAwsCaBundle, "", "Path to a PEM bundle of CA certificates trusted by the AWS clients (e.g. a MinIO CA)")
 	d.binder.BindBool(DriversAwsSsmParameterStoreEnable, false, "Use AWS SSM Parameter Store to pull/push parameters (files and envs)")
+	d.binder.BindBool(DriversAwsSsmSecureString, false, "Create new SSM parameters as SecureString (existing parameters keep their type)")
 	d.binder.BindBool(DriversAwsS3ParameterStorageEnable, false, "Use AWS S3 Parameter Storage to download/uploade files from parameter store")
-	d.binder.BindBool(DriversAwsRoute53DomainRegistryEnable, false, "Use AWS Route53 domain registry service")
+	d.binder.BindBool(DriversAwsS3PathStyle, false, "Use path-style addressing (endpoint/bucket/key) for S3, usually required by S3 compatible storages")
+	d.binder.BindBool(DriversAwsKmsKeyProviderEnable, false, "Use AWS KMS to encrypt file parameters (query encryption=kms&encryption_key=<key id or alias>)")
+	d.binder.BindBool(DriversAwsRoute53DomainRegistryEnable, false, "Use AWS Route53 domain registry service (also solves ACME DNS-01 challenges)")
+	d.binder.BindDuration(DriversAwsRoute53Dns01PropagationTimeout, awsdriver.DefaultDNS01PropagationTimeout, "Max time to wait for ACME DNS-01 challenge records to be INSYNC and propagated")
+	d.binder.BindDuration(DriversAwsRoute53Dns01PollingInterval, awsdriver.DefaultDNS01PollingInterval, "Interval between checks of ACME DNS-01 challenge records")
+	d.binder.BindString(DriversAwsRoute53OwnerId, awsdriver.DefaultRoute53OwnerID, "ID of this hike instance in the ownership TXT records, only owned DNS records are changed")
+	d.binder.BindBool(DriversAwsRoute53AdoptRecords, false, "Take ownership of existing DNS records without ownership TXT records")
+	d.binder.BindBool(DriversAwsRoute53GcEnable, false, "Delete the owned DNS records of services no longer discovered")
+	d.binder.BindDuration(DriversAwsRoute53GcGracePeriod, awsdriver.DefaultRoute53GCGracePeriod, "Time an owned DNS record must be missing from discovery before being deleted")
+	d.binder.BindBool(DriversAwsRoute53GcDryRun, false, "Only report the owned DNS records which would be deleted")
 	d.binder.BindBool(DriversAwsCloudMapServiceDiscoveryEnable, false, "Use AWS Cloud Map service discovery service")
 	d.binder.BindStringSlice(DriversAwsCloudMapNamespacesNames, []string{}, "AWS CloudMap (Service Discovery) namespaces to watch for services and instances")
 	d.binder.BindString(DriversAwsCloudMapParameterUriTag, "parameter_uri", "Tag in the Cloud Map Service resource to discover parameter envs and files")
+	d.binder.BindStringArray(DriversAwsCloudMapTargets, []string{}, "Cloud Map targets queried in parallel, as region=<region>&role_arn=<arn>&external_id=<id>&namespaces=<ns1,ns2> (default is the cloudmap client with the namespaces names)")
+
+	d.bindSubsystems()
 
 }
 
@@ -52,7 +94,12 @@ func (d *AWSDriver) Load(ctx context.Context, logger *zap.SugaredLogger) error {
 
 	d.logger = logger
 
-	config, err := config.LoadDefaultConfig(ctx)
+	loadOptions, err := d.loadOptions()
+	if err != nil {
+		return err
+	}
+
+	config, err := config.LoadDefaultConfig(ctx, loadOptions...)
 	if err != nil {
 		return err
 	}
@@ -67,59 +114,99 @@ func (d *AWSDriver) Load(ctx context.Context, logger *zap.SugaredLogger) error {
 
 	d.config = config
 
+	d.configs = make(map[string]aws.Config)
+
+	for _, subsystem := range awsSubsystems {
+
+		d.configs[subsystem], err = d.loadSubsystemConfig(ctx, subsystem)
+		if err != nil {
+			return err
+		}
+
+	}
+
+	if d.binder.Viper.GetBool(DriversAwsCloudMapServiceDiscoveryEnable) {
+
+		d.cloudMapTargets, err = d.parseCloudMapTargets()
+		if err != nil {
+			return err
+		}
+
+	}
+
 	return nil
 
 }
 
+func (d *AWSDriver) GetPrincipal(ctx context.Context) (string, error) {
+
+	if !d.binder.Viper.GetBool(DriversAwsSsmParameterStoreEnable) && !d.binder.Viper.GetBool(DriversAwsS3ParameterStorageEnable) {
+		return "", nil
+	}
+
+	getCallerIdentityOutput, err := sts.NewFromConfig(d.configs[AwsSsmSubsystem]).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", err
+	}
+
+	return aws.ToString(getCallerIdentityOutput.Arn), nil
+
+}
+
 func (d *AWSDriver) ApplyParameterSourceOptions(opts *parameter.SourceOptions) {
 
 	if d.binder.Viper.GetBool(DriversAwsSsmParameterStoreEnable) {
 
-		ssmClient := ssm.NewFromConfig(d.config)
+		ssmClient := ssm.NewFromConfig(d.configs[AwsSsmSubsystem])
 
-		store := awsdriver.NewSSMParameterStore(ssmClient, d.logger)
+		store := awsdriver.NewSSMParameterStore(
+			ssmClient,
+			d.binder.Viper.GetBool(DriversAwsSsmSecureString),
+			d.logger,
+		)
 
-		opts.Store = store
-		opts.ParameterOptions.Writer = store
+		opts.RegisterStore(awsdriver.SSMParameterStoreScheme, store)
 
 	}
 
 	if d.binder.Viper.GetBool(DriversAwsS3ParameterStorageEnable) {
 
-		s3Client := s3.NewFromConfig(d.config)
+		s3Client := s3.NewFromConfig(d.configs[AwsS3Subsystem], func(o *s3.Options) {
+			o.UsePathStyle = d.binder.Viper.GetBool(DriversAwsS3PathStyle)
+
+		})
 
 		storage := awsdriver.NewS3ParameterStorage(s3Client, d.logger)
 
 		opts.ParameterOptions.Downloader = storage
 		opts.ParameterOptions.Uploader = storage
+		opts.ParameterOptions.Lister = storage
+		opts.ParameterOptions.Remover = storage
 
 	}
 
-}
-
-func (d *AWSDriver) GetEnvoyDiscoveryServices(cacheOptions *parameter.SourceOptions) []servicemesh.EnvoyDiscoveryService {
+	if d.binder.Viper.GetBool(DriversAwsKmsKeyProviderEnable) {
 
-	services := []servicemesh.EnvoyDiscoveryService{}
+		kmsClient := kms.NewFromConfig(d.configs[AwsKmsSubsystem])
 
-	if d.binder.Viper.GetBool(DriversAwsCloudMapServiceDiscoveryEnable) {
+		provider := awsdriver.NewKMSKeyProvider(kmsClient, d.logger)
 
-		cloudMapClient := servicediscovery.NewFromConfig(d.config)
+		opts.RegisterKeyProvider(awsdriver.KMSKeyProviderName, provider)
 
-		var domainRegistry *awsdriver.Route53DomainRegistry = nil
+	}
 
-		if d.binder.Viper.GetBool(DriversAwsRoute53DomainRegistryEnable) {
+}
 
-			route53Client := route53.NewFromConfig(d.config)
+func (d *AWSDriver) GetEnvoyDiscoveryServices(cacheOptions *parameter.SourceOptions, domainRegistry servicemesh.DomainRegistry) []servicemesh.EnvoyDiscoveryService {
 
-			domainRegistry = awsdriver.NewRoute53DomainRegistry(route53Client, d.logger)
+	services := []servicemesh.EnvoyDiscoveryService{}
 
-		}
+	if d.binder.Viper.GetBool(DriversAwsCloudMapServiceDiscoveryEnable) {
 
 		service := awsdriver.NewCloudMapServiceDiscovery(
-			d.binder.Viper.GetStringSlice(DriversAwsCloudMapNamespacesNames),
+			d.cloudMapTargets,
 			d.binder.Viper.GetString(DriversAwsCloudMapParameterUriTag),
 			cacheOptions,
-			cloudMapClient,
 			d.logger,
 			domainRegistry,
 		)
@@ -131,3 +218,59 @@ func (d *AWSDriver) GetEnvoyDiscoveryServices(cacheOptions *parameter.SourceOpti
 	return services
 
 }
+
+// getDomainRegistry returns the Route53 domain registry shared by the service
+// discovery and the DNS-01 challenges, nil when not enabled
+func (d *AWSDriver) getDomainRegistry() *awsdriver.Route53DomainRegistry {
+
+	if !d.binder.Viper.GetBool(DriversAwsRoute53DomainRegistryEnable) {
+		return nil
+	}
+
+	if d.domainRegistry == nil {
+
+		route53Client := route53.NewFromConfig(d.configs[AwsRoute53Subsystem])
+
+		d.domainRegistry = awsdriver.NewRoute53DomainRegistry(
+			route53Client,
+			d.binder.Viper.GetDuration(DriversAwsRoute53Dns01PropagationTimeout),
+			d.binder.Viper.GetDuration(DriversAwsRoute53Dns01PollingInterval),
+			&awsdriver.Route53OwnershipOptions{
+				OwnerID:       d.binder.Viper.GetString(DriversAwsRoute53OwnerId),
+				AdoptRecords:  d.binder.Viper.GetBool(DriversAwsRoute53AdoptRecords),
+				GCEnable:      d.binder.Viper.GetBool(DriversAwsRoute53GcEnable),
+				GCGracePeriod: d.binder.Viper.GetDuration(DriversAwsRoute53GcGracePeriod),
+				GCDryRun:      d.binder.Viper.GetBool(DriversAwsRoute53GcDryRun),
+			},
+			d.logger,
+		)
+
+	}
+
+	return d.domainRegistry
+
+}
+
+func (d *AWSDriver) GetDomainRegistry() servicemesh.DomainRegistry {
+
+	domainRegistry := d.getDomainRegistry()
+
+	if domainRegistry == nil {
+		return nil
+	}
+
+	return domainRegistry
+
+}
+
+func (d *AWSDriver) GetDNS01Provider() challenge.Provider {
+
+	domainRegistry := d.getDomainRegistry()
+
+	if domainRegistry == nil {
+		return nil
+	}
+
+	return domainRegistry
+
+}
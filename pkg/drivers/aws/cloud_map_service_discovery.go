@@ -2,10 +2,13 @@ package awsdriver
 
 import (
 	"context"
+	"sort"
+	"sync"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/servicediscovery"
 	servicediscoverytypes "github.com/aws/aws-sdk-go-v2/service/servicediscovery/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	endpointv3 "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
 	"github.com/upper-institute/hike/pkg/parameter"
@@ -14,11 +17,51 @@ import (
 	sdapi "github.com/upper-institute/hike/proto/api/service-discovery"
 	"go.uber.org/zap"
 	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
 )
 
+// Filter metadata namespace of the endpoints discovered in Cloud Map
+const CloudMapEndpointMetadataNamespace = "hike.cloudmap"
+
+// CloudMapDiscoveryTarget is a Cloud Map client of one account and region,
+// with the namespaces to watch
+type CloudMapDiscoveryTarget struct {
+	Region          string
+	AccountID       string
+	NamespacesNames []string
+	CloudMapClient  *servicediscovery.Client
+	// Optional, used to find the account ID when it's not known
+	StsClient *sts.Client
+
+	accountOnce sync.Once
+}
+
+func (t *CloudMapDiscoveryTarget) resolveAccountID(ctx context.Context, logger *zap.SugaredLogger) {
+
+	t.accountOnce.Do(func() {
+
+		if len(t.AccountID) > 0 || t.StsClient == nil {
+			return
+		}
+
+		getCallerIdentityOutput, err := t.StsClient.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+		if err != nil {
+			logger.Warnw("Unable to find the account ID of the Cloud Map target", "error", err)
+			return
+		}
+
+		t.AccountID = aws.ToString(getCallerIdentityOutput.Account)
+
+	})
+
+}
+
 type cloudMapServiceDiscovery_operation struct {
 	ctx context.Context
 
+	target *CloudMapDiscoveryTarget
+
 	serviceSummary servicediscoverytypes.ServiceSummary
 
 	service *sdapi.Service
@@ -27,44 +70,46 @@ type cloudMapServiceDiscovery_operation struct {
 }
 
 type cloudMapServiceDiscovery struct {
-	namespacesNames []string
+	targets         []*CloudMapDiscoveryTarget
 	parameterUriTag string
 
 	parameterSourceOptions *parameter.SourceOptions
-	cloudMapClient         *servicediscovery.Client
 
 	logger *zap.SugaredLogger
 
-	domainRegistry *Route53DomainRegistry
+	domainRegistry servicemesh.DomainRegistry
+
+	// Services of each target in the last successful discovery, kept when
+	// the target fails so Envoy doesn't lose its clusters
+	lastTargetServices [][]*sdapi.Service
 }
 
 func NewCloudMapServiceDiscovery(
-	namespacesNames []string,
+	targets []*CloudMapDiscoveryTarget,
 	parameterUriTag string,
 	parameterSourceOptions *parameter.SourceOptions,
-	cloudMapClient *servicediscovery.Client,
 	logger *zap.SugaredLogger,
-	domainRegistry *Route53DomainRegistry,
+	domainRegistry servicemesh.DomainRegistry,
 ) servicemesh.EnvoyDiscoveryService {
 
 	return &cloudMapServiceDiscovery{
-		namespacesNames,
-		parameterUriTag,
-		parameterSourceOptions,
-		cloudMapClient,
-		logger,
-		domainRegistry,
+		targets:                targets,
+		parameterUriTag:        parameterUriTag,
+		parameterSourceOptions: parameterSourceOptions,
+		logger:                 logger,
+		domainRegistry:         domainRegistry,
+		lastTargetServices:     make([][]*sdapi.Service, len(targets)),
 	}
 
 }
 
-func (c *cloudMapServiceDiscovery) getListNamespacesInputFilters() []servicediscoverytypes.NamespaceFilter {
+func (c *cloudMapServiceDiscovery) getListNamespacesInputFilters(target *CloudMapDiscoveryTarget) []servicediscoverytypes.NamespaceFilter {
 
 	c.logger.Debugw("Building NamespaceFilter to get namespaces IDs from namespaces names")
 
 	namesFilter := servicediscoverytypes.NamespaceFilter{
 		Name:      servicediscoverytypes.NamespaceFilterNameName,
-		Values:    c.namespacesNames,
+		Values:    target.NamespacesNames,
 		Condition: servicediscoverytypes.FilterConditionEq,
 	}
 
@@ -72,12 +117,12 @@ func (c *cloudMapServiceDiscovery) getListNamespacesInputFilters() []servicedisc
 
 }
 
-func (c *cloudMapServiceDiscovery) getListServicesInputFilters(ctx context.Context) ([]servicediscoverytypes.ServiceFilter, error) {
+func (c *cloudMapServiceDiscovery) getListServicesInputFilters(ctx context.Context, target *CloudMapDiscoveryTarget) ([]servicediscoverytypes.ServiceFilter, error) {
 
 	listNamespacesReq := servicediscovery.NewListNamespacesPaginator(
-		c.cloudMapClient,
+		target.CloudMapClient,
 		&servicediscovery.ListNamespacesInput{
-			Filters: c.getListNamespacesInputFilters(),
+			Filters: c.getListNamespacesInputFilters(target),
 		},
 	)
 
@@ -98,7 +143,7 @@ func (c *cloudMapServiceDiscovery) getListServicesInputFilters(ctx context.Conte
 
 			namespaceId := aws.ToString(namespace.Id)
 
-			c.logger.Infow("Found namespace from AWS Cloud Map", "namespace_id", namespaceId)
+			c.logger.Infow("Found namespace from AWS Cloud Map", "namespace_id", namespaceId, "region", target.Region)
 
 			namespaceIdsFilter.Values = append(namespaceIdsFilter.Values, namespaceId)
 
@@ -112,7 +157,7 @@ func (c *cloudMapServiceDiscovery) getListServicesInputFilters(ctx context.Conte
 
 func (c *cloudMapServiceDiscovery) getServiceParameters(op *cloudMapServiceDiscovery_operation) (*parameter.Source, error) {
 
-	listServiceTagsRes, err := c.cloudMapClient.ListTagsForResource(
+	listServiceTagsRes, err := op.target.CloudMapClient.ListTagsForResource(
 		op.ctx,
 		&servicediscovery.ListTagsForResourceInput{ResourceARN: op.serviceSummary.Arn},
 	)
@@ -156,7 +201,7 @@ func (c *cloudMapServiceDiscovery) getServiceParameters(op *cloudMapServiceDisco
 func (c *cloudMapServiceDiscovery) discoverEndpoints(op *cloudMapServiceDiscovery_operation) error {
 
 	listInstancesReq := servicediscovery.NewListInstancesPaginator(
-		c.cloudMapClient,
+		op.target.CloudMapClient,
 		&servicediscovery.ListInstancesInput{
 			ServiceId: op.serviceSummary.Id,
 		},
@@ -164,6 +209,14 @@ func (c *cloudMapServiceDiscovery) discoverEndpoints(op *cloudMapServiceDiscover
 
 	lbEndpoints := []*endpointv3.LbEndpoint{}
 
+	endpointMetadata, err := structpb.NewStruct(map[string]interface{}{
+		"region":  op.target.Region,
+		"account": op.target.AccountID,
+	})
+	if err != nil {
+		return err
+	}
+
 	for listInstancesReq.HasMorePages() {
 
 		listInstancesPage, err := listInstancesReq.NextPage(op.ctx)
@@ -197,6 +250,11 @@ func (c *cloudMapServiceDiscovery) discoverEndpoints(op *cloudMapServiceDiscover
 							},
 						},
 					},
+					Metadata: &corev3.Metadata{
+						FilterMetadata: map[string]*structpb.Struct{
+							CloudMapEndpointMetadataNamespace: endpointMetadata,
+						},
+					},
 				},
 			)
 
@@ -206,6 +264,9 @@ func (c *cloudMapServiceDiscovery) discoverEndpoints(op *cloudMapServiceDiscover
 
 	op.service.EnvoyClusterLoadAssignment = &endpointv3.ClusterLoadAssignment{
 		Endpoints: []*endpointv3.LocalityLbEndpoints{{
+			Locality: &corev3.Locality{
+				Region: op.target.Region,
+			},
 			LbEndpoints: lbEndpoints,
 		}},
 	}
@@ -274,67 +335,225 @@ func (c *cloudMapServiceDiscovery) discoverService(op *cloudMapServiceDiscovery_
 
 }
 
-func (c *cloudMapServiceDiscovery) Discover(ctx context.Context, svcCh chan *sdapi.Service) {
+// discoverTarget returns the services of the target and the IDs of the ones
+// which failed to be discovered
+func (c *cloudMapServiceDiscovery) discoverTarget(ctx context.Context, target *CloudMapDiscoveryTarget) ([]*sdapi.Service, []string, error) {
 
-	defer close(svcCh)
+	target.resolveAccountID(ctx, c.logger)
 
-	listServicesFilters, err := c.getListServicesInputFilters(ctx)
+	logger := c.logger.With("region", target.Region, "account", target.AccountID)
+
+	listServicesFilters, err := c.getListServicesInputFilters(ctx, target)
 	if err != nil {
-		c.logger.Error(err)
-		return
+		return nil, nil, err
 	}
 
 	listServicesReq := servicediscovery.NewListServicesPaginator(
-		c.cloudMapClient,
+		target.CloudMapClient,
 		&servicediscovery.ListServicesInput{
 			Filters: listServicesFilters,
 		},
 	)
 
+	services := []*sdapi.Service{}
+	failedServiceIds := []string{}
+
 	for listServicesReq.HasMorePages() {
 
 		listServicesPage, err := listServicesReq.NextPage(ctx)
 		if err != nil {
-			c.logger.Error(err)
-			return
-		}
-
-		op := &cloudMapServiceDiscovery_operation{
-			ctx: ctx,
+			return services, failedServiceIds, err
 		}
 
 		for _, serviceSummary := range listServicesPage.Services {
 
-			op.serviceSummary = serviceSummary
-			op.logger = c.logger.With("service_name", aws.ToString(serviceSummary.Name))
+			op := &cloudMapServiceDiscovery_operation{
+				ctx:            ctx,
+				target:         target,
+				serviceSummary: serviceSummary,
+				logger:         logger.With("service_name", aws.ToString(serviceSummary.Name)),
+			}
 
 			svc, err := c.discoverService(op)
 			if err != nil {
-				c.logger.Error(err)
+				op.logger.Error(err)
+				failedServiceIds = append(failedServiceIds, aws.ToString(serviceSummary.Id))
 				continue
 			}
 
 			if svc != nil {
-				op.logger.Info("Sending service through discovery channel")
-				svcCh <- svc
+				services = append(services, svc)
+			}
+
+		}
+
+	}
 
-				if c.domainRegistry != nil && svc.DnsRecords != nil {
+	return services, failedServiceIds, nil
 
-					op.logger.Info("Found dns records in this service")
+}
 
-					for _, record := range svc.DnsRecords {
-						err = c.domainRegistry.registerDnsRecord(op.ctx, record)
-						if err != nil {
-							c.logger.Error(err)
-						}
-					}
+// previousServices returns copies of the services of the last discovery with
+// the IDs
+func previousServices(services []*sdapi.Service, serviceIds []string) []*sdapi.Service {
 
-				}
+	ids := make(map[string]bool)
 
-			}
+	for _, serviceId := range serviceIds {
+		ids[serviceId] = true
+	}
+
+	previous := []*sdapi.Service{}
+
+	for _, svc := range services {
+		if ids[svc.ServiceId] {
+			previous = append(previous, svc)
+		}
+	}
+
+	return cloneServices(previous)
+
+}
+
+func cloneServices(services []*sdapi.Service) []*sdapi.Service {
+
+	clones := make([]*sdapi.Service, 0, len(services))
+
+	for _, svc := range services {
+		clones = append(clones, proto.Clone(svc).(*sdapi.Service))
+	}
+
+	return clones
+
+}
+
+// mergeServices merges services with the same name found in more than one
+// target, the endpoints of every region are appended to the first service
+func mergeServices(services []*sdapi.Service) []*sdapi.Service {
+
+	merged := []*sdapi.Service{}
+	byName := make(map[string]*sdapi.Service)
+
+	for _, svc := range services {
 
+		first, ok := byName[svc.ServiceName]
+		if !ok {
+			byName[svc.ServiceName] = svc
+			merged = append(merged, svc)
+			continue
 		}
 
+		if first.EnvoyClusterLoadAssignment == nil || svc.EnvoyClusterLoadAssignment == nil {
+			continue
+		}
+
+		first.EnvoyClusterLoadAssignment.Endpoints = append(
+			first.EnvoyClusterLoadAssignment.Endpoints,
+			svc.EnvoyClusterLoadAssignment.Endpoints...,
+		)
+
+	}
+
+	// Keep the snapshot hash stable regardless of the order targets finish
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].ServiceName < merged[j].ServiceName
+	})
+
+	for _, svc := range merged {
+
+		if svc.EnvoyClusterLoadAssignment == nil {
+			continue
+		}
+
+		endpoints := svc.EnvoyClusterLoadAssignment.Endpoints
+
+		sort.SliceStable(endpoints, func(i, j int) bool {
+			return endpoints[i].GetLocality().GetRegion() < endpoints[j].GetLocality().GetRegion()
+		})
+
+	}
+
+	return merged
+
+}
+
+func (c *cloudMapServiceDiscovery) Discover(ctx context.Context, svcCh chan *sdapi.Service) {
+
+	defer close(svcCh)
+
+	// Query every target in parallel
+
+	wg := sync.WaitGroup{}
+	mutex := sync.Mutex{}
+
+	targetServices := make([][]*sdapi.Service, len(c.targets))
+	complete := true
+
+	for i, target := range c.targets {
+
+		wg.Add(1)
+
+		go func(i int, target *CloudMapDiscoveryTarget) {
+
+			defer wg.Done()
+
+			services, failedServiceIds, err := c.discoverTarget(ctx, target)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			if err != nil {
+
+				c.logger.Errorw("Unable to discover Cloud Map target, keeping services of the last discovery", "region", target.Region, "account", target.AccountID, "error", err, "service_count", len(c.lastTargetServices[i]))
+
+				complete = false
+
+				targetServices[i] = cloneServices(c.lastTargetServices[i])
+
+				return
+
+			}
+
+			// A failed service may still exist, its records must not be
+			// collected and Envoy keeps its last known cluster
+			if len(failedServiceIds) > 0 {
+
+				c.logger.Warnw("Unable to discover some Cloud Map services, keeping them from the last discovery", "region", target.Region, "account", target.AccountID, "service_ids", failedServiceIds)
+
+				complete = false
+
+				services = append(services, previousServices(c.lastTargetServices[i], failedServiceIds)...)
+
+			}
+
+			// Mutators and merging change services in place, keep a copy
+			c.lastTargetServices[i] = cloneServices(services)
+
+			targetServices[i] = services
+
+		}(i, target)
+
+	}
+
+	wg.Wait()
+
+	services := []*sdapi.Service{}
+
+	for _, targetService := range targetServices {
+		services = append(services, targetService...)
+	}
+
+	services = mergeServices(services)
+
+	for _, svc := range services {
+
+		c.logger.Infow("Sending service through discovery channel", "service_name", svc.ServiceName)
+		svcCh <- svc
+
+	}
+
+	if c.domainRegistry != nil {
+		c.domainRegistry.SyncServices(ctx, services, complete)
 	}
 
 	c.logger.Info("End of cloud map service discovery")
@@ -0,0 +1,63 @@
+package awsdriver
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+	parameter "github.com/upper-institute/hike/pkg/parameter"
+	"go.uber.org/zap"
+)
+
+const KMSKeyProviderName = "kms"
+
+// kmsKeyProvider wraps data keys with a KMS key, the key reference is a key
+// ID, ARN or alias (e.g. alias/hike)
+type kmsKeyProvider struct {
+	kmsClient *kms.Client
+
+	logger *zap.SugaredLogger
+}
+
+func NewKMSKeyProvider(
+	kmsClient *kms.Client,
+	logger *zap.SugaredLogger,
+) parameter.KeyProvider {
+	return &kmsKeyProvider{
+		kmsClient: kmsClient,
+		logger:    logger.With("driver", "kms_key_provider"),
+	}
+}
+
+func (p *kmsKeyProvider) GenerateDataKey(ctx context.Context, keyId string) ([]byte, []byte, error) {
+
+	p.logger.Debugw("Generate data key", "key_id", keyId)
+
+	generateDataKeyOutput, err := p.kmsClient.GenerateDataKey(ctx, &kms.GenerateDataKeyInput{
+		KeyId:   aws.String(keyId),
+		KeySpec: types.DataKeySpecAes256,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return generateDataKeyOutput.Plaintext, generateDataKeyOutput.CiphertextBlob, nil
+
+}
+
+func (p *kmsKeyProvider) DecryptDataKey(ctx context.Context, keyId string, wrapped []byte) ([]byte, error) {
+
+	p.logger.Debugw("Decrypt data key", "key_id", keyId)
+
+	decryptOutput, err := p.kmsClient.Decrypt(ctx, &kms.DecryptInput{
+		KeyId:          aws.String(keyId),
+		CiphertextBlob: wrapped,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return decryptOutput.Plaintext, nil
+
+}
@@ -0,0 +1,190 @@
+package awsdriver
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/aws/aws-sdk-go-v2/service/route53/types"
+	"github.com/go-acme/lego/v4/challenge/dns01"
+)
+
+const (
+	DefaultDNS01PropagationTimeout = 5 * time.Minute
+	DefaultDNS01PollingInterval    = 10 * time.Second
+
+	acmeChallengeTTL = 60
+)
+
+// findHostedZone returns the hosted zone of the longest zone name matching
+// the fqdn, public zones are preferred over private ones with the same name
+func (id *Route53DomainRegistry) findHostedZone(ctx context.Context, fqdn string) (string, error) {
+
+	labels := strings.Split(fqdn, domainSeparator)
+
+	for i := range labels {
+
+		zoneName := strings.Join(labels[i:], domainSeparator)
+
+		listHostedZonesOutput, err := id.route53Client.ListHostedZonesByName(ctx, &route53.ListHostedZonesByNameInput{
+			DNSName: aws.String(zoneName),
+		})
+		if err != nil {
+			return "", err
+		}
+
+		hostedZoneId := ""
+
+		for _, hostedZone := range listHostedZonesOutput.HostedZones {
+
+			if strings.TrimRight(aws.ToString(hostedZone.Name), domainSeparator) != zoneName {
+				continue
+			}
+
+			if hostedZone.Config == nil || !hostedZone.Config.PrivateZone {
+				return aws.ToString(hostedZone.Id), nil
+			}
+
+			if len(hostedZoneId) == 0 {
+				hostedZoneId = aws.ToString(hostedZone.Id)
+			}
+
+		}
+
+		if len(hostedZoneId) > 0 {
+			return hostedZoneId, nil
+		}
+
+	}
+
+	return "", fmt.Errorf("Found 0 hosted zones (challenge fqdn: %s)", fqdn)
+
+}
+
+// changeChallenge adds or removes the value from the TXT record set, keeping
+// values of other challenges of the same name (e.g. wildcard and apex)
+func (id *Route53DomainRegistry) changeChallenge(ctx context.Context, fqdn string, value string, present bool) (*types.ChangeInfo, error) {
+
+	fqdn = dns01.UnFqdn(fqdn)
+
+	logger := id.logger.With("record_fqdn", fqdn, "present", present)
+
+	hostedZoneId, err := id.findHostedZone(ctx, fqdn)
+	if err != nil {
+		return nil, err
+	}
+
+	registration := &route53DomainRegistry_Registration{
+		ctx:          ctx,
+		logger:       logger,
+		hostedZoneId: hostedZoneId,
+		fqdn:         fqdn,
+		recordType:   types.RRTypeTxt,
+	}
+
+	recordSet, err := id.listRecords(registration)
+	if err != nil {
+		return nil, err
+	}
+
+	quotedValue := strconv.Quote(value)
+
+	resourceRecords := []types.ResourceRecord{}
+
+	for _, record := range recordSet {
+
+		if record.Type != types.RRTypeTxt {
+			continue
+		}
+
+		for _, rr := range record.ResourceRecords {
+			if aws.ToString(rr.Value) != quotedValue {
+				resourceRecords = append(resourceRecords, rr)
+			}
+		}
+
+	}
+
+	if present {
+		resourceRecords = append(resourceRecords, types.ResourceRecord{Value: aws.String(quotedValue)})
+	}
+
+	change := types.Change{
+		Action: types.ChangeActionUpsert,
+		ResourceRecordSet: &types.ResourceRecordSet{
+			Name:            aws.String(fqdn),
+			Type:            types.RRTypeTxt,
+			TTL:             aws.Int64(acmeChallengeTTL),
+			ResourceRecords: resourceRecords,
+		},
+	}
+
+	if len(resourceRecords) == 0 {
+
+		if len(recordSet) == 0 || recordSet[0].Type != types.RRTypeTxt {
+			return nil, nil
+		}
+
+		change.Action = types.ChangeActionDelete
+		change.ResourceRecordSet = recordSet[0]
+
+	}
+
+	logger.Infow("Changing ACME challenge record", "action", change.Action, "value_count", len(resourceRecords))
+
+	changeRecordOutput, err := id.changeRecord(registration, []types.Change{change})
+	if err != nil {
+		return nil, err
+	}
+
+	return changeRecordOutput.ChangeInfo, nil
+
+}
+
+// Present creates the _acme-challenge TXT record and waits for the change to
+// be INSYNC, the ACME client then checks the propagation on the
+// authoritative nameservers before telling the ACME server it's ready
+func (id *Route53DomainRegistry) Present(domain, token, keyAuth string) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), id.dns01PropagationTimeout)
+	defer cancel()
+
+	fqdn, value := dns01.GetRecord(domain, keyAuth)
+
+	changeInfo, err := id.changeChallenge(ctx, fqdn, value, true)
+	if err != nil {
+		return err
+	}
+
+	id.logger.Infow("Waiting for ACME challenge record change to be INSYNC", "record_fqdn", fqdn, "change_id", aws.ToString(changeInfo.Id))
+
+	waiter := route53.NewResourceRecordSetsChangedWaiter(id.route53Client, func(o *route53.ResourceRecordSetsChangedWaiterOptions) {
+		o.MinDelay = id.dns01PollingInterval
+	})
+
+	return waiter.Wait(ctx, &route53.GetChangeInput{Id: changeInfo.Id}, id.dns01PropagationTimeout)
+
+}
+
+// CleanUp removes the value of the challenge from the _acme-challenge record
+func (id *Route53DomainRegistry) CleanUp(domain, token, keyAuth string) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), id.dns01PropagationTimeout)
+	defer cancel()
+
+	fqdn, value := dns01.GetRecord(domain, keyAuth)
+
+	_, err := id.changeChallenge(ctx, fqdn, value, false)
+
+	return err
+
+}
+
+// Timeout of the propagation check and its polling interval
+func (id *Route53DomainRegistry) Timeout() (time.Duration, time.Duration) {
+	return id.dns01PropagationTimeout, id.dns01PollingInterval
+}
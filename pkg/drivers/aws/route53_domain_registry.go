@@ -2,13 +2,17 @@ package awsdriver
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/route53"
 	"github.com/aws/aws-sdk-go-v2/service/route53/types"
+	"github.com/upper-institute/hike/pkg/servicemesh"
 	sdapi "github.com/upper-institute/hike/proto/api/service-discovery"
 	"go.uber.org/zap"
 	"google.golang.org/protobuf/types/known/durationpb"
@@ -16,8 +20,14 @@ import (
 
 const domainSeparator = "."
 
+var (
+	MissingSetIdentifierErr = errors.New("DNS record with a routing policy requires a set identifier")
+	CnameAliasErr           = errors.New("DNS alias records must be A or AAAA")
+)
+
 type route53DomainRegistry_Registration struct {
 	ctx    context.Context
+	svc    *sdapi.Service
 	record *sdapi.DnsRecord
 
 	logger *zap.SugaredLogger
@@ -30,48 +40,106 @@ type route53DomainRegistry_Registration struct {
 type Route53DomainRegistry struct {
 	route53Client *route53.Client
 
+	// Timeout and polling interval of DNS-01 challenges, see Timeout
+	dns01PropagationTimeout time.Duration
+	dns01PollingInterval    time.Duration
+
+	ownership *Route53OwnershipOptions
+
+	// Garbage collection state: hosted zones which may hold owned records,
+	// seeded with every hosted zone on the first collection, records
+	// registered in the current sync and since when the owned records are
+	// no longer registered
+	gcMu         sync.Mutex
+	gcSeeded     bool
+	gcZones      map[string]bool
+	registered   map[recordKey]bool
+	missingSince map[recordKey]time.Time
+
+	// Services of the current sync, records owned by other services can be
+	// taken over when their service is no longer discovered
+	discovered map[string]bool
+	complete   bool
+
 	logger *zap.SugaredLogger
 }
 
 func NewRoute53DomainRegistry(
 	route53Client *route53.Client,
+	dns01PropagationTimeout time.Duration,
+	dns01PollingInterval time.Duration,
+	ownership *Route53OwnershipOptions,
 	logger *zap.SugaredLogger,
 ) *Route53DomainRegistry {
+
+	if len(ownership.OwnerID) == 0 {
+		ownership.OwnerID = DefaultRoute53OwnerID
+	}
+
 	return &Route53DomainRegistry{
-		route53Client: route53Client,
-		logger:        logger,
+		route53Client:           route53Client,
+		dns01PropagationTimeout: dns01PropagationTimeout,
+		dns01PollingInterval:    dns01PollingInterval,
+		ownership:               ownership,
+		gcZones:                 make(map[string]bool),
+		missingSince:            make(map[recordKey]time.Time),
+		logger:                  logger,
 	}
+
+}
+
+// recordName returns the record name as sent to Route53, which returns
+// names with a trailing dot and wildcards escaped
+func recordName(name string) string {
+	return strings.ReplaceAll(strings.TrimRight(name, domainSeparator), "\\052", "*")
 }
 
 func (id *Route53DomainRegistry) listRecords(registration *route53DomainRegistry_Registration) ([]*types.ResourceRecordSet, error) {
 
-	listResourceRecordSetsOutput, err := id.route53Client.ListResourceRecordSets(
-		registration.ctx,
-		&route53.ListResourceRecordSetsInput{
-			HostedZoneId:    aws.String(registration.hostedZoneId),
-			StartRecordName: aws.String(registration.fqdn),
-			StartRecordType: registration.recordType,
-		},
-	)
-	if err != nil {
-		return nil, err
+	var records []*types.ResourceRecordSet
+
+	input := &route53.ListResourceRecordSetsInput{
+		HostedZoneId:    aws.String(registration.hostedZoneId),
+		StartRecordName: aws.String(registration.fqdn),
+		StartRecordType: registration.recordType,
 	}
 
-	var records []*types.ResourceRecordSet
+	for {
+
+		listResourceRecordSetsOutput, err := id.route53Client.ListResourceRecordSets(registration.ctx, input)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range listResourceRecordSetsOutput.ResourceRecordSets {
 
-	for _, recordSet := range listResourceRecordSetsOutput.ResourceRecordSets {
+			recordSet := &listResourceRecordSetsOutput.ResourceRecordSets[i]
 
-		recordFqdn := strings.TrimRight(aws.ToString(recordSet.Name), domainSeparator)
+			recordFqdn := recordName(aws.ToString(recordSet.Name))
 
-		registration.logger.Debugw("Found record", "record_fqdn", recordFqdn, "match_fqdn", registration.fqdn)
+			registration.logger.Debugw("Found record", "record_fqdn", recordFqdn, "record_type", recordSet.Type, "match_fqdn", registration.fqdn)
+
+			// Record sets are sorted by name and type, the ones after the
+			// matching name and type are not needed
+			if recordFqdn != registration.fqdn || recordSet.Type != registration.recordType {
+				return records, nil
+			}
+
+			registration.logger.Debugw("Match existing record", "record_fqdn", recordFqdn, "set_identifier", aws.ToString(recordSet.SetIdentifier))
+
+			records = append(records, recordSet)
 
-		if recordFqdn == registration.fqdn {
-			registration.logger.Debugw("Match existing record", "record_fqdn", recordFqdn)
-			records = append(records, &recordSet)
 		}
-	}
 
-	return records, nil
+		if !listResourceRecordSetsOutput.IsTruncated {
+			return records, nil
+		}
+
+		input.StartRecordName = listResourceRecordSetsOutput.NextRecordName
+		input.StartRecordType = listResourceRecordSetsOutput.NextRecordType
+		input.StartRecordIdentifier = listResourceRecordSetsOutput.NextRecordIdentifier
+
+	}
 
 }
 
@@ -89,73 +157,303 @@ func (id *Route53DomainRegistry) changeRecord(registration *route53DomainRegistr
 
 }
 
-func (id *Route53DomainRegistry) registerCname(registration *route53DomainRegistry_Registration) error {
+func dnsRecordType(record *sdapi.DnsRecord) types.RRType {
+
+	switch record.Type {
+
+	case sdapi.DnsRecordType_DRT_CNAME:
+		return types.RRTypeCname
+
+	case sdapi.DnsRecordType_DRT_A:
+		return types.RRTypeA
+
+	case sdapi.DnsRecordType_DRT_AAAA:
+		return types.RRTypeAaaa
+
+	}
+
+	if len(record.CnameValue) > 0 && record.AliasTarget == nil {
+		return types.RRTypeCname
+	}
+
+	return types.RRTypeA
+
+}
+
+// desiredRecordSet builds the record set of the registration, nil when the
+// record has no value (e.g. a service without endpoints)
+func (id *Route53DomainRegistry) desiredRecordSet(registration *route53DomainRegistry_Registration) (*types.ResourceRecordSet, error) {
 
-	registration.recordType = types.RRTypeCname
+	record := registration.record
 
-	recordSet, err := id.listRecords(registration)
+	recordSet := &types.ResourceRecordSet{
+		Name: aws.String(registration.fqdn),
+		Type: registration.recordType,
+	}
+
+	if len(record.SetIdentifier) > 0 {
+		recordSet.SetIdentifier = aws.String(record.SetIdentifier)
+	}
+
+	if len(record.HealthCheckId) > 0 {
+		recordSet.HealthCheckId = aws.String(record.HealthCheckId)
+	}
+
+	switch policy := record.RoutingPolicy.(type) {
+
+	case *sdapi.DnsRecord_Weighted:
+		recordSet.Weight = aws.Int64(int64(policy.Weighted.Weight))
+
+	case *sdapi.DnsRecord_Latency:
+		recordSet.Region = types.ResourceRecordSetRegion(policy.Latency.Region)
+
+	case *sdapi.DnsRecord_Failover:
+
+		recordSet.Failover = types.ResourceRecordSetFailoverPrimary
+
+		if policy.Failover.Role == sdapi.DnsFailoverRole_DFR_SECONDARY {
+			recordSet.Failover = types.ResourceRecordSetFailoverSecondary
+		}
+
+	}
+
+	if record.RoutingPolicy != nil && recordSet.SetIdentifier == nil {
+		return nil, MissingSetIdentifierErr
+	}
+
+	if record.AliasTarget != nil {
+
+		if registration.recordType == types.RRTypeCname {
+			return nil, CnameAliasErr
+		}
+
+		recordSet.AliasTarget = &types.AliasTarget{
+			DNSName:              aws.String(record.AliasTarget.DnsName),
+			HostedZoneId:         aws.String(record.AliasTarget.HostedZoneId),
+			EvaluateTargetHealth: record.AliasTarget.EvaluateTargetHealth,
+		}
+
+		return recordSet, nil
+
+	}
+
+	values := record.Values
+
+	switch {
+
+	case registration.recordType == types.RRTypeCname:
+		values = []string{record.CnameValue}
+
+	case len(values) == 0:
+		values = servicemesh.EndpointAddresses(registration.svc, registration.recordType == types.RRTypeAaaa)
+
+	}
+
+	if len(values) == 0 || len(values[0]) == 0 {
+		return nil, nil
+	}
+
+	recordSet.TTL = aws.Int64(int64(record.Ttl.AsDuration().Seconds()))
+
+	for _, value := range values {
+		recordSet.ResourceRecords = append(recordSet.ResourceRecords, types.ResourceRecord{
+			Value: aws.String(value),
+		})
+	}
+
+	return recordSet, nil
+
+}
+
+func recordValues(recordSet *types.ResourceRecordSet) []string {
+
+	values := []string{}
+
+	for _, rr := range recordSet.ResourceRecords {
+		values = append(values, aws.ToString(rr.Value))
+	}
+
+	sort.Strings(values)
+
+	return values
+
+}
+
+func aliasTargetEqual(a *types.AliasTarget, b *types.AliasTarget) bool {
+
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return strings.EqualFold(recordName(aws.ToString(a.DNSName)), recordName(aws.ToString(b.DNSName))) &&
+		aws.ToString(a.HostedZoneId) == aws.ToString(b.HostedZoneId) &&
+		a.EvaluateTargetHealth == b.EvaluateTargetHealth
+
+}
+
+func recordSetEqual(a *types.ResourceRecordSet, b *types.ResourceRecordSet) bool {
+
+	return aws.ToInt64(a.TTL) == aws.ToInt64(b.TTL) &&
+		strings.Join(recordValues(a), ",") == strings.Join(recordValues(b), ",") &&
+		aliasTargetEqual(a.AliasTarget, b.AliasTarget) &&
+		aws.ToString(a.SetIdentifier) == aws.ToString(b.SetIdentifier) &&
+		aws.ToInt64(a.Weight) == aws.ToInt64(b.Weight) &&
+		a.Region == b.Region &&
+		a.Failover == b.Failover &&
+		aws.ToString(a.HealthCheckId) == aws.ToString(b.HealthCheckId)
+
+}
+
+// registerRecordSet upserts the record set with the same set identifier,
+// deleting the ones which can't coexist with it: a simple record set can't
+// share its name and type with routing policy ones. Only record sets owned
+// by the service are changed, the ownership record is updated in the same
+// batch.
+func (id *Route53DomainRegistry) registerRecordSet(registration *route53DomainRegistry_Registration, desired *types.ResourceRecordSet) error {
+
+	recordSets, err := id.listRecords(registration)
 	if err != nil {
 		return err
 	}
 
-	resourceRecords := []types.ResourceRecord{{
-		Value: aws.String(registration.record.CnameValue),
-	}}
+	ownershipSet, ownerships, err := id.listOwnerships(registration)
+	if err != nil {
+		return err
+	}
 
-	if len(recordSet) == 0 {
+	serviceName := registration.svc.ServiceName
+	desiredSetIdentifier := aws.ToString(desired.SetIdentifier)
 
-		registration.logger.Infow("Inserting record (action create)", "record_fqdn", registration.fqdn)
+	owners := make(map[string]*ownership)
 
-		_, err = id.changeRecord(registration, []types.Change{{
-			Action: types.ChangeActionCreate,
-			ResourceRecordSet: &types.ResourceRecordSet{
-				Name:            aws.String(registration.fqdn),
-				Type:            types.RRTypeCname,
-				TTL:             aws.Int64(int64(registration.record.Ttl.AsDuration().Seconds())),
-				ResourceRecords: resourceRecords,
-			},
-		}})
+	for _, o := range ownerships {
+		owners[o.setIdentifier] = o
+	}
+
+	// checkOwner fails when the record set can't be changed, returns whether
+	// it's adopted
+	checkOwner := func(setIdentifier string) (bool, error) {
+
+		o, ok := owners[setIdentifier]
+
+		switch {
+
+		case ok && id.owns(o, serviceName):
+			return false, nil
+
+		case ok && id.canTakeOver(o):
+			registration.logger.Infow("Taking over record of a service no longer discovered", "record_fqdn", registration.fqdn, "existing_set_identifier", setIdentifier, "owner_service", o.service)
+			return true, nil
+
+		case !ok && id.ownership.AdoptRecords:
+			registration.logger.Infow("Adopting record without owner", "record_fqdn", registration.fqdn, "existing_set_identifier", setIdentifier)
+			return true, nil
+
+		case ok:
+			registration.logger.Warnw("Record owned by other hike instance or service, skipping", "record_fqdn", registration.fqdn, "existing_set_identifier", setIdentifier, "owner_id", o.owner, "owner_service", o.service)
 
-		if err != nil {
-			return err
 		}
+
+		return false, RecordNotOwnedErr
+
 	}
 
-	for _, record := range recordSet {
+	changes := []types.Change{}
+	upToDate := false
 
-		for _, rr := range record.ResourceRecords {
+	for _, recordSet := range recordSets {
 
-			if aws.ToString(rr.Value) == registration.record.CnameValue {
+		setIdentifier := aws.ToString(recordSet.SetIdentifier)
 
-				registration.logger.Debugw("No need to update cname value, skipping action", "record_fqdn", aws.ToString(record.Name))
+		if setIdentifier == desiredSetIdentifier {
 
-				return nil
+			adopted, err := checkOwner(setIdentifier)
+			if err != nil {
+				return err
 			}
+
+			upToDate = !adopted && recordSetEqual(recordSet, desired)
+
+			continue
+
 		}
 
-		registration.logger.Infow("Changing record (action upsert)", "record_fqdn", aws.ToString(record.Name))
+		if len(setIdentifier) == 0 || desired.SetIdentifier == nil {
 
-		record.ResourceRecords = resourceRecords
+			if _, err := checkOwner(setIdentifier); err != nil {
+				return err
+			}
 
-		_, err = id.changeRecord(registration, []types.Change{{
-			Action:            types.ChangeActionUpsert,
-			ResourceRecordSet: record,
-		}})
+			registration.logger.Infow("Deleting conflicting record (action delete)", "record_fqdn", registration.fqdn, "existing_set_identifier", setIdentifier)
+
+			changes = append(changes, types.Change{
+				Action:            types.ChangeActionDelete,
+				ResourceRecordSet: recordSet,
+			})
+
+			delete(owners, setIdentifier)
 
-		if err != nil {
-			break
 		}
 
 	}
 
+	if upToDate && len(changes) == 0 {
+
+		registration.logger.Debugw("No need to update record, skipping action", "record_fqdn", registration.fqdn)
+
+		return nil
+
+	}
+
+	registration.logger.Infow("Changing record (action upsert)", "record_fqdn", registration.fqdn)
+
+	changes = append(changes, types.Change{
+		Action:            types.ChangeActionUpsert,
+		ResourceRecordSet: desired,
+	})
+
+	owners[desiredSetIdentifier] = &ownership{
+		owner:         id.ownership.OwnerID,
+		service:       serviceName,
+		setIdentifier: desiredSetIdentifier,
+	}
+
+	ownerships = []*ownership{}
+
+	for _, o := range owners {
+		ownerships = append(ownerships, o)
+	}
+
+	if change := ownershipChange(registration.fqdn, registration.recordType, ownershipSet, ownerships); change != nil {
+		changes = append(changes, *change)
+	}
+
+	_, err = id.changeRecord(registration, changes)
+
 	return err
 
 }
 
-func (id *Route53DomainRegistry) registerDnsRecord(ctx context.Context, record *sdapi.DnsRecord) error {
+func (id *Route53DomainRegistry) registerDnsRecord(ctx context.Context, svc *sdapi.Service, record *sdapi.DnsRecord) error {
 
 	logger := id.logger.With("zone", record.Zone, "record_name", record.RecordName)
 
+	fqdn := strings.Join(
+		[]string{
+			strings.TrimRight(record.RecordName, domainSeparator),
+			strings.TrimLeft(record.Zone, domainSeparator),
+		},
+		domainSeparator,
+	)
+
+	recordType := dnsRecordType(record)
+
+	// Registered even when it fails below, records are only collected when
+	// their service is gone
+	if id.registered != nil {
+		id.registered[newRecordKey(fqdn, recordType, record.SetIdentifier)] = true
+	}
+
 	listHostedZonesOutput, err := id.route53Client.ListHostedZonesByName(ctx, &route53.ListHostedZonesByNameInput{
 		DNSName:  aws.String(record.Zone),
 		MaxItems: aws.Int32(1),
@@ -175,27 +473,77 @@ func (id *Route53DomainRegistry) registerDnsRecord(ctx context.Context, record *
 	registration := &route53DomainRegistry_Registration{
 		ctx:          ctx,
 		hostedZoneId: aws.ToString(listHostedZonesOutput.HostedZones[0].Id),
+		svc:          svc,
 		record:       record,
 		logger:       logger,
-		fqdn: strings.Join(
-			[]string{
-				strings.TrimRight(record.RecordName, domainSeparator),
-				strings.TrimLeft(record.Zone, domainSeparator),
-			},
-			domainSeparator,
-		),
+		fqdn:         fqdn,
+		recordType:   recordType,
+	}
+
+	logger = logger.With("record_type", registration.recordType, "set_identifier", record.SetIdentifier)
+	registration.logger = logger
+
+	id.gcZones[registration.hostedZoneId] = true
+
+	desired, err := id.desiredRecordSet(registration)
+	if err != nil {
+		return err
+	}
+
+	if desired == nil {
+		logger.Warnw("No value for the record, skipping")
+		return nil
 	}
 
-	if len(record.CnameValue) > 0 {
+	return id.registerRecordSet(registration, desired)
+
+}
 
-		logger.Infow("Ingress domain is a CNAME value")
+// SyncServices registers the DNS records of the services and, when the
+// services are all the discovered ones, collects the owned records of the
+// services which are gone
+func (id *Route53DomainRegistry) SyncServices(ctx context.Context, services []*sdapi.Service, complete bool) {
+
+	id.gcMu.Lock()
+	defer id.gcMu.Unlock()
+
+	id.registered = make(map[recordKey]bool)
+	id.discovered = make(map[string]bool)
+	id.complete = complete
+
+	defer func() {
+		id.registered = nil
+		id.discovered = nil
+	}()
+
+	for _, svc := range services {
+		id.discovered[svc.ServiceName] = true
+	}
+
+	for _, svc := range services {
+
+		for _, record := range svc.DnsRecords {
+
+			err := id.registerDnsRecord(ctx, svc, record)
+			if err != nil {
+				id.logger.Errorw("Unable to register DNS record", "service_name", svc.ServiceName, "record_name", record.RecordName, "zone", record.Zone, "error", err)
+			}
 
-		if err := id.registerCname(registration); err != nil {
-			return err
 		}
+
 	}
 
-	return nil
+	if !id.ownership.GCEnable {
+		return
+	}
+
+	if !complete {
+		id.logger.Warnw("Discovery is incomplete, skipping DNS records garbage collection")
+		return
+	}
+
+	id.collectGarbage(ctx, id.registered)
+
 }
 
 func (r *Route53DomainRegistry) Discover(ctx context.Context, svcCh chan *sdapi.Service) {
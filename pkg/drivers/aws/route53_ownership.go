@@ -0,0 +1,456 @@
+package awsdriver
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/aws/aws-sdk-go-v2/service/route53/types"
+)
+
+const (
+	DefaultRoute53OwnerID       = "hike"
+	DefaultRoute53GCGracePeriod = time.Hour
+
+	ownershipNamePrefix = "_hike-"
+	ownershipWildcard   = "_wildcard"
+	ownershipHeritage   = "hike"
+	ownershipTTL        = 300
+)
+
+var RecordNotOwnedErr = errors.New("DNS record exists and is not owned by this hike instance and service")
+
+type Route53OwnershipOptions struct {
+	// Identifies the hike instance in the ownership records
+	OwnerID string
+	// Claims the existing records without ownership, e.g. created by hike
+	// versions without ownership records
+	AdoptRecords bool
+	// Deletes the owned records of services no longer discovered
+	GCEnable      bool
+	GCGracePeriod time.Duration
+	// Only reports the records which would be deleted
+	GCDryRun bool
+}
+
+// ownership is a value of the ownership TXT record of a record name and
+// type, one per owned record set, owned by a hike instance and service name
+type ownership struct {
+	owner         string
+	service       string
+	setIdentifier string
+}
+
+type recordKey struct {
+	fqdn          string
+	recordType    types.RRType
+	setIdentifier string
+}
+
+func newRecordKey(fqdn string, recordType types.RRType, setIdentifier string) recordKey {
+	return recordKey{strings.ToLower(fqdn), recordType, setIdentifier}
+}
+
+func (o *ownership) value() string {
+
+	return strconv.Quote(strings.Join([]string{
+		"heritage=" + ownershipHeritage,
+		"owner=" + o.owner,
+		"service=" + o.service,
+		"set-identifier=" + o.setIdentifier,
+	}, ","))
+
+}
+
+func parseOwnership(value string) (*ownership, bool) {
+
+	unquoted, err := strconv.Unquote(value)
+	if err != nil {
+		return nil, false
+	}
+
+	fields := make(map[string]string)
+
+	for _, field := range strings.Split(unquoted, ",") {
+
+		kv := strings.SplitN(field, "=", 2)
+
+		if len(kv) == 2 {
+			fields[kv[0]] = kv[1]
+		}
+
+	}
+
+	if fields["heritage"] != ownershipHeritage {
+		return nil, false
+	}
+
+	return &ownership{
+		owner:         fields["owner"],
+		service:       fields["service"],
+		setIdentifier: fields["set-identifier"],
+	}, true
+
+}
+
+// ownershipName is the TXT record name holding the owners of the record
+// sets of the name and type, e.g. _hike-a.api.example.com, a CNAME can't
+// share its name with other records
+func ownershipName(fqdn string, recordType types.RRType) string {
+
+	if strings.HasPrefix(fqdn, "*.") {
+		fqdn = ownershipWildcard + fqdn[1:]
+	}
+
+	return ownershipNamePrefix + strings.ToLower(string(recordType)) + domainSeparator + fqdn
+
+}
+
+func parseOwnershipName(name string) (string, types.RRType, bool) {
+
+	if !strings.HasPrefix(name, ownershipNamePrefix) {
+		return "", "", false
+	}
+
+	labels := strings.SplitN(strings.TrimPrefix(name, ownershipNamePrefix), domainSeparator, 2)
+
+	if len(labels) != 2 {
+		return "", "", false
+	}
+
+	fqdn := labels[1]
+
+	if strings.HasPrefix(fqdn, ownershipWildcard+domainSeparator) {
+		fqdn = "*" + strings.TrimPrefix(fqdn, ownershipWildcard)
+	}
+
+	return fqdn, types.RRType(strings.ToUpper(labels[0])), true
+
+}
+
+func (id *Route53DomainRegistry) owns(o *ownership, service string) bool {
+	return o.owner == id.ownership.OwnerID && o.service == service
+}
+
+// canTakeOver allows a service to take over a record of this instance when
+// the owner service is no longer discovered, e.g. renamed services or
+// records owned by Cloud Map service IDs
+func (id *Route53DomainRegistry) canTakeOver(o *ownership) bool {
+	return o.owner == id.ownership.OwnerID && id.complete && !id.discovered[o.service]
+}
+
+// listOwnerships returns the ownership TXT record set of the registration
+// name and type, nil when not found, and its values
+func (id *Route53DomainRegistry) listOwnerships(registration *route53DomainRegistry_Registration) (*types.ResourceRecordSet, []*ownership, error) {
+
+	ownershipRegistration := *registration
+
+	ownershipRegistration.fqdn = ownershipName(registration.fqdn, registration.recordType)
+	ownershipRegistration.recordType = types.RRTypeTxt
+
+	recordSets, err := id.listRecords(&ownershipRegistration)
+	if err != nil || len(recordSets) == 0 {
+		return nil, nil, err
+	}
+
+	ownerships := []*ownership{}
+
+	for _, rr := range recordSets[0].ResourceRecords {
+		if o, ok := parseOwnership(aws.ToString(rr.Value)); ok {
+			ownerships = append(ownerships, o)
+		}
+	}
+
+	return recordSets[0], ownerships, nil
+
+}
+
+// ownershipChange replaces the values of the ownership record set, deleting
+// it when there is no value left
+func ownershipChange(fqdn string, recordType types.RRType, current *types.ResourceRecordSet, ownerships []*ownership) *types.Change {
+
+	values := []string{}
+
+	for _, o := range ownerships {
+		values = append(values, o.value())
+	}
+
+	sort.Strings(values)
+
+	if current != nil && strings.Join(recordValues(current), ",") == strings.Join(values, ",") {
+		return nil
+	}
+
+	if len(values) == 0 {
+
+		if current == nil {
+			return nil
+		}
+
+		return &types.Change{
+			Action:            types.ChangeActionDelete,
+			ResourceRecordSet: current,
+		}
+
+	}
+
+	recordSet := &types.ResourceRecordSet{
+		Name: aws.String(ownershipName(fqdn, recordType)),
+		Type: types.RRTypeTxt,
+		TTL:  aws.Int64(ownershipTTL),
+	}
+
+	for _, value := range values {
+		recordSet.ResourceRecords = append(recordSet.ResourceRecords, types.ResourceRecord{
+			Value: aws.String(value),
+		})
+	}
+
+	return &types.Change{
+		Action:            types.ChangeActionUpsert,
+		ResourceRecordSet: recordSet,
+	}
+
+}
+
+func (id *Route53DomainRegistry) listZoneOwnerships(ctx context.Context, hostedZoneId string) (map[recordKey]*ownership, error) {
+
+	ownerships := make(map[recordKey]*ownership)
+
+	input := &route53.ListResourceRecordSetsInput{
+		HostedZoneId: aws.String(hostedZoneId),
+	}
+
+	for {
+
+		listResourceRecordSetsOutput, err := id.route53Client.ListResourceRecordSets(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, recordSet := range listResourceRecordSetsOutput.ResourceRecordSets {
+
+			if recordSet.Type != types.RRTypeTxt {
+				continue
+			}
+
+			fqdn, recordType, ok := parseOwnershipName(recordName(aws.ToString(recordSet.Name)))
+			if !ok {
+				continue
+			}
+
+			for _, rr := range recordSet.ResourceRecords {
+
+				o, ok := parseOwnership(aws.ToString(rr.Value))
+
+				if ok && o.owner == id.ownership.OwnerID {
+					ownerships[newRecordKey(fqdn, recordType, o.setIdentifier)] = o
+				}
+
+			}
+
+		}
+
+		if !listResourceRecordSetsOutput.IsTruncated {
+			return ownerships, nil
+		}
+
+		input.StartRecordName = listResourceRecordSetsOutput.NextRecordName
+		input.StartRecordType = listResourceRecordSetsOutput.NextRecordType
+		input.StartRecordIdentifier = listResourceRecordSetsOutput.NextRecordIdentifier
+
+	}
+
+}
+
+// deleteOwnedRecord deletes the record set and its ownership value
+func (id *Route53DomainRegistry) deleteOwnedRecord(registration *route53DomainRegistry_Registration, setIdentifier string) error {
+
+	recordSets, err := id.listRecords(registration)
+	if err != nil {
+		return err
+	}
+
+	ownershipSet, ownerships, err := id.listOwnerships(registration)
+	if err != nil {
+		return err
+	}
+
+	changes := []types.Change{}
+
+	for _, recordSet := range recordSets {
+		if aws.ToString(recordSet.SetIdentifier) == setIdentifier {
+			changes = append(changes, types.Change{
+				Action:            types.ChangeActionDelete,
+				ResourceRecordSet: recordSet,
+			})
+		}
+	}
+
+	kept := []*ownership{}
+
+	for _, o := range ownerships {
+		if o.owner != id.ownership.OwnerID || o.setIdentifier != setIdentifier {
+			kept = append(kept, o)
+		}
+	}
+
+	if change := ownershipChange(registration.fqdn, registration.recordType, ownershipSet, kept); change != nil {
+		changes = append(changes, *change)
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+
+	_, err = id.changeRecord(registration, changes)
+
+	return err
+
+}
+
+// seedGCZones adds every hosted zone to the garbage collection, records
+// owned before a restart may be in zones no longer registered
+func (id *Route53DomainRegistry) seedGCZones(ctx context.Context) error {
+
+	listHostedZonesReq := route53.NewListHostedZonesPaginator(id.route53Client, &route53.ListHostedZonesInput{})
+
+	hostedZoneIds := []string{}
+
+	for listHostedZonesReq.HasMorePages() {
+
+		listHostedZonesPage, err := listHostedZonesReq.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, hostedZone := range listHostedZonesPage.HostedZones {
+			hostedZoneIds = append(hostedZoneIds, aws.ToString(hostedZone.Id))
+		}
+
+	}
+
+	for _, hostedZoneId := range hostedZoneIds {
+		id.gcZones[hostedZoneId] = true
+	}
+
+	id.gcSeeded = true
+
+	return nil
+
+}
+
+// collectGarbage deletes the records owned by this instance in the zones
+// which are no longer registered by any service, once missing for longer
+// than the grace period
+func (id *Route53DomainRegistry) collectGarbage(ctx context.Context, registered map[recordKey]bool) {
+
+	if !id.gcSeeded {
+
+		err := id.seedGCZones(ctx)
+		if err != nil {
+			id.logger.Errorw("Unable to list hosted zones, collecting garbage only in the zones of registered records", "error", err)
+		}
+
+	}
+
+	now := time.Now()
+
+	pending := 0
+	deleted := 0
+
+	for hostedZoneId := range id.gcZones {
+
+		logger := id.logger.With("hosted_zone_id", hostedZoneId)
+
+		ownerships, err := id.listZoneOwnerships(ctx, hostedZoneId)
+		if err != nil {
+			logger.Errorw("Unable to list DNS records ownership, skipping garbage collection", "error", err)
+			continue
+		}
+
+		// Zones of registered records are added again by the next sync
+		if len(ownerships) == 0 {
+			delete(id.gcZones, hostedZoneId)
+			continue
+		}
+
+		for key, o := range ownerships {
+
+			if registered[key] {
+				delete(id.missingSince, key)
+				continue
+			}
+
+			missingSince, ok := id.missingSince[key]
+
+			if !ok {
+				missingSince = now
+				id.missingSince[key] = now
+			}
+
+			recordLogger := logger.With(
+				"record_fqdn", key.fqdn,
+				"record_type", key.recordType,
+				"set_identifier", key.setIdentifier,
+				"service_name", o.service,
+				"missing_since", missingSince,
+			)
+
+			if now.Sub(missingSince) < id.ownership.GCGracePeriod {
+
+				recordLogger.Infow("Owned DNS record no longer registered, deleting after grace period", "delete_at", missingSince.Add(id.ownership.GCGracePeriod))
+
+				pending++
+
+				continue
+
+			}
+
+			if id.ownership.GCDryRun {
+
+				recordLogger.Infow("Owned DNS record would be deleted (dry run)")
+
+				pending++
+
+				continue
+
+			}
+
+			recordLogger.Infow("Deleting owned DNS record no longer registered")
+
+			err := id.deleteOwnedRecord(&route53DomainRegistry_Registration{
+				ctx:          ctx,
+				logger:       recordLogger,
+				hostedZoneId: hostedZoneId,
+				fqdn:         key.fqdn,
+				recordType:   key.recordType,
+			}, key.setIdentifier)
+
+			if err != nil {
+				recordLogger.Errorw("Unable to delete owned DNS record", "error", err)
+				continue
+			}
+
+			delete(id.missingSince, key)
+
+			deleted++
+
+		}
+
+	}
+
+	id.logger.Infow(
+		"DNS records garbage collection report",
+		"pending_count", pending,
+		"deleted_count", deleted,
+		"grace_period", id.ownership.GCGracePeriod,
+		"dry_run", id.ownership.GCDryRun,
+	)
+
+}
@@ -1,17 +1,88 @@
 package awsdriver
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
+	"errors"
+	"net/url"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
-	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
 	parameter "github.com/upper-institute/hike/pkg/parameter"
 	"go.uber.org/zap"
 )
 
+const (
+	// Object metadata with the wrapped data key of encrypted files
+	S3DataKeyMetadata = "hike-data-key"
+	// Object metadata with the key provider name, informative only
+	S3EncryptionMetadata = "hike-encryption"
+	// Object metadata with the size of compressed files before the compression
+	S3OriginalSizeMetadata = "hike-original-size"
+)
+
+// Upload options read from the parameter URL query, or from the parameter
+// metadata when missing in the query, e.g.
+// file://bucket/ca.pem?sse=aws:kms&sse_kms_key_id=alias/s3&tags=team=ops,env=prod#/etc/ca.pem
+// Values found on download are set in the parameter metadata
+const (
+	// AES256 (SSE-S3) or aws:kms (SSE-KMS)
+	S3ServerSideEncryptionQuery = "sse"
+	S3SSEKMSKeyIdQuery          = "sse_kms_key_id"
+	S3StorageClassQuery         = "storage_class"
+	// Comma separated key=value pairs
+	S3TagsQuery         = "tags"
+	S3ContentTypeQuery  = "content_type"
+	S3CacheControlQuery = "cache_control"
+)
+
+func s3UploadOption(param *parameter.Parameter, name string) string {
+
+	value := param.GetQuery().Get(name)
+
+	if len(value) > 0 {
+		return value
+	}
+
+	return param.Metadata.Get(name)
+
+}
+
+// s3Tagging converts comma separated key=value pairs to the URL encoded
+// form expected by S3
+func s3Tagging(tags string) string {
+
+	values := make(url.Values)
+
+	for _, tag := range strings.Split(tags, ",") {
+
+		if len(tag) == 0 {
+			continue
+		}
+
+		key, value, _ := strings.Cut(tag, "=")
+
+		values.Add(strings.TrimSpace(key), strings.TrimSpace(value))
+
+	}
+
+	return values.Encode()
+
+}
+
+func setMetadata(param *parameter.Parameter, name string, value string) {
+
+	if len(value) > 0 {
+		param.Metadata.Set(name, value)
+	}
+
+}
+
 type s3ParameterFile struct {
 	s3Client     *s3.Client
 	s3Downloader *manager.Downloader
@@ -23,7 +94,7 @@ type s3ParameterFile struct {
 func NewS3ParameterStorage(
 	s3Client *s3.Client,
 	logger *zap.SugaredLogger,
-) parameter.Storage {
+) parameter.DirStorage {
 	return &s3ParameterFile{
 		s3Client:     s3Client,
 		s3Downloader: manager.NewDownloader(s3Client),
@@ -52,8 +123,10 @@ func (s *s3ParameterFile) Download(ctx context.Context, param *parameter.Paramet
 	headObjectOutput, err := s.s3Client.HeadObject(ctx, headObjectInput)
 	if err != nil {
 
-		if aerr, ok := err.(awserr.Error); ok {
-			switch aerr.Code() {
+		var apiErr smithy.APIError
+
+		if errors.As(err, &apiErr) {
+			switch apiErr.ErrorCode() {
 			case "NotFound":
 				return parameter.FileNotFoundErr
 			}
@@ -63,6 +136,33 @@ func (s *s3ParameterFile) Download(ctx context.Context, param *parameter.Paramet
 		return err
 	}
 
+	setMetadata(param, S3ServerSideEncryptionQuery, string(headObjectOutput.ServerSideEncryption))
+	setMetadata(param, S3SSEKMSKeyIdQuery, aws.ToString(headObjectOutput.SSEKMSKeyId))
+	setMetadata(param, S3StorageClassQuery, string(headObjectOutput.StorageClass))
+	setMetadata(param, S3ContentTypeQuery, aws.ToString(headObjectOutput.ContentType))
+	setMetadata(param, S3CacheControlQuery, aws.ToString(headObjectOutput.CacheControl))
+	setMetadata(param, parameter.OriginalSizeMetadata, headObjectOutput.Metadata[S3OriginalSizeMetadata])
+
+	getObjectTaggingOutput, err := s.s3Client.GetObjectTagging(ctx, &s3.GetObjectTaggingInput{
+		Bucket: headObjectInput.Bucket,
+		Key:    headObjectInput.Key,
+	})
+
+	// Tags are informative, the download doesn't depend on the permission
+	if err != nil {
+		log.Debugw("Unable to get object tags", "error", err)
+	} else if len(getObjectTaggingOutput.TagSet) > 0 {
+
+		tags := []string{}
+
+		for _, tag := range getObjectTaggingOutput.TagSet {
+			tags = append(tags, aws.ToString(tag.Key)+"="+aws.ToString(tag.Value))
+		}
+
+		param.Metadata.Set(S3TagsQuery, strings.Join(tags, ","))
+
+	}
+
 	buf := make([]byte, int(headObjectOutput.ContentLength))
 
 	w := manager.NewWriteAtBuffer(buf)
@@ -79,6 +179,32 @@ func (s *s3ParameterFile) Download(ctx context.Context, param *parameter.Paramet
 		return err
 	}
 
+	keyProvider, keyId, err := param.GetKeyProvider()
+	if err != nil {
+		return err
+	}
+
+	if keyProvider != nil {
+
+		wrappedKeyStr, ok := headObjectOutput.Metadata[S3DataKeyMetadata]
+		if !ok {
+			return parameter.MissingDataKeyErr
+		}
+
+		wrappedKey, err := base64.StdEncoding.DecodeString(wrappedKeyStr)
+		if err != nil {
+			return err
+		}
+
+		log.Debugw("Decrypting file", "key_id", keyId)
+
+		buf, err = parameter.OpenEnvelope(ctx, keyProvider, keyId, wrappedKey, buf)
+		if err != nil {
+			return err
+		}
+
+	}
+
 	writtenBytes, err := param.GetFile().Write(buf)
 
 	log.Debugw("Downloaded file", "downloaded_size", writtenBytes)
@@ -99,14 +225,74 @@ func (s *s3ParameterFile) Upload(ctx context.Context, param *parameter.Parameter
 	log.Infow("Upload parameter file to S3")
 
 	input := &s3.PutObjectInput{
-		Bucket: aws.String(param.GetHost()),
-		Key:    aws.String(objectKey),
-		Body:   param.GetFile(),
+		Bucket:   aws.String(param.GetHost()),
+		Key:      aws.String(objectKey),
+		Body:     param.GetFile(),
+		Metadata: map[string]string{},
+	}
+
+	if sse := s3UploadOption(param, S3ServerSideEncryptionQuery); len(sse) > 0 {
+		input.ServerSideEncryption = types.ServerSideEncryption(sse)
+	}
+
+	if keyId := s3UploadOption(param, S3SSEKMSKeyIdQuery); len(keyId) > 0 {
+
+		input.SSEKMSKeyId = aws.String(keyId)
+
+		if len(input.ServerSideEncryption) == 0 {
+			input.ServerSideEncryption = types.ServerSideEncryptionAwsKms
+		}
+
+	}
+
+	if storageClass := s3UploadOption(param, S3StorageClassQuery); len(storageClass) > 0 {
+		input.StorageClass = types.StorageClass(storageClass)
+	}
+
+	if tags := s3UploadOption(param, S3TagsQuery); len(tags) > 0 {
+		input.Tagging = aws.String(s3Tagging(tags))
+	}
+
+	if contentType := s3UploadOption(param, S3ContentTypeQuery); len(contentType) > 0 {
+		input.ContentType = aws.String(contentType)
+	}
+
+	if cacheControl := s3UploadOption(param, S3CacheControlQuery); len(cacheControl) > 0 {
+		input.CacheControl = aws.String(cacheControl)
+	}
+
+	if len(param.GetCompression()) > 0 {
+
+		if originalSize := param.Metadata.Get(parameter.OriginalSizeMetadata); len(originalSize) > 0 {
+			input.Metadata[S3OriginalSizeMetadata] = originalSize
+		}
+
+	}
+
+	keyProvider, keyId, err := param.GetKeyProvider()
+	if err != nil {
+		return err
+	}
+
+	if keyProvider != nil {
+
+		log.Debugw("Encrypting file", "key_id", keyId)
+
+		ciphertext, wrappedKey, err := parameter.SealEnvelope(ctx, keyProvider, keyId, param.GetFile().Bytes())
+		if err != nil {
+			return err
+		}
+
+		input.Body = bytes.NewReader(ciphertext)
+
+		input.Metadata[S3DataKeyMetadata] = base64.StdEncoding.EncodeToString(wrappedKey)
+		input.Metadata[S3EncryptionMetadata] = param.GetQuery().Get(parameter.EncryptionQuery)
+
 	}
 
 	log.Debugw("Starting upload of file")
 
-	_, err := s.s3Uploader.Upload(ctx, input)
+	_, err = s.s3Uploader.Upload(ctx, input)
 	if err != nil {
 		return err
 	}
@@ -115,3 +301,73 @@ func (s *s3ParameterFile) Upload(ctx context.Context, param *parameter.Parameter
 
 	return err
 }
+
+func (s *s3ParameterFile) Remove(ctx context.Context, param *parameter.Parameter) error {
+
+	objectKey := strings.TrimLeft(param.GetPath(), "/")
+
+	s.logger.Infow("Remove parameter file from S3",
+		"parameter_key", param.GetKey(),
+		"bucket", param.GetHost(),
+		"object_key", objectKey,
+	)
+
+	_, err := s.s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(param.GetHost()),
+		Key:    aws.String(objectKey),
+	})
+
+	return err
+
+}
+
+func (s *s3ParameterFile) List(ctx context.Context, param *parameter.Parameter) ([]string, error) {
+
+	prefix := strings.TrimLeft(param.GetPath(), "/")
+
+	if len(prefix) > 0 && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	log := s.logger.With(
+		"parameter_key", param.GetKey(),
+		"bucket", param.GetHost(),
+		"prefix", prefix,
+	)
+
+	log.Infow("List parameter directory from S3")
+
+	listObjectsReq := s3.NewListObjectsV2Paginator(s.s3Client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(param.GetHost()),
+		Prefix: aws.String(prefix),
+	})
+
+	relPaths := []string{}
+
+	for listObjectsReq.HasMorePages() {
+
+		listObjectsPage, err := listObjectsReq.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, object := range listObjectsPage.Contents {
+
+			relPath := strings.TrimPrefix(aws.ToString(object.Key), prefix)
+
+			// Skip "directory" placeholder objects
+			if len(relPath) == 0 || strings.HasSuffix(relPath, "/") {
+				continue
+			}
+
+			relPaths = append(relPaths, relPath)
+
+		}
+
+	}
+
+	log.Debugw("Listed parameter directory", "file_count", len(relPaths))
+
+	return relPaths, nil
+
+}
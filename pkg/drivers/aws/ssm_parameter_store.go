@@ -2,33 +2,41 @@ package awsdriver
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
 	"github.com/upper-institute/hike/pkg/parameter"
 	"go.uber.org/zap"
 )
 
 const (
+	SSMParameterStoreScheme     = "ssm"
 	SSMParameterPathSeparator   = "/"
-	SSMParameterPathPrefixQuery = "ssm_parameter_path_prefix"
+	SSMParameterPathPrefixQuery = parameter.PathPrefixMetadata
 )
 
 type ssmParameterStore struct {
 	ssmClient *ssm.Client
 
+	// Type of the created parameters, existing ones keep their type
+	secureString bool
+
 	logger *zap.SugaredLogger
 }
 
 func NewSSMParameterStore(
 	ssmClient *ssm.Client,
+	secureString bool,
 	logger *zap.SugaredLogger,
 ) parameter.Store {
 	return &ssmParameterStore{
-		ssmClient: ssmClient,
-		logger:    logger.With("driver", "aws_ssm_parameter_store"),
+		ssmClient:    ssmClient,
+		secureString: secureString,
+		logger:       logger.With("driver", "aws_ssm_parameter_store"),
 	}
 }
 
@@ -81,15 +89,73 @@ func (s *ssmParameterStore) Pull(ctx context.Context, options *parameter.PullReq
 
 }
 
+func (s *ssmParameterStore) parameterName(param *parameter.Parameter) string {
+
+	pathPrefix := strings.TrimRight(param.Metadata.Get(SSMParameterPathPrefixQuery), SSMParameterPathSeparator)
+
+	return fmt.Sprintf("%s%s%s", pathPrefix, SSMParameterPathSeparator, param.GetKey())
+
+}
+
+// parameterType returns the type of the existing parameter, or the type of
+// new parameters when not found
+func (s *ssmParameterStore) parameterType(ctx context.Context, name string) (types.ParameterType, error) {
+
+	getParameterOutput, err := s.ssmClient.GetParameter(ctx, &ssm.GetParameterInput{
+		Name: aws.String(name),
+	})
+
+	var notFound *types.ParameterNotFound
+
+	switch {
+
+	case errors.As(err, &notFound):
+
+		if s.secureString {
+			return types.ParameterTypeSecureString, nil
+		}
+
+		return types.ParameterTypeString, nil
+
+	case err != nil:
+		return "", err
+
+	}
+
+	return getParameterOutput.Parameter.Type, nil
+
+}
+
 func (s *ssmParameterStore) Put(ctx context.Context, param *parameter.Parameter) error {
 
-	pathPrefix := param.Metadata.Get(SSMParameterPathPrefixQuery)
+	name := s.parameterName(param)
+
+	parameterType, err := s.parameterType(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	s.logger.Infow("Put operation", "name", name, "type", parameterType)
+
+	_, err = s.ssmClient.PutParameter(ctx, &ssm.PutParameterInput{
+		Name:      aws.String(name),
+		Value:     aws.String(param.GetURLString()),
+		Type:      parameterType,
+		Overwrite: aws.Bool(true),
+	})
+
+	return err
+
+}
+
+func (s *ssmParameterStore) Delete(ctx context.Context, param *parameter.Parameter) error {
+
+	name := s.parameterName(param)
 
-	s.logger.Infow("Put operation", "path_prefix", pathPrefix)
+	s.logger.Infow("Delete operation", "name", name)
 
-	_, err := s.ssmClient.PutParameter(ctx, &ssm.PutParameterInput{
-		Name:  aws.String(fmt.Sprintf("%s%s", pathPrefix, param.GetKey())),
-		Value: aws.String(param.GetURLString()),
+	_, err := s.ssmClient.DeleteParameter(ctx, &ssm.DeleteParameterInput{
+		Name: aws.String(name),
 	})
 
 	return err
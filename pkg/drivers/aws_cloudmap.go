@@ -0,0 +1,112 @@
+package drivers
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/servicediscovery"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	awsdriver "github.com/upper-institute/hike/pkg/drivers/aws"
+)
+
+// Query keys of a Cloud Map discovery target, e.g.
+// region=eu-west-1&role_arn=arn:aws:iam::111111111111:role/hike&namespaces=prod.local,shared.local
+const (
+	CloudMapTargetRegionQuery     = "region"
+	CloudMapTargetRoleArnQuery    = "role_arn"
+	CloudMapTargetExternalIdQuery = "external_id"
+	CloudMapTargetAccountQuery    = "account"
+	CloudMapTargetNamespacesQuery = "namespaces"
+)
+
+func (d *AWSDriver) newCloudMapTarget(region string, roleArn string, externalId string, accountId string, namespacesNames []string) (*awsdriver.CloudMapDiscoveryTarget, error) {
+
+	cfg := d.configs[AwsCloudMapSubsystem].Copy()
+
+	if len(region) > 0 {
+		cfg.Region = region
+	}
+
+	if len(roleArn) > 0 {
+
+		parsedArn, err := arn.Parse(roleArn)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(accountId) == 0 {
+			accountId = parsedArn.AccountID
+		}
+
+		assumeRole(&cfg, roleArn, externalId, AwsCloudMapSubsystem)
+
+	}
+
+	return &awsdriver.CloudMapDiscoveryTarget{
+		Region:          cfg.Region,
+		AccountID:       accountId,
+		NamespacesNames: namespacesNames,
+		CloudMapClient:  servicediscovery.NewFromConfig(cfg),
+		StsClient:       sts.NewFromConfig(cfg),
+	}, nil
+
+}
+
+// parseCloudMapTargets parses the targets flag, without targets the Cloud
+// Map subsystem config is the only target
+func (d *AWSDriver) parseCloudMapTargets() ([]*awsdriver.CloudMapDiscoveryTarget, error) {
+
+	targetsQueries := d.binder.Viper.GetStringSlice(DriversAwsCloudMapTargets)
+
+	if len(targetsQueries) == 0 {
+
+		target, err := d.newCloudMapTarget("", "", "", "", d.binder.Viper.GetStringSlice(DriversAwsCloudMapNamespacesNames))
+		if err != nil {
+			return nil, err
+		}
+
+		return []*awsdriver.CloudMapDiscoveryTarget{target}, nil
+
+	}
+
+	targets := []*awsdriver.CloudMapDiscoveryTarget{}
+
+	for _, targetQuery := range targetsQueries {
+
+		query, err := url.ParseQuery(targetQuery)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid Cloud Map target '%s': %w", targetQuery, err)
+		}
+
+		namespacesNames := []string{}
+
+		for _, namespaceName := range strings.Split(query.Get(CloudMapTargetNamespacesQuery), ",") {
+			if len(namespaceName) > 0 {
+				namespacesNames = append(namespacesNames, namespaceName)
+			}
+		}
+
+		if len(namespacesNames) == 0 {
+			namespacesNames = d.binder.Viper.GetStringSlice(DriversAwsCloudMapNamespacesNames)
+		}
+
+		target, err := d.newCloudMapTarget(
+			query.Get(CloudMapTargetRegionQuery),
+			query.Get(CloudMapTargetRoleArnQuery),
+			query.Get(CloudMapTargetExternalIdQuery),
+			query.Get(CloudMapTargetAccountQuery),
+			namespacesNames,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid Cloud Map target '%s': %w", targetQuery, err)
+		}
+
+		targets = append(targets, target)
+
+	}
+
+	return targets, nil
+
+}
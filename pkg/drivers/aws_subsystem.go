@@ -0,0 +1,177 @@
+package drivers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/servicediscovery"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// Each subsystem can have its own profile, role, region and endpoint, e.g.
+// --drivers-aws-route53-role-arn for DNS in a shared networking account
+const (
+	AwsSsmSubsystem      = "ssm"
+	AwsS3Subsystem       = "s3"
+	AwsKmsSubsystem      = "kms"
+	AwsRoute53Subsystem  = "route53"
+	AwsCloudMapSubsystem = "cloudmap"
+)
+
+var awsSubsystems = []string{
+	AwsSsmSubsystem,
+	AwsS3Subsystem,
+	AwsKmsSubsystem,
+	AwsRoute53Subsystem,
+	AwsCloudMapSubsystem,
+}
+
+func awsSubsystemKey(subsystem string, name string) string {
+	return fmt.Sprintf("drivers.aws.%s.%s", subsystem, name)
+}
+
+func (d *AWSDriver) bindSubsystems() {
+
+	for _, subsystem := range awsSubsystems {
+
+		d.binder.BindString(awsSubsystemKey(subsystem, "profile"), "", fmt.Sprintf("AWS shared config profile of the %s client", subsystem))
+		d.binder.BindString(awsSubsystemKey(subsystem, "role.arn"), "", fmt.Sprintf("AWS IAM role assumed by the %s client", subsystem))
+		d.binder.BindString(awsSubsystemKey(subsystem, "role.external.id"), "", fmt.Sprintf("External ID to assume the role of the %s client", subsystem))
+		d.binder.BindString(awsSubsystemKey(subsystem, "region"), "", fmt.Sprintf("AWS region of the %s client", subsystem))
+		d.binder.BindString(awsSubsystemKey(subsystem, "endpoint.url"), "", fmt.Sprintf("Custom endpoint URL of the %s client (e.g. LocalStack, MinIO)", subsystem))
+
+	}
+
+}
+
+func (d *AWSDriver) loadOptions() ([]func(*config.LoadOptions) error, error) {
+
+	loadOptions := []func(*config.LoadOptions) error{}
+
+	if region := d.binder.Viper.GetString(DriversAwsRegion); len(region) > 0 {
+		loadOptions = append(loadOptions, config.WithRegion(region))
+	}
+
+	if profile := d.binder.Viper.GetString(DriversAwsProfile); len(profile) > 0 {
+		loadOptions = append(loadOptions, config.WithSharedConfigProfile(profile))
+	}
+
+	if caBundlePath := d.binder.Viper.GetString(DriversAwsCaBundle); len(caBundlePath) > 0 {
+
+		caBundle, err := os.ReadFile(caBundlePath)
+		if err != nil {
+			return nil, err
+		}
+
+		loadOptions = append(loadOptions, config.WithCustomCABundle(bytes.NewReader(caBundle)))
+
+	}
+
+	return loadOptions, nil
+
+}
+
+// SDK service ID of the client of each subsystem, the subsystem endpoint
+// must not be used by other clients (e.g. STS to assume the role)
+var awsSubsystemServiceIDs = map[string]string{
+	AwsSsmSubsystem:      ssm.ServiceID,
+	AwsS3Subsystem:       s3.ServiceID,
+	AwsKmsSubsystem:      kms.ServiceID,
+	AwsRoute53Subsystem:  route53.ServiceID,
+	AwsCloudMapSubsystem: servicediscovery.ServiceID,
+}
+
+// endpointResolver resolves the subsystem service to the subsystem endpoint,
+// and every other service to the global endpoint, default resolution is
+// used when empty
+func endpointResolver(serviceId string, subsystemEndpointUrl string, globalEndpointUrl string) aws.EndpointResolverWithOptions {
+
+	return aws.EndpointResolverWithOptionsFunc(func(service, region string, options ...interface{}) (aws.Endpoint, error) {
+
+		endpointUrl := globalEndpointUrl
+
+		if service == serviceId && len(subsystemEndpointUrl) > 0 {
+			endpointUrl = subsystemEndpointUrl
+		}
+
+		if len(endpointUrl) == 0 {
+			return aws.Endpoint{}, &aws.EndpointNotFoundError{}
+		}
+
+		return aws.Endpoint{
+			URL:           endpointUrl,
+			SigningRegion: region,
+			Source:        aws.EndpointSourceCustom,
+		}, nil
+
+	})
+
+}
+
+func (d *AWSDriver) loadSubsystemConfig(ctx context.Context, subsystem string) (aws.Config, error) {
+
+	cfg := d.config.Copy()
+
+	if profile := d.binder.Viper.GetString(awsSubsystemKey(subsystem, "profile")); len(profile) > 0 {
+
+		loadOptions, err := d.loadOptions()
+		if err != nil {
+			return cfg, err
+		}
+
+		loadOptions = append(loadOptions, config.WithSharedConfigProfile(profile))
+
+		cfg, err = config.LoadDefaultConfig(ctx, loadOptions...)
+		if err != nil {
+			return cfg, err
+		}
+
+	}
+
+	if region := d.binder.Viper.GetString(awsSubsystemKey(subsystem, "region")); len(region) > 0 {
+		cfg.Region = region
+	}
+
+	subsystemEndpointUrl := d.binder.Viper.GetString(awsSubsystemKey(subsystem, "endpoint.url"))
+	globalEndpointUrl := d.binder.Viper.GetString(DriversAwsEndpointUrl)
+
+	if len(subsystemEndpointUrl) > 0 || len(globalEndpointUrl) > 0 {
+		cfg.EndpointResolverWithOptions = endpointResolver(awsSubsystemServiceIDs[subsystem], subsystemEndpointUrl, globalEndpointUrl)
+	}
+
+	if roleArn := d.binder.Viper.GetString(awsSubsystemKey(subsystem, "role.arn")); len(roleArn) > 0 {
+
+		d.logger.Debugw("Assume role for AWS client", "subsystem", subsystem, "role_arn", roleArn)
+
+		assumeRole(&cfg, roleArn, d.binder.Viper.GetString(awsSubsystemKey(subsystem, "role.external.id")), subsystem)
+
+	}
+
+	return cfg, nil
+
+}
+
+func assumeRole(cfg *aws.Config, roleArn string, externalId string, sessionSuffix string) {
+
+	stsClient := sts.NewFromConfig(*cfg)
+
+	cfg.Credentials = aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(stsClient, roleArn, func(o *stscreds.AssumeRoleOptions) {
+
+		o.RoleSessionName = "hike-" + sessionSuffix
+
+		if len(externalId) > 0 {
+			o.ExternalID = aws.String(externalId)
+		}
+
+	}))
+
+}
@@ -0,0 +1,88 @@
+package drivers
+
+import (
+	"context"
+
+	"github.com/go-acme/lego/v4/challenge"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	localdriver "github.com/upper-institute/hike/pkg/drivers/local"
+	"github.com/upper-institute/hike/pkg/helpers"
+	"github.com/upper-institute/hike/pkg/parameter"
+	"github.com/upper-institute/hike/pkg/servicemesh"
+	"go.uber.org/zap"
+)
+
+const (
+	DriversLocalFsParameterStoreEnable = "drivers.local.fs.parameter.store.enable"
+	DriversLocalFsParameterStoreRoot   = "drivers.local.fs.parameter.store.root"
+	DriversLocalKeyFileProviderEnable  = "drivers.local.key.file.provider.enable"
+	DriversLocalKeyFileProviderRoot    = "drivers.local.key.file.provider.root"
+)
+
+type LocalDriver struct {
+	logger *zap.SugaredLogger
+
+	binder *helpers.FlagBinder
+}
+
+func (d *LocalDriver) Bind(flagSet *pflag.FlagSet, cfg *viper.Viper) {
+
+	d.binder = &helpers.FlagBinder{Viper: cfg, FlagSet: flagSet}
+
+	d.binder.BindBool(DriversLocalFsParameterStoreEnable, false, "Use local filesystem to pull/push parameters (source URIs with scheme local://)")
+	d.binder.BindString(DriversLocalFsParameterStoreRoot, ".", "Root directory of the local filesystem parameter store")
+	d.binder.BindBool(DriversLocalKeyFileProviderEnable, false, "Use local key files to encrypt file parameters (query encryption=local&encryption_key=<file>)")
+	d.binder.BindString(DriversLocalKeyFileProviderRoot, ".", "Root directory of the local key files")
+
+}
+
+func (d *LocalDriver) Load(ctx context.Context, logger *zap.SugaredLogger) error {
+
+	d.logger = logger
+
+	return nil
+
+}
+
+func (d *LocalDriver) GetPrincipal(ctx context.Context) (string, error) {
+	return "", nil
+}
+
+func (d *LocalDriver) ApplyParameterSourceOptions(opts *parameter.SourceOptions) {
+
+	if d.binder.Viper.GetBool(DriversLocalFsParameterStoreEnable) {
+
+		store := localdriver.NewFSParameterStore(
+			d.binder.Viper.GetString(DriversLocalFsParameterStoreRoot),
+			d.logger,
+		)
+
+		opts.RegisterStore(localdriver.FSParameterStoreScheme, store)
+
+	}
+
+	if d.binder.Viper.GetBool(DriversLocalKeyFileProviderEnable) {
+
+		provider := localdriver.NewKeyFileProvider(
+			d.binder.Viper.GetString(DriversLocalKeyFileProviderRoot),
+			d.logger,
+		)
+
+		opts.RegisterKeyProvider(localdriver.KeyFileProviderName, provider)
+
+	}
+
+}
+
+func (d *LocalDriver) GetDNS01Provider() challenge.Provider {
+	return nil
+}
+
+func (d *LocalDriver) GetDomainRegistry() servicemesh.DomainRegistry {
+	return nil
+}
+
+func (d *LocalDriver) GetEnvoyDiscoveryServices(opts *parameter.SourceOptions, domainRegistry servicemesh.DomainRegistry) []servicemesh.EnvoyDiscoveryService {
+	return []servicemesh.EnvoyDiscoveryService{}
+}
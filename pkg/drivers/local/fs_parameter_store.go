@@ -0,0 +1,161 @@
+package localdriver
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/upper-institute/hike/pkg/parameter"
+	"go.uber.org/zap"
+)
+
+const (
+	FSParameterStoreScheme = "local"
+	FSParameterPathPrefix  = "/"
+)
+
+var (
+	PathOutsideRootErr = errors.New("Parameter path is outside the store root")
+	InvalidKeyErr      = errors.New("Parameter key must be a file name, without path separators")
+)
+
+// fsParameterStore keeps every parameter as a file named by the parameter
+// key, inside the directory of its path, holding the parameter URL
+type fsParameterStore struct {
+	root string
+
+	logger *zap.SugaredLogger
+}
+
+func NewFSParameterStore(
+	root string,
+	logger *zap.SugaredLogger,
+) parameter.Store {
+	return &fsParameterStore{
+		root:   root,
+		logger: logger.With("driver", "local_fs_parameter_store"),
+	}
+}
+
+func (s *fsParameterStore) Pull(ctx context.Context, options *parameter.PullRequest) error {
+
+	dir, err := s.confine(filepath.FromSlash(options.Url.Path))
+	if err != nil {
+		return err
+	}
+
+	err = filepath.WalkDir(dir, func(filePath string, entry fs.DirEntry, err error) error {
+
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			return nil
+		}
+
+		relDir, err := filepath.Rel(s.root, filepath.Dir(filePath))
+		if err != nil {
+			return err
+		}
+
+		pathPrefix := path.Join(FSParameterPathPrefix, filepath.ToSlash(relDir))
+		key := entry.Name()
+
+		value, err := os.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+
+		s.logger.Infow("Pull operation", "key", key, "path_prefix", pathPrefix)
+
+		param, err := options.NewFromURLString(key, strings.TrimSpace(string(value)))
+		if err != nil {
+			return err
+		}
+
+		param.Metadata.Set(parameter.PathPrefixMetadata, pathPrefix)
+
+		options.Result <- param
+
+		return nil
+
+	})
+
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	close(options.Result)
+
+	return nil
+
+}
+
+// confine joins the path elements to the root, failing when the result is
+// outside of it (e.g. local:///../etc)
+func (s *fsParameterStore) confine(elem ...string) (string, error) {
+
+	filePath := filepath.Join(append([]string{s.root}, elem...)...)
+
+	rel, err := filepath.Rel(s.root, filePath)
+	if err != nil {
+		return "", err
+	}
+
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", PathOutsideRootErr
+	}
+
+	return filePath, nil
+
+}
+
+func (s *fsParameterStore) filePath(param *parameter.Parameter) (string, error) {
+
+	key := param.GetKey()
+
+	if len(key) == 0 || key == "." || key == ".." || strings.ContainsAny(key, "/\\") {
+		return "", InvalidKeyErr
+	}
+
+	pathPrefix := param.Metadata.Get(parameter.PathPrefixMetadata)
+
+	return s.confine(filepath.FromSlash(pathPrefix), key)
+
+}
+
+func (s *fsParameterStore) Put(ctx context.Context, param *parameter.Parameter) error {
+
+	filePath, err := s.filePath(param)
+	if err != nil {
+		return err
+	}
+
+	s.logger.Infow("Put operation", "file_path", filePath)
+
+	err = os.MkdirAll(filepath.Dir(filePath), 0700)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filePath, []byte(param.GetURLString()), 0600)
+
+}
+
+func (s *fsParameterStore) Delete(ctx context.Context, param *parameter.Parameter) error {
+
+	filePath, err := s.filePath(param)
+	if err != nil {
+		return err
+	}
+
+	s.logger.Infow("Delete operation", "file_path", filePath)
+
+	return os.Remove(filePath)
+
+}
@@ -0,0 +1,95 @@
+package localdriver
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/upper-institute/hike/pkg/parameter"
+	"go.uber.org/zap"
+)
+
+const KeyFileProviderName = "local"
+
+var InvalidKeyFileErr = errors.New("Key file must hold 32 bytes, raw or base64 encoded")
+
+// keyFileProvider wraps data keys with AES keys read from files in the root
+// directory, the key reference is the file path relative to the root. A key
+// can be created with: head -c 32 /dev/urandom | base64 > root/key
+type keyFileProvider struct {
+	root string
+
+	logger *zap.SugaredLogger
+}
+
+func NewKeyFileProvider(
+	root string,
+	logger *zap.SugaredLogger,
+) parameter.KeyProvider {
+	return &keyFileProvider{
+		root:   root,
+		logger: logger.With("driver", "local_key_file_provider"),
+	}
+}
+
+func (p *keyFileProvider) readKey(keyId string) ([]byte, error) {
+
+	content, err := os.ReadFile(filepath.Join(p.root, filepath.FromSlash(filepath.Clean("/"+keyId))))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(content) == parameter.DataKeySize {
+		return content, nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(content)))
+	if err != nil || len(key) != parameter.DataKeySize {
+		return nil, InvalidKeyFileErr
+	}
+
+	return key, nil
+
+}
+
+func (p *keyFileProvider) GenerateDataKey(ctx context.Context, keyId string) ([]byte, []byte, error) {
+
+	p.logger.Debugw("Generate data key", "key_id", keyId)
+
+	key, err := p.readKey(keyId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dataKey := make([]byte, parameter.DataKeySize)
+
+	_, err = rand.Read(dataKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	wrapped, err := parameter.EncryptAESGCM(key, dataKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return dataKey, wrapped, nil
+
+}
+
+func (p *keyFileProvider) DecryptDataKey(ctx context.Context, keyId string, wrapped []byte) ([]byte, error) {
+
+	p.logger.Debugw("Decrypt data key", "key_id", keyId)
+
+	key, err := p.readKey(keyId)
+	if err != nil {
+		return nil, err
+	}
+
+	return parameter.DecryptAESGCM(key, wrapped)
+
+}
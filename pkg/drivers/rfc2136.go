@@ -0,0 +1,131 @@
+package drivers
+
+import (
+	"context"
+
+	"github.com/go-acme/lego/v4/challenge"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	rfc2136driver "github.com/upper-institute/hike/pkg/drivers/rfc2136"
+	"github.com/upper-institute/hike/pkg/helpers"
+	"github.com/upper-institute/hike/pkg/parameter"
+	"github.com/upper-institute/hike/pkg/servicemesh"
+	"go.uber.org/zap"
+)
+
+const (
+	DriversRfc2136DomainRegistryEnable    = "drivers.rfc2136.domain.registry.enable"
+	DriversRfc2136Nameserver              = "drivers.rfc2136.nameserver"
+	DriversRfc2136Net                     = "drivers.rfc2136.net"
+	DriversRfc2136Timeout                 = "drivers.rfc2136.timeout"
+	DriversRfc2136TsigKey                 = "drivers.rfc2136.tsig.key"
+	DriversRfc2136TsigSecret              = "drivers.rfc2136.tsig.secret"
+	DriversRfc2136TsigAlgorithm           = "drivers.rfc2136.tsig.algorithm"
+	DriversRfc2136Dns01PropagationTimeout = "drivers.rfc2136.dns01.propagation.timeout"
+	DriversRfc2136Dns01PollingInterval    = "drivers.rfc2136.dns01.polling.interval"
+	DriversRfc2136OwnerId                 = "drivers.rfc2136.owner.id"
+	DriversRfc2136AdoptRecords            = "drivers.rfc2136.adopt.records"
+	DriversRfc2136GcEnable                = "drivers.rfc2136.gc.enable"
+	DriversRfc2136GcGracePeriod           = "drivers.rfc2136.gc.grace.period"
+	DriversRfc2136GcDryRun                = "drivers.rfc2136.gc.dry.run"
+	DriversRfc2136GcZones                 = "drivers.rfc2136.gc.zones"
+)
+
+// RFC2136Driver manages DNS records of zones accepting RFC 2136 dynamic
+// updates, e.g. on-premises BIND or PowerDNS
+type RFC2136Driver struct {
+	logger *zap.SugaredLogger
+
+	domainRegistry *rfc2136driver.DomainRegistry
+
+	binder *helpers.FlagBinder
+}
+
+func (d *RFC2136Driver) Bind(flagSet *pflag.FlagSet, cfg *viper.Viper) {
+
+	d.binder = &helpers.FlagBinder{Viper: cfg, FlagSet: flagSet}
+
+	d.binder.BindBool(DriversRfc2136DomainRegistryEnable, false, "Use RFC 2136 dynamic updates as domain registry (also solves ACME DNS-01 challenges)")
+	d.binder.BindString(DriversRfc2136Nameserver, "", "Primary nameserver (host:port) accepting the dynamic updates of the zones")
+	d.binder.BindString(DriversRfc2136Net, rfc2136driver.DefaultNet, "Protocol of the dynamic updates: udp, tcp or tcp-tls")
+	d.binder.BindDuration(DriversRfc2136Timeout, rfc2136driver.DefaultTimeout, "Timeout of each DNS query and update")
+	d.binder.BindString(DriversRfc2136TsigKey, "", "Name of the TSIG key signing the dynamic updates (unsigned when empty)")
+	d.binder.BindString(DriversRfc2136TsigSecret, "", "Base64 secret of the TSIG key")
+	d.binder.BindString(DriversRfc2136TsigAlgorithm, rfc2136driver.DefaultTSIGAlgorithm, "Algorithm of the TSIG key (e.g. hmac-sha256., hmac-sha512.)")
+	d.binder.BindDuration(DriversRfc2136Dns01PropagationTimeout, rfc2136driver.DefaultDNS01PropagationTimeout, "Max time to wait for ACME DNS-01 challenge records to be propagated")
+	d.binder.BindDuration(DriversRfc2136Dns01PollingInterval, rfc2136driver.DefaultDNS01PollingInterval, "Interval between checks of ACME DNS-01 challenge records")
+	d.binder.BindString(DriversRfc2136OwnerId, rfc2136driver.DefaultOwnerID, "ID of this hike instance in the ownership TXT records, only owned DNS records are changed")
+	d.binder.BindBool(DriversRfc2136AdoptRecords, false, "Take ownership of existing DNS records without ownership TXT records")
+	d.binder.BindBool(DriversRfc2136GcEnable, false, "Delete the owned DNS records of services no longer discovered (requires zone transfers)")
+	d.binder.BindDuration(DriversRfc2136GcGracePeriod, rfc2136driver.DefaultGCGracePeriod, "Time an owned DNS record must be missing from discovery before being deleted")
+	d.binder.BindBool(DriversRfc2136GcDryRun, false, "Only report the owned DNS records which would be deleted")
+	d.binder.BindStringSlice(DriversRfc2136GcZones, []string{}, "Zones checked for owned DNS records to delete, besides the zones of the registered records")
+
+}
+
+func (d *RFC2136Driver) Load(ctx context.Context, logger *zap.SugaredLogger) error {
+
+	d.logger = logger
+
+	if !d.binder.Viper.GetBool(DriversRfc2136DomainRegistryEnable) {
+		return nil
+	}
+
+	options := &rfc2136driver.DomainRegistryOptions{
+		Nameserver:              d.binder.Viper.GetString(DriversRfc2136Nameserver),
+		Net:                     d.binder.Viper.GetString(DriversRfc2136Net),
+		Timeout:                 d.binder.Viper.GetDuration(DriversRfc2136Timeout),
+		TSIGKeyName:             d.binder.Viper.GetString(DriversRfc2136TsigKey),
+		TSIGSecret:              d.binder.Viper.GetString(DriversRfc2136TsigSecret),
+		TSIGAlgorithm:           d.binder.Viper.GetString(DriversRfc2136TsigAlgorithm),
+		DNS01PropagationTimeout: d.binder.Viper.GetDuration(DriversRfc2136Dns01PropagationTimeout),
+		DNS01PollingInterval:    d.binder.Viper.GetDuration(DriversRfc2136Dns01PollingInterval),
+		OwnerID:                 d.binder.Viper.GetString(DriversRfc2136OwnerId),
+		AdoptRecords:            d.binder.Viper.GetBool(DriversRfc2136AdoptRecords),
+		GCEnable:                d.binder.Viper.GetBool(DriversRfc2136GcEnable),
+		GCGracePeriod:           d.binder.Viper.GetDuration(DriversRfc2136GcGracePeriod),
+		GCDryRun:                d.binder.Viper.GetBool(DriversRfc2136GcDryRun),
+		GCZones:                 d.binder.Viper.GetStringSlice(DriversRfc2136GcZones),
+	}
+
+	domainRegistry, err := options.NewDomainRegistry(logger)
+	if err != nil {
+		return err
+	}
+
+	d.domainRegistry = domainRegistry
+
+	return nil
+
+}
+
+func (d *RFC2136Driver) GetPrincipal(ctx context.Context) (string, error) {
+	return "", nil
+}
+
+func (d *RFC2136Driver) ApplyParameterSourceOptions(opts *parameter.SourceOptions) {
+}
+
+func (d *RFC2136Driver) GetDomainRegistry() servicemesh.DomainRegistry {
+
+	if d.domainRegistry == nil {
+		return nil
+	}
+
+	return d.domainRegistry
+
+}
+
+func (d *RFC2136Driver) GetDNS01Provider() challenge.Provider {
+
+	if d.domainRegistry == nil {
+		return nil
+	}
+
+	return d.domainRegistry
+
+}
+
+func (d *RFC2136Driver) GetEnvoyDiscoveryServices(opts *parameter.SourceOptions, domainRegistry servicemesh.DomainRegistry) []servicemesh.EnvoyDiscoveryService {
+	return []servicemesh.EnvoyDiscoveryService{}
+}
@@ -0,0 +1,75 @@
+package rfc2136driver
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-acme/lego/v4/challenge/dns01"
+	"github.com/miekg/dns"
+)
+
+const (
+	DefaultDNS01PropagationTimeout = 2 * time.Minute
+	DefaultDNS01PollingInterval    = 2 * time.Second
+
+	acmeChallengeTTL = 60
+)
+
+// changeChallenge adds or removes the value from the TXT record set, values
+// of other challenges of the same name (e.g. wildcard and apex) are kept
+func (r *DomainRegistry) changeChallenge(fqdn string, value string, present bool) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.options.DNS01PropagationTimeout)
+	defer cancel()
+
+	zone, err := r.findZone(ctx, fqdn)
+	if err != nil {
+		return err
+	}
+
+	rr, err := dns.NewRR(fmt.Sprintf("%s %d IN TXT %q", fqdn, acmeChallengeTTL, value))
+	if err != nil {
+		return err
+	}
+
+	r.logger.Infow("Changing ACME challenge record", "zone", zone, "record_fqdn", fqdn, "present", present)
+
+	msg := new(dns.Msg)
+	msg.SetUpdate(zone)
+
+	if present {
+		msg.Insert([]dns.RR{rr})
+	} else {
+		msg.Remove([]dns.RR{rr})
+	}
+
+	_, err = r.exchange(ctx, msg)
+
+	return err
+
+}
+
+// Present adds the _acme-challenge TXT record, the ACME client then checks
+// the propagation on the authoritative nameservers
+func (r *DomainRegistry) Present(domain, token, keyAuth string) error {
+
+	fqdn, value := dns01.GetRecord(domain, keyAuth)
+
+	return r.changeChallenge(fqdn, value, true)
+
+}
+
+// CleanUp removes the value of the challenge from the _acme-challenge record
+func (r *DomainRegistry) CleanUp(domain, token, keyAuth string) error {
+
+	fqdn, value := dns01.GetRecord(domain, keyAuth)
+
+	return r.changeChallenge(fqdn, value, false)
+
+}
+
+// Timeout of the propagation check and its polling interval
+func (r *DomainRegistry) Timeout() (time.Duration, time.Duration) {
+	return r.options.DNS01PropagationTimeout, r.options.DNS01PollingInterval
+}
@@ -0,0 +1,51 @@
+package rfc2136driver
+
+import (
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+func TestDNS01Challenge(t *testing.T) {
+
+	ns := newTestNameserver(t)
+	r := newTestDomainRegistry(t, ns, &DomainRegistryOptions{})
+
+	if err := r.Present("www.example.org", "token", "www"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The wildcard and apex challenges share the record name
+	if err := r.Present("example.org", "token", "wildcard"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Present("example.org", "token", "apex"); err != nil {
+		t.Fatal(err)
+	}
+
+	if values := ns.values("_acme-challenge.example.org.", dns.TypeTXT); len(values) != 2 {
+		t.Fatalf("unexpected challenge records %v", values)
+	}
+
+	if err := r.CleanUp("example.org", "token", "wildcard"); err != nil {
+		t.Fatal(err)
+	}
+
+	if values := ns.values("_acme-challenge.example.org.", dns.TypeTXT); len(values) != 1 {
+		t.Fatalf("unexpected challenge records after clean up %v", values)
+	}
+
+	if err := r.CleanUp("www.example.org", "token", "www"); err != nil {
+		t.Fatal(err)
+	}
+
+	if values := ns.values("_acme-challenge.www.example.org.", dns.TypeTXT); len(values) != 0 {
+		t.Fatalf("challenge records not cleaned up %v", values)
+	}
+
+	if err := r.Present("example.com", "token", "www"); err != ZoneNotFoundErr {
+		t.Fatalf("expected zone not found, got %v", err)
+	}
+
+}
@@ -0,0 +1,450 @@
+package rfc2136driver
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/upper-institute/hike/pkg/servicemesh"
+	sdapi "github.com/upper-institute/hike/proto/api/service-discovery"
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultNet           = "udp"
+	DefaultTSIGAlgorithm = dns.HmacSHA256
+	DefaultTimeout       = 10 * time.Second
+	DefaultRecordTTL     = 30 * time.Second
+
+	// Max age of the TSIG signature time accepted by the nameserver
+	tsigFudge = 300
+)
+
+var (
+	MissingNameserverErr        = errors.New("Missing nameserver of the RFC 2136 domain registry")
+	MissingTSIGSecretErr        = errors.New("Missing TSIG secret of the TSIG key")
+	UnsupportedAliasErr         = errors.New("DNS alias records are not supported by RFC 2136 dynamic updates")
+	UnsupportedRoutingPolicyErr = errors.New("DNS routing policies are not supported by RFC 2136 dynamic updates")
+	ZoneNotFoundErr             = errors.New("Found no zone of the name in the nameserver")
+)
+
+type DomainRegistryOptions struct {
+	// Primary nameserver (host:port) of the zones, accepting dynamic updates
+	Nameserver string
+	// udp, tcp or tcp-tls
+	Net     string
+	Timeout time.Duration
+	// TSIG key name and base64 secret, updates are not signed when empty
+	TSIGKeyName   string
+	TSIGSecret    string
+	TSIGAlgorithm string
+	// Timeout and polling interval of DNS-01 challenges, see Timeout
+	DNS01PropagationTimeout time.Duration
+	DNS01PollingInterval    time.Duration
+	// Identifies the hike instance in the ownership records
+	OwnerID string
+	// Claims the existing records without ownership, e.g. created by hand
+	AdoptRecords bool
+	// Deletes the owned records of services no longer discovered, the zones
+	// are transferred (AXFR) to find them
+	GCEnable      bool
+	GCGracePeriod time.Duration
+	// Only reports the records which would be deleted
+	GCDryRun bool
+	// Zones checked for owned records besides the ones of the registered
+	// records, e.g. zones of services gone before a restart
+	GCZones []string
+}
+
+func (options *DomainRegistryOptions) NewDomainRegistry(logger *zap.SugaredLogger) (*DomainRegistry, error) {
+
+	if len(options.Nameserver) == 0 {
+		return nil, MissingNameserverErr
+	}
+
+	client := &dns.Client{
+		Net:     options.Net,
+		Timeout: options.Timeout,
+	}
+
+	if len(client.Net) == 0 {
+		client.Net = DefaultNet
+	}
+
+	if client.Timeout == 0 {
+		client.Timeout = DefaultTimeout
+	}
+
+	if options.DNS01PropagationTimeout == 0 {
+		options.DNS01PropagationTimeout = DefaultDNS01PropagationTimeout
+	}
+
+	if options.DNS01PollingInterval == 0 {
+		options.DNS01PollingInterval = DefaultDNS01PollingInterval
+	}
+
+	if len(options.OwnerID) == 0 {
+		options.OwnerID = DefaultOwnerID
+	}
+
+	if len(options.TSIGKeyName) > 0 {
+
+		if len(options.TSIGSecret) == 0 {
+			return nil, MissingTSIGSecretErr
+		}
+
+		if _, err := base64.StdEncoding.DecodeString(options.TSIGSecret); err != nil {
+			return nil, err
+		}
+
+		client.TsigSecret = map[string]string{dns.Fqdn(options.TSIGKeyName): options.TSIGSecret}
+
+	}
+
+	return &DomainRegistry{
+		options:      options,
+		client:       client,
+		gcZones:      make(map[string]bool),
+		missingSince: make(map[recordKey]time.Time),
+		logger:       logger.With("part", "rfc2136/domain-registry"),
+	}, nil
+
+}
+
+type recordKey struct {
+	zone       string
+	fqdn       string
+	recordType uint16
+}
+
+// DomainRegistry registers the DNS records of the services with RFC 2136
+// dynamic updates, e.g. in BIND or PowerDNS zones
+type DomainRegistry struct {
+	options *DomainRegistryOptions
+
+	client *dns.Client
+
+	// Garbage collection state: zones which may hold owned records and since
+	// when the owned records are no longer registered
+	mu           sync.Mutex
+	gcZones      map[string]bool
+	missingSince map[recordKey]time.Time
+
+	// Services of the current sync, records owned by other services can be
+	// taken over when their service is no longer discovered
+	discovered map[string]bool
+	complete   bool
+
+	logger *zap.SugaredLogger
+}
+
+// sign adds the TSIG record to the message, the client signs it when sent
+func (r *DomainRegistry) sign(msg *dns.Msg) {
+
+	if len(r.options.TSIGKeyName) == 0 {
+		return
+	}
+
+	algorithm := r.options.TSIGAlgorithm
+
+	if len(algorithm) == 0 {
+		algorithm = DefaultTSIGAlgorithm
+	}
+
+	msg.SetTsig(dns.Fqdn(r.options.TSIGKeyName), dns.Fqdn(algorithm), tsigFudge, time.Now().Unix())
+
+}
+
+// exchange signs the message with the TSIG key and sends it to the
+// nameserver, failing on responses other than success or NXDOMAIN
+func (r *DomainRegistry) exchange(ctx context.Context, msg *dns.Msg) (*dns.Msg, error) {
+
+	r.sign(msg)
+
+	res, _, err := r.client.ExchangeContext(ctx, msg, r.options.Nameserver)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.Rcode != dns.RcodeSuccess && res.Rcode != dns.RcodeNameError {
+		return nil, fmt.Errorf("DNS %s failed with %s (name: %s)", dns.OpcodeToString[msg.Opcode], dns.RcodeToString[res.Rcode], msg.Question[0].Name)
+	}
+
+	return res, nil
+
+}
+
+func (r *DomainRegistry) lookup(ctx context.Context, fqdn string, recordType uint16) ([]dns.RR, error) {
+
+	msg := new(dns.Msg)
+	msg.SetQuestion(fqdn, recordType)
+
+	res, err := r.exchange(ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	records := []dns.RR{}
+
+	for _, rr := range res.Answer {
+		if rr.Header().Rrtype == recordType && strings.EqualFold(rr.Header().Name, fqdn) {
+			records = append(records, rr)
+		}
+	}
+
+	return records, nil
+
+}
+
+// findZone returns the longest zone of the fqdn served by the nameserver
+func (r *DomainRegistry) findZone(ctx context.Context, fqdn string) (string, error) {
+
+	labels := dns.SplitDomainName(fqdn)
+
+	for i := range labels {
+
+		zone := dns.Fqdn(strings.Join(labels[i:], "."))
+
+		soa, err := r.lookup(ctx, zone, dns.TypeSOA)
+		if err != nil {
+			return "", err
+		}
+
+		if len(soa) > 0 {
+			return zone, nil
+		}
+
+	}
+
+	return "", ZoneNotFoundErr
+
+}
+
+func recordType(record *sdapi.DnsRecord) uint16 {
+
+	switch record.Type {
+
+	case sdapi.DnsRecordType_DRT_CNAME:
+		return dns.TypeCNAME
+
+	case sdapi.DnsRecordType_DRT_A:
+		return dns.TypeA
+
+	case sdapi.DnsRecordType_DRT_AAAA:
+		return dns.TypeAAAA
+
+	}
+
+	if len(record.CnameValue) > 0 {
+		return dns.TypeCNAME
+	}
+
+	return dns.TypeA
+
+}
+
+// desiredRecords builds the resource records of the record, empty when the
+// record has no value (e.g. a service without endpoints)
+func desiredRecords(svc *sdapi.Service, record *sdapi.DnsRecord, key recordKey) ([]dns.RR, error) {
+
+	values := record.Values
+
+	switch {
+
+	case key.recordType == dns.TypeCNAME:
+		values = []string{}
+
+		if len(record.CnameValue) > 0 {
+			values = append(values, dns.Fqdn(record.CnameValue))
+		}
+
+	case len(values) == 0:
+		values = servicemesh.EndpointAddresses(svc, key.recordType == dns.TypeAAAA)
+
+	}
+
+	ttl := DefaultRecordTTL
+
+	if record.Ttl.IsValid() {
+		ttl = record.Ttl.AsDuration()
+	}
+
+	records := []dns.RR{}
+
+	for _, value := range values {
+
+		rr, err := dns.NewRR(fmt.Sprintf("%s %d IN %s %s", key.fqdn, int64(ttl.Seconds()), dns.TypeToString[key.recordType], value))
+		if err != nil {
+			return nil, err
+		}
+
+		records = append(records, rr)
+
+	}
+
+	return records, nil
+
+}
+
+func recordsEqual(a []dns.RR, b []dns.RR) bool {
+
+	if len(a) != len(b) {
+		return false
+	}
+
+	values := func(records []dns.RR) []string {
+
+		values := []string{}
+
+		for _, rr := range records {
+			values = append(values, strings.ToLower(rr.String()))
+		}
+
+		sort.Strings(values)
+
+		return values
+
+	}
+
+	return strings.Join(values(a), "\n") == strings.Join(values(b), "\n")
+
+}
+
+// registerDnsRecord replaces the record set when it's owned by the service
+// (or can be claimed), the ownership record is changed in the same update
+func (r *DomainRegistry) registerDnsRecord(ctx context.Context, svc *sdapi.Service, record *sdapi.DnsRecord, key recordKey) error {
+
+	logger := r.logger.With("zone", key.zone, "record_fqdn", key.fqdn, "record_type", dns.TypeToString[key.recordType])
+
+	if record.AliasTarget != nil {
+		return UnsupportedAliasErr
+	}
+
+	if record.RoutingPolicy != nil {
+		return UnsupportedRoutingPolicyErr
+	}
+
+	desired, err := desiredRecords(svc, record, key)
+	if err != nil {
+		return err
+	}
+
+	if len(desired) == 0 {
+		logger.Warnw("No value for the record, skipping")
+		return nil
+	}
+
+	current, err := r.lookup(ctx, key.fqdn, key.recordType)
+	if err != nil {
+		return err
+	}
+
+	owner, err := r.lookupOwnership(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	claimed, err := r.checkOwner(logger, owner, svc.ServiceName, len(current) > 0)
+	if err != nil {
+		return err
+	}
+
+	if !claimed && recordsEqual(current, desired) {
+
+		logger.Debugw("No need to update record, skipping action")
+
+		return nil
+
+	}
+
+	logger.Infow("Replacing record set (dynamic update)", "value_count", len(desired))
+
+	msg := new(dns.Msg)
+	msg.SetUpdate(key.zone)
+	msg.RemoveRRset([]dns.RR{&dns.ANY{Hdr: dns.RR_Header{Name: key.fqdn, Rrtype: key.recordType}}})
+	msg.Insert(desired)
+
+	if claimed {
+
+		o := &ownership{
+			owner:   r.options.OwnerID,
+			service: svc.ServiceName,
+		}
+
+		msg.RemoveRRset([]dns.RR{&dns.ANY{Hdr: dns.RR_Header{Name: ownershipName(key), Rrtype: dns.TypeTXT}}})
+		msg.Insert([]dns.RR{o.record(key)})
+
+	}
+
+	_, err = r.exchange(ctx, msg)
+
+	return err
+
+}
+
+// SyncServices implements servicemesh.DomainRegistry, registers the DNS
+// records of the services and, when the services are all the discovered
+// ones, collects the owned records of the services which are gone
+func (r *DomainRegistry) SyncServices(ctx context.Context, services []*sdapi.Service, complete bool) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	registered := make(map[recordKey]bool)
+
+	r.discovered = make(map[string]bool)
+	r.complete = complete
+
+	defer func() {
+		r.discovered = nil
+	}()
+
+	for _, svc := range services {
+		r.discovered[svc.ServiceName] = true
+	}
+
+	for _, svc := range services {
+
+		for _, record := range svc.DnsRecords {
+
+			zone := dns.Fqdn(strings.Trim(record.Zone, "."))
+
+			key := recordKey{
+				zone:       strings.ToLower(zone),
+				fqdn:       strings.ToLower(dns.Fqdn(strings.TrimRight(record.RecordName, ".") + "." + zone)),
+				recordType: recordType(record),
+			}
+
+			// Registered even when it fails below, records are only
+			// collected when their service is gone
+			registered[key] = true
+
+			r.gcZones[key.zone] = true
+
+			err := r.registerDnsRecord(ctx, svc, record, key)
+			if err != nil {
+				r.logger.Errorw("Unable to register DNS record", "service_name", svc.ServiceName, "record_name", record.RecordName, "zone", record.Zone, "error", err)
+			}
+
+		}
+
+	}
+
+	if !r.options.GCEnable {
+		return
+	}
+
+	if !complete {
+		r.logger.Warnw("Discovery is incomplete, skipping DNS records garbage collection")
+		return
+	}
+
+	r.collectGarbage(ctx, registered)
+
+}
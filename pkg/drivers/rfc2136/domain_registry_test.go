@@ -0,0 +1,478 @@
+package rfc2136driver
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	sdapi "github.com/upper-institute/hike/proto/api/service-discovery"
+	"go.uber.org/zap"
+)
+
+const (
+	testZone       = "example.org."
+	testTSIGKey    = "hike."
+	testTSIGSecret = "c2VjcmV0c2VjcmV0c2VjcmV0c2VjcmV0"
+)
+
+// testNameserver is an authoritative nameserver of a single zone accepting
+// dynamic updates and zone transfers signed with the TSIG key
+type testNameserver struct {
+	mu      sync.Mutex
+	records []dns.RR
+	updates int
+	refused int
+
+	addr string
+}
+
+func newTestNameserver(t *testing.T) *testNameserver {
+
+	soa, err := dns.NewRR(testZone + " 300 IN SOA ns." + testZone + " admin." + testZone + " 1 3600 600 86400 60")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ns := &testNameserver{
+		records: []dns.RR{soa},
+		addr:    listener.Addr().String(),
+	}
+
+	started := make(chan struct{})
+
+	server := &dns.Server{
+		Listener:          listener,
+		Handler:           ns,
+		TsigSecret:        map[string]string{testTSIGKey: testTSIGSecret},
+		MsgAcceptFunc:     func(dns.Header) dns.MsgAcceptAction { return dns.MsgAccept },
+		NotifyStartedFunc: func() { close(started) },
+	}
+
+	go server.ActivateAndServe()
+
+	t.Cleanup(func() { server.Shutdown() })
+
+	<-started
+
+	return ns
+
+}
+
+func (ns *testNameserver) ServeDNS(w dns.ResponseWriter, req *dns.Msg) {
+
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+
+	res := new(dns.Msg)
+	res.SetReply(req)
+
+	tsig := req.IsTsig()
+
+	if (tsig == nil && req.Opcode == dns.OpcodeUpdate) || (tsig != nil && w.TsigStatus() != nil) {
+
+		ns.refused++
+
+		res.Rcode = dns.RcodeRefused
+		w.WriteMsg(res)
+
+		return
+
+	}
+
+	if tsig != nil {
+		res.SetTsig(tsig.Hdr.Name, tsig.Algorithm, tsigFudge, time.Now().Unix())
+	}
+
+	switch {
+
+	case req.Opcode == dns.OpcodeUpdate:
+		ns.update(req.Ns)
+
+	case req.Question[0].Qtype == dns.TypeAXFR:
+		res.Answer = append(append(res.Answer, ns.records...), ns.records[0])
+
+	default:
+		res.Answer = ns.find(req.Question[0].Name, req.Question[0].Qtype)
+
+	}
+
+	w.WriteMsg(res)
+
+}
+
+func (ns *testNameserver) update(changes []dns.RR) {
+
+	ns.updates++
+
+	for _, change := range changes {
+
+		header := change.Header()
+
+		kept := []dns.RR{}
+
+		for _, rr := range ns.records {
+
+			sameSet := strings.EqualFold(rr.Header().Name, header.Name) && rr.Header().Rrtype == header.Rrtype
+
+			switch {
+
+			case header.Class == dns.ClassANY && sameSet:
+
+			case header.Class == dns.ClassNONE && sameSet && dns.IsDuplicate(rr, inet(change)):
+
+			default:
+				kept = append(kept, rr)
+
+			}
+
+		}
+
+		if header.Class == dns.ClassINET {
+			kept = append(kept, change)
+		}
+
+		ns.records = kept
+
+	}
+
+}
+
+func inet(rr dns.RR) dns.RR {
+
+	rr = dns.Copy(rr)
+	rr.Header().Class = dns.ClassINET
+
+	return rr
+
+}
+
+func (ns *testNameserver) find(name string, recordType uint16) []dns.RR {
+
+	records := []dns.RR{}
+
+	for _, rr := range ns.records {
+		if strings.EqualFold(rr.Header().Name, name) && rr.Header().Rrtype == recordType {
+			records = append(records, rr)
+		}
+	}
+
+	return records
+
+}
+
+func (ns *testNameserver) values(name string, recordType uint16) []string {
+
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+
+	values := []string{}
+
+	for _, rr := range ns.find(name, recordType) {
+		values = append(values, strings.TrimPrefix(rr.String(), rr.Header().String()))
+	}
+
+	return values
+
+}
+
+func (ns *testNameserver) counts() (int, int) {
+
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+
+	return ns.updates, ns.refused
+
+}
+
+func (ns *testNameserver) add(t *testing.T, record string) {
+
+	rr, err := dns.NewRR(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+
+	ns.records = append(ns.records, rr)
+
+}
+
+func newTestDomainRegistry(t *testing.T, ns *testNameserver, options *DomainRegistryOptions) *DomainRegistry {
+
+	options.Nameserver = ns.addr
+	options.Net = "tcp"
+	options.TSIGKeyName = testTSIGKey
+
+	if len(options.TSIGSecret) == 0 {
+		options.TSIGSecret = testTSIGSecret
+	}
+
+	r, err := options.NewDomainRegistry(zap.NewNop().Sugar())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return r
+
+}
+
+func testService(name string, values ...string) *sdapi.Service {
+
+	return &sdapi.Service{
+		ServiceName: name,
+		DnsRecords: []*sdapi.DnsRecord{
+			{Zone: "example.org", RecordName: name, Type: sdapi.DnsRecordType_DRT_A, Values: values},
+			{Zone: "example.org", RecordName: "www." + name, CnameValue: name + ".example.org"},
+		},
+	}
+
+}
+
+func TestRegister(t *testing.T) {
+
+	ctx := context.Background()
+	ns := newTestNameserver(t)
+
+	t.Run("wrong TSIG secret", func(t *testing.T) {
+
+		r := newTestDomainRegistry(t, ns, &DomainRegistryOptions{TSIGSecret: "d3Jvbmd3cm9uZw=="})
+
+		r.SyncServices(ctx, []*sdapi.Service{testService("api", "10.0.0.1")}, true)
+
+		if _, refused := ns.counts(); refused == 0 || len(ns.values("api.example.org.", dns.TypeA)) != 0 {
+			t.Fatal("update accepted with a wrong TSIG secret")
+		}
+
+	})
+
+	r := newTestDomainRegistry(t, ns, &DomainRegistryOptions{})
+
+	t.Run("register", func(t *testing.T) {
+
+		r.SyncServices(ctx, []*sdapi.Service{testService("api", "10.0.0.2", "10.0.0.1")}, true)
+
+		if values := ns.values("api.example.org.", dns.TypeA); len(values) != 2 {
+			t.Fatalf("unexpected A records %v", values)
+		}
+
+		if values := ns.values("www.api.example.org.", dns.TypeCNAME); len(values) != 1 || values[0] != "api.example.org." {
+			t.Fatalf("unexpected CNAME records %v", values)
+		}
+
+		if values := ns.values("_hike-a.api.example.org.", dns.TypeTXT); len(values) != 1 || values[0] != `"heritage=hike,owner=hike,service=api"` {
+			t.Fatalf("unexpected ownership records %v", values)
+		}
+
+	})
+
+	t.Run("up to date", func(t *testing.T) {
+
+		before, _ := ns.counts()
+
+		r.SyncServices(ctx, []*sdapi.Service{testService("api", "10.0.0.1", "10.0.0.2")}, true)
+
+		if after, _ := ns.counts(); after != before {
+			t.Fatalf("%d updates of up to date records", after-before)
+		}
+
+	})
+
+	t.Run("replace", func(t *testing.T) {
+
+		r.SyncServices(ctx, []*sdapi.Service{testService("api", "10.0.0.3")}, true)
+
+		if values := ns.values("api.example.org.", dns.TypeA); len(values) != 1 || values[0] != "10.0.0.3" {
+			t.Fatalf("unexpected A records %v", values)
+		}
+
+	})
+
+	t.Run("unsupported routing policy", func(t *testing.T) {
+
+		svc := testService("weighted", "10.0.0.4")
+		svc.DnsRecords[0].RoutingPolicy = &sdapi.DnsRecord_Weighted{Weighted: &sdapi.DnsWeightedRouting{Weight: 1}}
+
+		r.SyncServices(ctx, []*sdapi.Service{testService("api", "10.0.0.3"), svc}, true)
+
+		if len(ns.values("weighted.example.org.", dns.TypeA)) != 0 {
+			t.Fatal("registered record with a routing policy")
+		}
+
+	})
+
+}
+
+func TestOwnership(t *testing.T) {
+
+	ctx := context.Background()
+	ns := newTestNameserver(t)
+
+	ns.add(t, "manual.example.org. 60 IN A 192.0.2.1")
+	ns.add(t, "other.example.org. 60 IN A 192.0.2.2")
+	ns.add(t, `_hike-a.other.example.org. 300 IN TXT "heritage=hike,owner=other,service=other"`)
+
+	r := newTestDomainRegistry(t, ns, &DomainRegistryOptions{})
+
+	t.Run("not owned", func(t *testing.T) {
+
+		r.SyncServices(ctx, []*sdapi.Service{testService("manual", "10.0.0.1"), testService("other", "10.0.0.2")}, true)
+
+		if values := ns.values("manual.example.org.", dns.TypeA); len(values) != 1 || values[0] != "192.0.2.1" {
+			t.Fatalf("record without owner changed %v", values)
+		}
+
+		if values := ns.values("other.example.org.", dns.TypeA); len(values) != 1 || values[0] != "192.0.2.2" {
+			t.Fatalf("record of other instance changed %v", values)
+		}
+
+	})
+
+	t.Run("owned by other service", func(t *testing.T) {
+
+		r.SyncServices(ctx, []*sdapi.Service{testService("api", "10.0.0.3")}, true)
+
+		svc := testService("api", "10.0.0.4")
+		svc.ServiceName = "web"
+
+		r.SyncServices(ctx, []*sdapi.Service{testService("api", "10.0.0.3"), svc}, true)
+
+		if values := ns.values("api.example.org.", dns.TypeA); len(values) != 1 || values[0] != "10.0.0.3" {
+			t.Fatalf("record of other service changed %v", values)
+		}
+
+	})
+
+	t.Run("take over from service no longer discovered", func(t *testing.T) {
+
+		svc := testService("api", "10.0.0.4")
+		svc.ServiceName = "web"
+
+		r.SyncServices(ctx, []*sdapi.Service{svc}, false)
+
+		if values := ns.values("api.example.org.", dns.TypeA); values[0] != "10.0.0.3" {
+			t.Fatalf("record taken over on incomplete discovery %v", values)
+		}
+
+		r.SyncServices(ctx, []*sdapi.Service{svc}, true)
+
+		if values := ns.values("api.example.org.", dns.TypeA); len(values) != 1 || values[0] != "10.0.0.4" {
+			t.Fatalf("record not taken over %v", values)
+		}
+
+		if values := ns.values("_hike-a.api.example.org.", dns.TypeTXT); len(values) != 1 || !strings.Contains(values[0], "service=web") {
+			t.Fatalf("unexpected ownership records %v", values)
+		}
+
+	})
+
+	t.Run("adopt", func(t *testing.T) {
+
+		r := newTestDomainRegistry(t, ns, &DomainRegistryOptions{AdoptRecords: true})
+
+		r.SyncServices(ctx, []*sdapi.Service{testService("manual", "10.0.0.1"), testService("other", "10.0.0.2")}, true)
+
+		if values := ns.values("manual.example.org.", dns.TypeA); len(values) != 1 || values[0] != "10.0.0.1" {
+			t.Fatalf("record without owner not adopted %v", values)
+		}
+
+		if values := ns.values("other.example.org.", dns.TypeA); len(values) != 1 || values[0] != "192.0.2.2" {
+			t.Fatalf("record of other instance adopted %v", values)
+		}
+
+	})
+
+}
+
+func TestGarbageCollection(t *testing.T) {
+
+	ctx := context.Background()
+	ns := newTestNameserver(t)
+
+	r := newTestDomainRegistry(t, ns, &DomainRegistryOptions{GCEnable: true, GCGracePeriod: time.Hour})
+
+	r.SyncServices(ctx, []*sdapi.Service{testService("api", "10.0.0.1"), testService("web", "10.0.0.2")}, true)
+
+	t.Run("incomplete discovery", func(t *testing.T) {
+
+		r.SyncServices(ctx, []*sdapi.Service{testService("api", "10.0.0.1")}, false)
+
+		if len(r.missingSince) != 0 {
+			t.Fatal("garbage collected on incomplete discovery")
+		}
+
+	})
+
+	t.Run("grace period", func(t *testing.T) {
+
+		r.SyncServices(ctx, []*sdapi.Service{testService("api", "10.0.0.1")}, true)
+
+		if len(r.missingSince) != 2 || len(ns.values("web.example.org.", dns.TypeA)) != 1 {
+			t.Fatalf("unexpected records missing %v", r.missingSince)
+		}
+
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+
+		r.options.GCGracePeriod = 0
+		r.options.GCDryRun = true
+
+		r.SyncServices(ctx, []*sdapi.Service{testService("api", "10.0.0.1")}, true)
+
+		if len(ns.values("web.example.org.", dns.TypeA)) != 1 {
+			t.Fatal("deleted record on dry run")
+		}
+
+	})
+
+	t.Run("delete", func(t *testing.T) {
+
+		r.options.GCDryRun = false
+
+		r.SyncServices(ctx, []*sdapi.Service{testService("api", "10.0.0.1")}, true)
+
+		if len(ns.values("web.example.org.", dns.TypeA)) != 0 || len(ns.values("www.web.example.org.", dns.TypeCNAME)) != 0 {
+			t.Fatal("owned records not deleted")
+		}
+
+		if len(ns.values("_hike-a.web.example.org.", dns.TypeTXT)) != 0 || len(ns.values("_hike-cname.www.web.example.org.", dns.TypeTXT)) != 0 {
+			t.Fatal("ownership records not deleted")
+		}
+
+		if len(ns.values("api.example.org.", dns.TypeA)) != 1 {
+			t.Fatal("registered record deleted")
+		}
+
+	})
+
+	t.Run("restart", func(t *testing.T) {
+
+		ns.add(t, "manual.example.org. 60 IN A 192.0.2.1")
+		ns.add(t, `_hike-a.other.example.org. 300 IN TXT "heritage=hike,owner=other,service=other"`)
+		ns.add(t, "other.example.org. 60 IN A 192.0.2.2")
+
+		r := newTestDomainRegistry(t, ns, &DomainRegistryOptions{GCEnable: true, GCZones: []string{"example.org"}})
+
+		r.SyncServices(ctx, []*sdapi.Service{}, true)
+
+		if len(ns.values("api.example.org.", dns.TypeA)) != 0 {
+			t.Fatal("owned record not deleted after restart")
+		}
+
+		if len(ns.values("manual.example.org.", dns.TypeA)) != 1 || len(ns.values("other.example.org.", dns.TypeA)) != 1 {
+			t.Fatal("record not owned deleted")
+		}
+
+	})
+
+}
@@ -0,0 +1,356 @@
+package rfc2136driver
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultOwnerID       = "hike"
+	DefaultGCGracePeriod = time.Hour
+
+	ownershipNamePrefix = "_hike-"
+	ownershipWildcard   = "_wildcard"
+	ownershipHeritage   = "hike"
+	ownershipTTL        = 300
+)
+
+var RecordNotOwnedErr = errors.New("DNS record exists and is not owned by this hike instance and service")
+
+// ownership is the value of the ownership TXT record of a record name and
+// type, owned by a hike instance and service name
+type ownership struct {
+	owner   string
+	service string
+}
+
+func (o *ownership) value() string {
+
+	return strings.Join([]string{
+		"heritage=" + ownershipHeritage,
+		"owner=" + o.owner,
+		"service=" + o.service,
+	}, ",")
+
+}
+
+func (o *ownership) record(key recordKey) dns.RR {
+
+	return &dns.TXT{
+		Hdr: dns.RR_Header{
+			Name:   ownershipName(key),
+			Rrtype: dns.TypeTXT,
+			Class:  dns.ClassINET,
+			Ttl:    ownershipTTL,
+		},
+		Txt: []string{o.value()},
+	}
+
+}
+
+func parseOwnership(txt []string) (*ownership, bool) {
+
+	fields := make(map[string]string)
+
+	for _, field := range strings.Split(strings.Join(txt, ""), ",") {
+
+		kv := strings.SplitN(field, "=", 2)
+
+		if len(kv) == 2 {
+			fields[kv[0]] = kv[1]
+		}
+
+	}
+
+	if fields["heritage"] != ownershipHeritage {
+		return nil, false
+	}
+
+	return &ownership{
+		owner:   fields["owner"],
+		service: fields["service"],
+	}, true
+
+}
+
+// ownershipName is the TXT record name holding the owner of the record set,
+// e.g. _hike-a.api.example.com., a CNAME can't share its name with other
+// records
+func ownershipName(key recordKey) string {
+
+	fqdn := key.fqdn
+
+	if strings.HasPrefix(fqdn, "*.") {
+		fqdn = ownershipWildcard + fqdn[1:]
+	}
+
+	return ownershipNamePrefix + strings.ToLower(dns.TypeToString[key.recordType]) + "." + fqdn
+
+}
+
+func parseOwnershipName(name string) (string, uint16, bool) {
+
+	if !strings.HasPrefix(name, ownershipNamePrefix) {
+		return "", 0, false
+	}
+
+	labels := strings.SplitN(strings.TrimPrefix(name, ownershipNamePrefix), ".", 2)
+
+	if len(labels) != 2 {
+		return "", 0, false
+	}
+
+	recordType, ok := dns.StringToType[strings.ToUpper(labels[0])]
+	if !ok {
+		return "", 0, false
+	}
+
+	fqdn := labels[1]
+
+	if strings.HasPrefix(fqdn, ownershipWildcard+".") {
+		fqdn = "*" + strings.TrimPrefix(fqdn, ownershipWildcard)
+	}
+
+	return fqdn, recordType, true
+
+}
+
+// lookupOwnership returns the owner of the record set, nil when not owned
+func (r *DomainRegistry) lookupOwnership(ctx context.Context, key recordKey) (*ownership, error) {
+
+	records, err := r.lookup(ctx, ownershipName(key), dns.TypeTXT)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rr := range records {
+		if o, ok := parseOwnership(rr.(*dns.TXT).Txt); ok {
+			return o, nil
+		}
+	}
+
+	return nil, nil
+
+}
+
+// checkOwner fails when the record set can't be changed by the service,
+// returns whether its ownership must be claimed
+func (r *DomainRegistry) checkOwner(logger *zap.SugaredLogger, o *ownership, service string, exists bool) (bool, error) {
+
+	switch {
+
+	case o != nil && o.owner == r.options.OwnerID && o.service == service:
+		return false, nil
+
+	// Services can take over records of this instance when the owner
+	// service is no longer discovered, e.g. renamed services
+	case o != nil && o.owner == r.options.OwnerID && r.complete && !r.discovered[o.service]:
+		logger.Infow("Taking over record of a service no longer discovered", "owner_service", o.service)
+		return true, nil
+
+	case o == nil && !exists:
+		return true, nil
+
+	case o == nil && r.options.AdoptRecords:
+		logger.Infow("Adopting record without owner")
+		return true, nil
+
+	case o != nil:
+		logger.Warnw("Record owned by other hike instance or service, skipping", "owner_id", o.owner, "owner_service", o.service)
+
+	}
+
+	return false, RecordNotOwnedErr
+
+}
+
+// listZoneOwnerships transfers the zone (AXFR) to find the records owned by
+// this instance
+func (r *DomainRegistry) listZoneOwnerships(ctx context.Context, zone string) (map[recordKey]*ownership, error) {
+
+	network := "tcp"
+
+	if r.client.Net == "tcp-tls" {
+		network = r.client.Net
+	}
+
+	conn, err := (&dns.Client{Net: network, Timeout: r.client.Timeout}).DialContext(ctx, r.options.Nameserver)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := new(dns.Msg)
+	msg.SetAxfr(zone)
+
+	r.sign(msg)
+
+	transfer := &dns.Transfer{
+		Conn:         conn,
+		ReadTimeout:  r.client.Timeout,
+		WriteTimeout: r.client.Timeout,
+		TsigSecret:   r.client.TsigSecret,
+	}
+
+	envelopes, err := transfer.In(msg, r.options.Nameserver)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	ownerships := make(map[recordKey]*ownership)
+
+	for envelope := range envelopes {
+
+		if envelope.Error != nil {
+			err = envelope.Error
+			continue
+		}
+
+		for _, rr := range envelope.RR {
+
+			txt, ok := rr.(*dns.TXT)
+			if !ok {
+				continue
+			}
+
+			fqdn, recordType, ok := parseOwnershipName(strings.ToLower(txt.Hdr.Name))
+			if !ok {
+				continue
+			}
+
+			if o, ok := parseOwnership(txt.Txt); ok && o.owner == r.options.OwnerID {
+				ownerships[recordKey{zone: zone, fqdn: fqdn, recordType: recordType}] = o
+			}
+
+		}
+
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ownerships, nil
+
+}
+
+// deleteOwnedRecord deletes the record set and its ownership in one update
+func (r *DomainRegistry) deleteOwnedRecord(ctx context.Context, key recordKey, o *ownership) error {
+
+	msg := new(dns.Msg)
+	msg.SetUpdate(key.zone)
+	msg.RemoveRRset([]dns.RR{&dns.ANY{Hdr: dns.RR_Header{Name: key.fqdn, Rrtype: key.recordType}}})
+	msg.Remove([]dns.RR{o.record(key)})
+
+	_, err := r.exchange(ctx, msg)
+
+	return err
+
+}
+
+// collectGarbage deletes the records owned by this instance which are no
+// longer registered by any service, once missing for longer than the grace
+// period
+func (r *DomainRegistry) collectGarbage(ctx context.Context, registered map[recordKey]bool) {
+
+	zones := make(map[string]bool)
+
+	for _, zone := range r.options.GCZones {
+		zones[strings.ToLower(dns.Fqdn(strings.Trim(zone, ".")))] = true
+	}
+
+	for zone := range r.gcZones {
+		zones[zone] = true
+	}
+
+	now := time.Now()
+
+	pending := 0
+	deleted := 0
+
+	for zone := range zones {
+
+		logger := r.logger.With("zone", zone)
+
+		ownerships, err := r.listZoneOwnerships(ctx, zone)
+		if err != nil {
+			logger.Errorw("Unable to transfer zone to list DNS records ownership, skipping garbage collection", "error", err)
+			continue
+		}
+
+		// Zones of registered records are added again by the next sync
+		if len(ownerships) == 0 {
+			delete(r.gcZones, zone)
+			continue
+		}
+
+		for key, o := range ownerships {
+
+			if registered[key] {
+				delete(r.missingSince, key)
+				continue
+			}
+
+			missingSince, ok := r.missingSince[key]
+
+			if !ok {
+				missingSince = now
+				r.missingSince[key] = now
+			}
+
+			recordLogger := logger.With(
+				"record_fqdn", key.fqdn,
+				"record_type", dns.TypeToString[key.recordType],
+				"service_name", o.service,
+				"missing_since", missingSince,
+			)
+
+			if now.Sub(missingSince) < r.options.GCGracePeriod {
+
+				recordLogger.Infow("Owned DNS record no longer registered, deleting after grace period", "delete_at", missingSince.Add(r.options.GCGracePeriod))
+
+				pending++
+
+				continue
+
+			}
+
+			if r.options.GCDryRun {
+
+				recordLogger.Infow("Owned DNS record would be deleted (dry run)")
+
+				pending++
+
+				continue
+
+			}
+
+			recordLogger.Infow("Deleting owned DNS record no longer registered (dynamic update)")
+
+			if err := r.deleteOwnedRecord(ctx, key, o); err != nil {
+				recordLogger.Errorw("Unable to delete owned DNS record", "error", err)
+				continue
+			}
+
+			delete(r.missingSince, key)
+
+			deleted++
+
+		}
+
+	}
+
+	r.logger.Infow(
+		"DNS records garbage collection report",
+		"pending_count", pending,
+		"deleted_count", deleted,
+		"grace_period", r.options.GCGracePeriod,
+		"dry_run", r.options.GCDryRun,
+	)
+
+}
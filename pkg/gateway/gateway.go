@@ -0,0 +1,303 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	ContentType = "application/json"
+
+	// Server streaming responses are written as one JSON message per line
+	StreamContentType = "application/x-ndjson"
+
+	MaxRequestBodySize = 32 << 20
+)
+
+// Prefixes of the methods without side effects, the only ones served for GET
+// requests so links or prefetches can't change parameters
+var readOnlyMethodPrefixes = []string{"Get", "List", "Watch"}
+
+var (
+	marshalOptions   = protojson.MarshalOptions{}
+	unmarshalOptions = protojson.UnmarshalOptions{}
+)
+
+// Gateway exposes gRPC services as JSON over HTTP, every method is served
+// at the same path used by gRPC (/package.Service/Method) and goes through
+// the same interceptors as the gRPC server.
+type Gateway struct {
+	mux *http.ServeMux
+
+	unaryInterceptor  grpc.UnaryServerInterceptor
+	streamInterceptor grpc.StreamServerInterceptor
+
+	logger *zap.SugaredLogger
+}
+
+func NewGateway(
+	mux *http.ServeMux,
+	unaryInterceptor grpc.UnaryServerInterceptor,
+	streamInterceptor grpc.StreamServerInterceptor,
+	logger *zap.SugaredLogger,
+) *Gateway {
+	return &Gateway{
+		mux:               mux,
+		unaryInterceptor:  unaryInterceptor,
+		streamInterceptor: streamInterceptor,
+		logger:            logger.With("part", "gateway"),
+	}
+}
+
+// RegisterService implements grpc.ServiceRegistrar, so generated
+// Register*Server functions can be used with the gateway.
+func (g *Gateway) RegisterService(desc *grpc.ServiceDesc, impl interface{}) {
+
+	for i := range desc.Methods {
+
+		method := desc.Methods[i]
+		fullMethod := fmt.Sprintf("/%s/%s", desc.ServiceName, method.MethodName)
+
+		g.logger.Debugw("Register unary method", "method", fullMethod)
+
+		readOnly := isReadOnlyMethod(method.MethodName)
+
+		g.mux.HandleFunc(fullMethod, func(w http.ResponseWriter, r *http.Request) {
+			g.serveUnary(w, r, impl, readOnly, method.Handler)
+		})
+
+	}
+
+	for i := range desc.Streams {
+
+		stream := desc.Streams[i]
+		fullMethod := fmt.Sprintf("/%s/%s", desc.ServiceName, stream.StreamName)
+
+		if stream.ClientStreams {
+			g.logger.Debugw("Ignoring client streaming method", "method", fullMethod)
+			continue
+		}
+
+		g.logger.Debugw("Register server streaming method", "method", fullMethod)
+
+		readOnly := isReadOnlyMethod(stream.StreamName)
+
+		g.mux.HandleFunc(fullMethod, func(w http.ResponseWriter, r *http.Request) {
+			g.serveStream(w, r, impl, fullMethod, readOnly, stream)
+		})
+
+	}
+
+}
+
+func (g *Gateway) newContext(r *http.Request) context.Context {
+
+	ctx := r.Context()
+
+	md := make(metadata.MD)
+
+	for key, values := range r.Header {
+		md.Append(key, values...)
+	}
+
+	ctx = metadata.NewIncomingContext(ctx, md)
+
+	p := &peer.Peer{}
+
+	if addr, err := net.ResolveTCPAddr("tcp", r.RemoteAddr); err == nil {
+		p.Addr = addr
+	}
+
+	if r.TLS != nil {
+		p.AuthInfo = credentials.TLSInfo{
+			State: *r.TLS,
+			CommonAuthInfo: credentials.CommonAuthInfo{
+				SecurityLevel: credentials.PrivacyAndIntegrity,
+			},
+		}
+	}
+
+	return peer.NewContext(ctx, p)
+
+}
+
+func (g *Gateway) decoder(r *http.Request) func(interface{}) error {
+
+	return func(v interface{}) error {
+
+		msg, ok := v.(proto.Message)
+		if !ok {
+			return status.Errorf(codes.Internal, "Unable to decode request of type %T", v)
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, MaxRequestBodySize))
+		if err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		if len(body) > 0 {
+
+			err = unmarshalOptions.Unmarshal(body, msg)
+			if err != nil {
+				return status.Error(codes.InvalidArgument, err.Error())
+			}
+
+		}
+
+		err = populateFromQuery(msg, r.URL.Query())
+		if err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		return nil
+
+	}
+
+}
+
+func isReadOnlyMethod(methodName string) bool {
+
+	for _, prefix := range readOnlyMethodPrefixes {
+		if strings.HasPrefix(methodName, prefix) {
+			return true
+		}
+	}
+
+	return false
+
+}
+
+// allowMethod accepts POST for every method and GET only for read-only ones
+func (g *Gateway) allowMethod(w http.ResponseWriter, r *http.Request, readOnly bool) bool {
+
+	if r.Method == http.MethodPost || (readOnly && r.Method == http.MethodGet) {
+		return true
+	}
+
+	if readOnly {
+		w.Header().Set("Allow", "GET, POST")
+	} else {
+		w.Header().Set("Allow", "POST")
+	}
+
+	g.writeStatus(w, http.StatusMethodNotAllowed, status.Newf(codes.Unimplemented, "Method not allowed: %s", r.Method))
+
+	return false
+
+}
+
+func (g *Gateway) serveUnary(
+	w http.ResponseWriter,
+	r *http.Request,
+	impl interface{},
+	readOnly bool,
+	handler func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error),
+) {
+
+	if !g.allowMethod(w, r, readOnly) {
+		return
+	}
+
+	res, err := handler(impl, g.newContext(r), g.decoder(r), g.unaryInterceptor)
+	if err != nil {
+		g.writeError(w, err)
+		return
+	}
+
+	msg, ok := res.(proto.Message)
+	if !ok {
+		g.writeError(w, status.Errorf(codes.Internal, "Unable to encode response of type %T", res))
+		return
+	}
+
+	body, err := marshalOptions.Marshal(msg)
+	if err != nil {
+		g.writeError(w, status.Error(codes.Internal, err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+
+}
+
+func (g *Gateway) serveStream(w http.ResponseWriter, r *http.Request, impl interface{}, fullMethod string, readOnly bool, desc grpc.StreamDesc) {
+
+	if !g.allowMethod(w, r, readOnly) {
+		return
+	}
+
+	stream := &serverStream{
+		ctx:    g.newContext(r),
+		w:      w,
+		decode: g.decoder(r),
+	}
+
+	info := &grpc.StreamServerInfo{
+		FullMethod:     fullMethod,
+		IsClientStream: desc.ClientStreams,
+		IsServerStream: desc.ServerStreams,
+	}
+
+	var err error
+
+	if g.streamInterceptor == nil {
+		err = desc.Handler(impl, stream)
+	} else {
+		err = g.streamInterceptor(impl, stream, info, desc.Handler)
+	}
+
+	if err == nil {
+		return
+	}
+
+	if !stream.wroteHeader {
+		g.writeError(w, err)
+		return
+	}
+
+	// Headers are already sent, the error is the last line of the stream
+	body, _ := marshalOptions.Marshal(status.Convert(err).Proto())
+
+	w.Write(body)
+	w.Write([]byte("\n"))
+
+}
+
+func (g *Gateway) writeError(w http.ResponseWriter, err error) {
+
+	st := status.Convert(err)
+
+	g.writeStatus(w, HTTPStatusFromCode(st.Code()), st)
+
+}
+
+func (g *Gateway) writeStatus(w http.ResponseWriter, httpStatus int, st *status.Status) {
+
+	g.logger.Debugw("Gateway request failed", "code", st.Code(), "message", st.Message())
+
+	body, marshalErr := marshalOptions.Marshal(st.Proto())
+	if marshalErr != nil {
+		body = []byte(`{"code":13,"message":"Unable to encode error"}`)
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(httpStatus)
+	w.Write(body)
+
+}
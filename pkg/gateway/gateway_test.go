@@ -0,0 +1,74 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	paramapi "github.com/upper-institute/hike/proto/api/parameter"
+	"go.uber.org/zap"
+)
+
+type testParameterServer struct {
+	paramapi.UnimplementedParameterServiceServer
+
+	deleted []string
+}
+
+func (s *testParameterServer) Get(ctx context.Context, req *paramapi.GetRequest) (*paramapi.GetResponse, error) {
+	return &paramapi.GetResponse{Parameter: &paramapi.Parameter{Key: req.Key}}, nil
+}
+
+func (s *testParameterServer) Delete(ctx context.Context, req *paramapi.DeleteRequest) (*paramapi.DeleteResponse, error) {
+
+	s.deleted = append(s.deleted, req.Key)
+
+	return &paramapi.DeleteResponse{}, nil
+
+}
+
+func TestAllowMethod(t *testing.T) {
+
+	mux := http.NewServeMux()
+	server := &testParameterServer{}
+
+	paramapi.RegisterParameterServiceServer(NewGateway(mux, nil, nil, zap.NewNop().Sugar()), server)
+
+	tests := []struct {
+		method string
+		path   string
+		code   int
+		allow  string
+	}{
+		{http.MethodGet, "/opscontrol.api.parameter.ParameterService/Get?key=A", http.StatusOK, ""},
+		{http.MethodPost, "/opscontrol.api.parameter.ParameterService/Get", http.StatusOK, ""},
+		{http.MethodGet, "/opscontrol.api.parameter.ParameterService/Delete?key=A", http.StatusMethodNotAllowed, "POST"},
+		{http.MethodPut, "/opscontrol.api.parameter.ParameterService/Get", http.StatusMethodNotAllowed, "GET, POST"},
+		{http.MethodPost, "/opscontrol.api.parameter.ParameterService/Delete", http.StatusOK, ""},
+	}
+
+	for _, test := range tests {
+
+		body := strings.NewReader("")
+
+		if test.method == http.MethodPost {
+			body = strings.NewReader(`{"key": "B"}`)
+		}
+
+		rec := httptest.NewRecorder()
+
+		mux.ServeHTTP(rec, httptest.NewRequest(test.method, test.path, body))
+
+		if rec.Code != test.code || rec.Header().Get("Allow") != test.allow {
+			t.Errorf("%s %s: expected %d (allow %q), got %d (allow %q)", test.method, test.path, test.code, test.allow, rec.Code, rec.Header().Get("Allow"))
+		}
+
+	}
+
+	if len(server.deleted) != 1 || server.deleted[0] != "B" {
+		t.Fatalf("unexpected deleted keys %v", server.deleted)
+	}
+
+}
@@ -0,0 +1,91 @@
+package gateway
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// populateFromQuery sets top level scalar fields of the request from the
+// URL query, matching the proto or JSON field name, so GET requests don't
+// need a body (e.g. ?source_uri=/prod/app&load_files=true)
+func populateFromQuery(msg proto.Message, query url.Values) error {
+
+	fields := msg.ProtoReflect().Descriptor().Fields()
+
+	for name, values := range query {
+
+		if len(values) == 0 {
+			continue
+		}
+
+		field := fields.ByName(protoreflect.Name(name))
+		if field == nil {
+			field = fields.ByJSONName(name)
+		}
+
+		if field == nil {
+			return fmt.Errorf("Unknown query parameter: %s", name)
+		}
+
+		if field.IsList() || field.IsMap() {
+			return fmt.Errorf("Query parameter not supported for repeated field: %s", name)
+		}
+
+		value, err := parseScalar(field, values[len(values)-1])
+		if err != nil {
+			return fmt.Errorf("Invalid query parameter %s: %w", name, err)
+		}
+
+		msg.ProtoReflect().Set(field, value)
+
+	}
+
+	return nil
+
+}
+
+func parseScalar(field protoreflect.FieldDescriptor, str string) (protoreflect.Value, error) {
+
+	switch field.Kind() {
+
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(str), nil
+
+	case protoreflect.BoolKind:
+		v, err := strconv.ParseBool(str)
+		return protoreflect.ValueOfBool(v), err
+
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		v, err := strconv.ParseInt(str, 10, 32)
+		return protoreflect.ValueOfInt32(int32(v)), err
+
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		v, err := strconv.ParseInt(str, 10, 64)
+		return protoreflect.ValueOfInt64(v), err
+
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		v, err := strconv.ParseUint(str, 10, 32)
+		return protoreflect.ValueOfUint32(uint32(v)), err
+
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		v, err := strconv.ParseUint(str, 10, 64)
+		return protoreflect.ValueOfUint64(v), err
+
+	case protoreflect.EnumKind:
+
+		if enumValue := field.Enum().Values().ByName(protoreflect.Name(str)); enumValue != nil {
+			return protoreflect.ValueOfEnum(enumValue.Number()), nil
+		}
+
+		v, err := strconv.ParseInt(str, 10, 32)
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(v)), err
+
+	}
+
+	return protoreflect.Value{}, fmt.Errorf("Unsupported field kind %s", field.Kind())
+
+}
@@ -0,0 +1,52 @@
+package gateway
+
+import (
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+)
+
+// HTTPStatusFromCode maps gRPC codes to HTTP status codes, following
+// google/rpc/code.proto
+func HTTPStatusFromCode(code codes.Code) int {
+
+	switch code {
+
+	case codes.OK:
+		return http.StatusOK
+
+	case codes.Canceled:
+		return 499
+
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+
+	case codes.NotFound:
+		return http.StatusNotFound
+
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+
+	}
+
+	return http.StatusInternalServerError
+
+}
@@ -0,0 +1,99 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+type serverStream struct {
+	ctx context.Context
+	w   http.ResponseWriter
+
+	decode func(interface{}) error
+
+	header      metadata.MD
+	wroteHeader bool
+}
+
+func (s *serverStream) SetHeader(md metadata.MD) error {
+
+	if s.wroteHeader {
+		return status.Error(codes.Internal, "Headers already sent")
+	}
+
+	s.header = metadata.Join(s.header, md)
+
+	return nil
+
+}
+
+func (s *serverStream) SendHeader(md metadata.MD) error {
+
+	err := s.SetHeader(md)
+	if err != nil {
+		return err
+	}
+
+	for key, values := range s.header {
+		for _, value := range values {
+			s.w.Header().Add(key, value)
+		}
+	}
+
+	s.w.Header().Set("Content-Type", StreamContentType)
+	s.w.WriteHeader(http.StatusOK)
+
+	s.wroteHeader = true
+
+	return nil
+
+}
+
+func (s *serverStream) SetTrailer(md metadata.MD) {}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *serverStream) SendMsg(m interface{}) error {
+
+	if !s.wroteHeader {
+
+		err := s.SendHeader(nil)
+		if err != nil {
+			return err
+		}
+
+	}
+
+	msg, ok := m.(proto.Message)
+	if !ok {
+		return status.Errorf(codes.Internal, "Unable to encode response of type %T", m)
+	}
+
+	body, err := marshalOptions.Marshal(msg)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	_, err = s.w.Write(append(body, '\n'))
+	if err != nil {
+		return err
+	}
+
+	if flusher, ok := s.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+
+	return nil
+
+}
+
+func (s *serverStream) RecvMsg(m interface{}) error {
+	return s.decode(m)
+}
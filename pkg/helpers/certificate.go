@@ -0,0 +1,153 @@
+package helpers
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"strings"
+)
+
+var (
+	CertificateNotFoundErr = errors.New("No certificate found in PEM data")
+	PrivateKeyNotFoundErr  = errors.New("No private key found in PEM data")
+	UnknownPrivateKeyErr   = errors.New("Unknown private key type, use RSA, ECDSA or Ed25519")
+)
+
+// SplitPEMBundle splits a PEM bundle (certificate chain followed by the
+// private key, as stored in certificate file parameters) in the chain and
+// the private key
+func SplitPEMBundle(data []byte) ([]byte, []byte, error) {
+
+	chain := bytes.NewBuffer(nil)
+	key := bytes.NewBuffer(nil)
+
+	for {
+
+		block, rest := pem.Decode(data)
+		if block == nil {
+			break
+		}
+
+		data = rest
+
+		switch {
+
+		case block.Type == "CERTIFICATE":
+			pem.Encode(chain, block)
+
+		case strings.HasSuffix(block.Type, "PRIVATE KEY"):
+			pem.Encode(key, block)
+
+		}
+
+	}
+
+	if chain.Len() == 0 {
+		return nil, nil, CertificateNotFoundErr
+	}
+
+	if key.Len() == 0 {
+		return nil, nil, PrivateKeyNotFoundErr
+	}
+
+	return chain.Bytes(), key.Bytes(), nil
+
+}
+
+// ParseLeafCertificate parses the first certificate of PEM data
+func ParseLeafCertificate(data []byte) (*x509.Certificate, error) {
+
+	for {
+
+		block, rest := pem.Decode(data)
+		if block == nil {
+			return nil, CertificateNotFoundErr
+		}
+
+		data = rest
+
+		if block.Type == "CERTIFICATE" {
+			return x509.ParseCertificate(block.Bytes)
+		}
+
+	}
+
+}
+
+// ParseCertificates parses every certificate of PEM data, in order
+func ParseCertificates(data []byte) ([]*x509.Certificate, error) {
+
+	certs := []*x509.Certificate{}
+
+	for {
+
+		block, rest := pem.Decode(data)
+		if block == nil {
+			break
+		}
+
+		data = rest
+
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+
+		certs = append(certs, cert)
+
+	}
+
+	if len(certs) == 0 {
+		return nil, CertificateNotFoundErr
+	}
+
+	return certs, nil
+
+}
+
+// ParsePrivateKey parses the first private key of PEM data, in PKCS #8,
+// PKCS #1 or SEC 1 form
+func ParsePrivateKey(data []byte) (crypto.Signer, error) {
+
+	for {
+
+		block, rest := pem.Decode(data)
+		if block == nil {
+			return nil, PrivateKeyNotFoundErr
+		}
+
+		data = rest
+
+		switch block.Type {
+
+		case "PRIVATE KEY":
+
+			key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+
+			signer, ok := key.(crypto.Signer)
+			if !ok {
+				return nil, UnknownPrivateKeyErr
+			}
+
+			return signer, nil
+
+		case "RSA PRIVATE KEY":
+			return x509.ParsePKCS1PrivateKey(block.Bytes)
+
+		case "EC PRIVATE KEY":
+			return x509.ParseECPrivateKey(block.Bytes)
+
+		}
+
+	}
+
+}
@@ -2,6 +2,7 @@ package helpers
 
 import (
 	"strings"
+	"time"
 
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
@@ -36,3 +37,15 @@ func (f *FlagBinder) BindStringSlice(key string, value []string, usage string) {
 	f.FlagSet.StringSlice(name, value, usage)
 	f.bind(key, name)
 }
+
+func (f *FlagBinder) BindStringArray(key string, value []string, usage string) {
+	name := strings.ReplaceAll(key, ".", "-")
+	f.FlagSet.StringArray(name, value, usage)
+	f.bind(key, name)
+}
+
+func (f *FlagBinder) BindDuration(key string, value time.Duration, usage string) {
+	name := strings.ReplaceAll(key, ".", "-")
+	f.FlagSet.Duration(name, value, usage)
+	f.bind(key, name)
+}
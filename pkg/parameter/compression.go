@@ -0,0 +1,182 @@
+package parameter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"strconv"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	// Query of file parameters with the compression of the stored file, e.g.
+	// file://bucket/mesh.json?compression=zstd#/etc/mesh.json
+	CompressionQuery = "compression"
+
+	GzipCompression = "gzip"
+	ZstdCompression = "zstd"
+
+	// Metadata keys filled on push and load of compressed files
+	OriginalSizeMetadata   = "original_size"
+	CompressedSizeMetadata = "compressed_size"
+
+	// Decompressed files bigger than the limit fail to load, guarding
+	// against decompression bombs
+	MaxDecompressedSize = 256 << 20
+)
+
+func (p *Parameter) GetCompression() string {
+	return p.GetQuery().Get(CompressionQuery)
+}
+
+func compress(compression string, content []byte) ([]byte, error) {
+
+	buf := bytes.NewBuffer(nil)
+
+	var w io.WriteCloser
+
+	switch compression {
+
+	case GzipCompression:
+		w = gzip.NewWriter(buf)
+
+	case ZstdCompression:
+
+		zw, err := zstd.NewWriter(buf)
+		if err != nil {
+			return nil, err
+		}
+
+		w = zw
+
+	default:
+		return nil, UnknownCompressionErr
+
+	}
+
+	_, err := w.Write(content)
+	if err != nil {
+		return nil, err
+	}
+
+	err = w.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+
+}
+
+func decompress(compression string, content []byte) ([]byte, error) {
+
+	switch compression {
+
+	case GzipCompression:
+
+		r, err := gzip.NewReader(bytes.NewReader(content))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+
+		return readDecompressed(r)
+
+	case ZstdCompression:
+
+		r, err := zstd.NewReader(bytes.NewReader(content))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+
+		return readDecompressed(r)
+
+	}
+
+	return nil, UnknownCompressionErr
+
+}
+
+func readDecompressed(r io.Reader) ([]byte, error) {
+
+	content, err := io.ReadAll(io.LimitReader(r, MaxDecompressedSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(content) > MaxDecompressedSize {
+		return nil, DecompressedTooLargeErr
+	}
+
+	return content, nil
+
+}
+
+func (p *Parameter) setSizeMetadata(originalSize int, compressedSize int) {
+
+	p.Metadata.Set(OriginalSizeMetadata, strconv.Itoa(originalSize))
+	p.Metadata.Set(CompressedSizeMetadata, strconv.Itoa(compressedSize))
+
+}
+
+// upload compresses the file before the upload when asked by the URL query,
+// the parameter file keeps the original content
+func (p *Parameter) upload(ctx context.Context) error {
+
+	compression := p.GetCompression()
+
+	if len(compression) == 0 {
+		return p.options.Uploader.Upload(ctx, p)
+	}
+
+	original := p.file
+
+	compressed, err := compress(compression, original.Bytes())
+	if err != nil {
+		return err
+	}
+
+	p.setSizeMetadata(original.Len(), len(compressed))
+
+	p.file = bytes.NewBuffer(compressed)
+
+	defer func() {
+		p.file = original
+	}()
+
+	return p.options.Uploader.Upload(ctx, p)
+
+}
+
+// download decompresses the file after the download when asked by the URL
+// query
+func (p *Parameter) download(ctx context.Context) error {
+
+	err := p.options.Downloader.Download(ctx, p)
+	if err != nil {
+		return err
+	}
+
+	compression := p.GetCompression()
+
+	if len(compression) == 0 {
+		return nil
+	}
+
+	content, err := decompress(compression, p.file.Bytes())
+	if err != nil {
+		return err
+	}
+
+	p.setSizeMetadata(len(content), p.file.Len())
+
+	p.file.Reset()
+
+	_, err = p.file.Write(content)
+
+	return err
+
+}
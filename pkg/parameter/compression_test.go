@@ -0,0 +1,159 @@
+package parameter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"strconv"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+type zeroReader struct{}
+
+func (r zeroReader) Read(p []byte) (int, error) {
+
+	for i := range p {
+		p[i] = 0
+	}
+
+	return len(p), nil
+
+}
+
+func TestCompressRoundTrip(t *testing.T) {
+
+	content := bytes.Repeat([]byte("static_resources:\n  listeners: []\n"), 100)
+
+	for _, compression := range []string{GzipCompression, ZstdCompression} {
+
+		compressed, err := compress(compression, content)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(compressed) >= len(content) {
+			t.Errorf("%s: not compressed, %d bytes from %d", compression, len(compressed), len(content))
+		}
+
+		decompressed, err := decompress(compression, compressed)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(decompressed, content) {
+			t.Errorf("%s: unexpected decompressed content", compression)
+		}
+
+	}
+
+	if _, err := compress("lz4", content); err != UnknownCompressionErr {
+		t.Errorf("expected unknown compression, got %v", err)
+	}
+
+	if _, err := decompress("lz4", content); err != UnknownCompressionErr {
+		t.Errorf("expected unknown compression, got %v", err)
+	}
+
+}
+
+func TestDecompressTooLarge(t *testing.T) {
+
+	if testing.Short() {
+		t.Skip("decompresses over 256MB")
+	}
+
+	tests := []struct {
+		compression string
+		writer      func(w io.Writer) (io.WriteCloser, error)
+	}{
+		{GzipCompression, func(w io.Writer) (io.WriteCloser, error) { return gzip.NewWriter(w), nil }},
+		{ZstdCompression, func(w io.Writer) (io.WriteCloser, error) { return zstd.NewWriter(w) }},
+	}
+
+	for _, test := range tests {
+
+		buf := bytes.NewBuffer(nil)
+
+		w, err := test.writer(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Streamed, the content itself is never held in memory
+		if _, err := io.Copy(w, io.LimitReader(zeroReader{}, MaxDecompressedSize+1)); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := decompress(test.compression, buf.Bytes()); err != DecompressedTooLargeErr {
+			t.Errorf("%s: expected decompressed too large, got %v", test.compression, err)
+		}
+
+	}
+
+}
+
+func TestCompressedFileSizeMetadata(t *testing.T) {
+
+	storage := newMemStorage()
+
+	options := newMemParameterOptions(storage)
+	options.Writer = newMemStore(map[string]string{})
+
+	content := bytes.Repeat([]byte(`{"clusters": []}`), 100)
+
+	for _, compression := range []string{GzipCompression, ZstdCompression} {
+
+		urlStr := "file://bucket/mesh-" + compression + ".json?compression=" + compression + "#/etc/mesh.json"
+
+		pushed, err := options.NewFromURLString("MESH", urlStr)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		pushed.GetFile().Write(content)
+
+		if err := pushed.Push(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		// The parameter keeps the original content, the stored one is compressed
+		stored, _ := storage.get("bucket/mesh-" + compression + ".json")
+
+		if !bytes.Equal(pushed.GetFile().Bytes(), content) || bytes.Equal(stored, content) {
+			t.Fatalf("%s: unexpected pushed content", compression)
+		}
+
+		loaded, err := options.NewFromURLString("MESH", urlStr)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := loaded.Load(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(loaded.GetFile().Bytes(), content) {
+			t.Fatalf("%s: unexpected loaded content", compression)
+		}
+
+		for _, param := range []*Parameter{pushed, loaded} {
+
+			originalSize := param.Metadata.Get(OriginalSizeMetadata)
+			compressedSize := param.Metadata.Get(CompressedSizeMetadata)
+
+			if originalSize != strconv.Itoa(len(content)) || compressedSize != strconv.Itoa(len(stored)) {
+				t.Errorf("%s: unexpected size metadata %s and %s", compression, originalSize, compressedSize)
+			}
+
+		}
+
+	}
+
+}
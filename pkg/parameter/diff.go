@@ -0,0 +1,189 @@
+package parameter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+
+	paramapi "github.com/upper-institute/hike/proto/api/parameter"
+)
+
+type DiffKind string
+
+const (
+	// Only found in the first source
+	MissingDiff DiffKind = "-"
+	// Only found in the second source
+	ExtraDiff DiffKind = "+"
+	// Found in both with different values (or file checksums)
+	DifferentDiff DiffKind = "~"
+)
+
+type Difference struct {
+	Kind DiffKind
+	Key  string
+	A    *Parameter
+	B    *Parameter
+}
+
+type Diff struct {
+	Differences []*Difference
+}
+
+func contentChecksum(content []byte) string {
+
+	sum := sha256.Sum256(content)
+
+	return hex.EncodeToString(sum[:])
+
+}
+
+func checksum(ctx context.Context, param *Parameter) (string, error) {
+
+	err := param.Load(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return contentChecksum(param.GetFile().Bytes()), nil
+
+}
+
+func equalValues(ctx context.Context, a *Parameter, b *Parameter) (bool, error) {
+
+	if a.GetType() != b.GetType() {
+		return false, nil
+	}
+
+	switch {
+
+	case a.GetType() == paramapi.ParameterType_PT_VAR:
+		return a.GetFragment() == b.GetFragment(), nil
+
+	// Dirs point to a bucket and prefix (or archive), without fragment
+	case a.GetType() != paramapi.ParameterType_PT_FILE || a.GetURLString() == b.GetURLString():
+		return a.GetURLString() == b.GetURLString(), nil
+
+	}
+
+	sumA, err := checksum(ctx, a)
+	if err != nil {
+		return false, err
+	}
+
+	sumB, err := checksum(ctx, b)
+	if err != nil {
+		return false, err
+	}
+
+	return sumA == sumB, nil
+
+}
+
+// NewDiff compares two restored sources by key, var parameters by value, file
+// parameters by the checksum of their content and dirs by their URL
+func NewDiff(ctx context.Context, a *Source, b *Source) (*Diff, error) {
+
+	diff := &Diff{Differences: []*Difference{}}
+
+	for _, paramA := range a.List() {
+
+		key := paramA.GetKey()
+
+		if !b.Has(key) {
+			diff.Differences = append(diff.Differences, &Difference{Kind: MissingDiff, Key: key, A: paramA})
+			continue
+		}
+
+		paramB := b.Get(key)
+
+		equal, err := equalValues(ctx, paramA, paramB)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to compare %s: %w", key, err)
+		}
+
+		if !equal {
+			diff.Differences = append(diff.Differences, &Difference{Kind: DifferentDiff, Key: key, A: paramA, B: paramB})
+		}
+
+	}
+
+	for _, paramB := range b.List() {
+
+		if !a.Has(paramB.GetKey()) {
+			diff.Differences = append(diff.Differences, &Difference{Kind: ExtraDiff, Key: paramB.GetKey(), B: paramB})
+		}
+
+	}
+
+	sort.Slice(diff.Differences, func(i, j int) bool {
+		return diff.Differences[i].Key < diff.Differences[j].Key
+	})
+
+	return diff, nil
+
+}
+
+func (d *Diff) Count(kind DiffKind) int {
+
+	count := 0
+
+	for _, difference := range d.Differences {
+		if difference.Kind == kind {
+			count++
+		}
+	}
+
+	return count
+
+}
+
+func describe(param *Parameter, redact bool) string {
+
+	if param.GetType() == paramapi.ParameterType_PT_FILE && param.GetFile().Len() > 0 && !redact {
+
+		return fmt.Sprintf("%s (sha256:%s)", RedactedURLString(param), contentChecksum(param.GetFile().Bytes()))
+
+	}
+
+	if redact {
+		return RedactedURLString(param)
+	}
+
+	return param.GetURLString()
+
+}
+
+// Write shows the differences, var values and file checksums are only shown
+// when redact is false
+func (d *Diff) Write(w io.Writer, redact bool) {
+
+	for _, difference := range d.Differences {
+
+		switch difference.Kind {
+
+		case MissingDiff:
+			fmt.Fprintf(w, "%s %s = %s\n", difference.Kind, difference.Key, describe(difference.A, redact))
+
+		case ExtraDiff:
+			fmt.Fprintf(w, "%s %s = %s\n", difference.Kind, difference.Key, describe(difference.B, redact))
+
+		case DifferentDiff:
+			fmt.Fprintf(w, "%s %s = %s != %s\n", difference.Kind, difference.Key, describe(difference.A, redact), describe(difference.B, redact))
+
+		}
+
+	}
+
+	fmt.Fprintf(
+		w,
+		"Diff: %d missing, %d extra, %d different\n",
+		d.Count(MissingDiff),
+		d.Count(ExtraDiff),
+		d.Count(DifferentDiff),
+	)
+
+}
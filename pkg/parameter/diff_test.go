@@ -0,0 +1,50 @@
+package parameter
+
+import (
+	"context"
+	"testing"
+)
+
+func TestEqualValues(t *testing.T) {
+
+	options := &ParameterOptions{}
+
+	tests := []struct {
+		a     string
+		b     string
+		equal bool
+	}{
+		{"var://#value", "var://#value", true},
+		{"var://#value", "var://#other", false},
+		{"var://#value", "file://bucket/key#value", false},
+		{"file://bucket/key#a", "file://bucket/key#a", true},
+		{"dir://bucket/prefix", "dir://bucket/prefix", true},
+		{"dir://staging/prefix", "dir://prod/prefix", false},
+		{"dir://bucket/staging", "dir://bucket/prod", false},
+		{"dir://bucket/prefix?include=*.pem", "dir://bucket/prefix", false},
+	}
+
+	for _, test := range tests {
+
+		a, err := options.NewFromURLString("KEY", test.a)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		b, err := options.NewFromURLString("KEY", test.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		equal, err := equalValues(context.Background(), a, b)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if equal != test.equal {
+			t.Errorf("%s and %s: expected equal %v", test.a, test.b, test.equal)
+		}
+
+	}
+
+}
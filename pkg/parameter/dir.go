@@ -0,0 +1,450 @@
+package parameter
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	paramapi "github.com/upper-institute/hike/proto/api/parameter"
+)
+
+const (
+	// Globs matched against the relative path or the base name of each file,
+	// e.g. dir://bucket/lua/?include=*.lua&exclude=test_*#/etc/envoy/lua
+	IncludeQuery = "include"
+	ExcludeQuery = "exclude"
+
+	// Query to keep the directory as a single archive instead of a prefix,
+	// compression and encryption apply to the archive
+	ArchiveQuery = "archive"
+	TarArchive   = "tar"
+)
+
+func matchGlobs(relPath string, globs []string) bool {
+
+	for _, glob := range globs {
+
+		if ok, _ := path.Match(glob, relPath); ok {
+			return true
+		}
+
+		if ok, _ := path.Match(glob, path.Base(relPath)); ok {
+			return true
+		}
+
+	}
+
+	return false
+
+}
+
+// IncludeDirFile checks the include/exclude globs of the URL query, every
+// file is included without include globs
+func (p *Parameter) IncludeDirFile(relPath string) bool {
+
+	query := p.GetQuery()
+
+	includes := query[IncludeQuery]
+
+	if len(includes) > 0 && !matchGlobs(relPath, includes) {
+		return false
+	}
+
+	return !matchGlobs(relPath, query[ExcludeQuery])
+
+}
+
+func (p *Parameter) isArchive() (bool, error) {
+
+	switch p.GetQuery().Get(ArchiveQuery) {
+
+	case "":
+		return false, nil
+
+	case TarArchive:
+		return true, nil
+
+	}
+
+	return false, UnknownArchiveErr
+
+}
+
+// dirFile returns the file parameter of a file inside the prefix, with the
+// same compression and encryption queries
+func (p *Parameter) dirFile(relPath string) (*Parameter, error) {
+
+	query := p.GetQuery()
+
+	query.Del(IncludeQuery)
+	query.Del(ExcludeQuery)
+	query.Del(ArchiveQuery)
+
+	return p.options.NewFromURI(path.Join(p.key, relPath), &url.URL{
+		Scheme:   FileScheme,
+		Host:     p.GetHost(),
+		Path:     path.Join(p.GetPath(), relPath),
+		RawQuery: query.Encode(),
+	})
+
+}
+
+// localDirFiles walks the directory returning the relative (slash) paths of
+// included files
+func (p *Parameter) localDirFiles(dir string) ([]string, error) {
+
+	relPaths := []string{}
+
+	err := filepath.WalkDir(dir, func(filePath string, entry fs.DirEntry, err error) error {
+
+		if err != nil {
+			return err
+		}
+
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(dir, filePath)
+		if err != nil {
+			return err
+		}
+
+		relPath = filepath.ToSlash(relPath)
+
+		if p.IncludeDirFile(relPath) {
+			relPaths = append(relPaths, relPath)
+		}
+
+		return nil
+
+	})
+
+	return relPaths, err
+
+}
+
+// localPath joins the relative path to the directory, rejecting paths that
+// escape it
+func localPath(dir string, relPath string) (string, error) {
+
+	cleanPath := path.Clean("/" + relPath)
+
+	if cleanPath == "/" {
+		return "", InvalidDirPathErr
+	}
+
+	return filepath.Join(dir, filepath.FromSlash(cleanPath)), nil
+
+}
+
+func writeLocalFile(dir string, relPath string, content io.Reader) error {
+
+	filePath, err := localPath(dir, relPath)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(filePath), 0755)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(file, content)
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+
+}
+
+// LoadDir materialises the directory parameter into the local directory of
+// the URL fragment
+func (p *Parameter) LoadDir(ctx context.Context) error {
+
+	if p.GetType() != paramapi.ParameterType_PT_DIR {
+		return LoadOnlyDirTypeErr
+	}
+
+	dir := p.GetFragment()
+
+	archive, err := p.isArchive()
+	if err != nil {
+		return err
+	}
+
+	if archive {
+		return p.loadArchive(ctx, dir)
+	}
+
+	if p.options.Lister == nil {
+		return StorageNotEnabledErr
+	}
+
+	relPaths, err := p.options.Lister.List(ctx, p)
+	if err != nil {
+		return err
+	}
+
+	for _, relPath := range relPaths {
+
+		if !p.IncludeDirFile(relPath) {
+			continue
+		}
+
+		file, err := p.dirFile(relPath)
+		if err != nil {
+			return err
+		}
+
+		err = file.download(ctx)
+		if err != nil {
+			return err
+		}
+
+		err = writeLocalFile(dir, relPath, file.GetFile())
+		if err != nil {
+			return err
+		}
+
+	}
+
+	return nil
+
+}
+
+// dirChecksum hashes the paths and content of the included files, or the
+// archive, to detect changes of the directory at the same URL
+func (p *Parameter) dirChecksum(ctx context.Context) (string, error) {
+
+	archive, err := p.isArchive()
+	if err != nil {
+		return "", err
+	}
+
+	if archive {
+
+		p.file.Reset()
+
+		err := p.download(ctx)
+		if err != nil {
+			return "", err
+		}
+
+		return contentChecksum(p.file.Bytes()), nil
+
+	}
+
+	if p.options.Lister == nil {
+		return "", StorageNotEnabledErr
+	}
+
+	relPaths, err := p.options.Lister.List(ctx, p)
+	if err != nil {
+		return "", err
+	}
+
+	sort.Strings(relPaths)
+
+	hash := sha256.New()
+
+	for _, relPath := range relPaths {
+
+		if !p.IncludeDirFile(relPath) {
+			continue
+		}
+
+		file, err := p.dirFile(relPath)
+		if err != nil {
+			return "", err
+		}
+
+		err = file.download(ctx)
+		if err != nil {
+			return "", err
+		}
+
+		sum := sha256.Sum256(file.GetFile().Bytes())
+
+		hash.Write([]byte(relPath))
+		hash.Write(sum[:])
+
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+
+}
+
+func (p *Parameter) loadArchive(ctx context.Context, dir string) error {
+
+	p.file.Reset()
+
+	err := p.download(ctx)
+	if err != nil {
+		return err
+	}
+
+	r := tar.NewReader(p.file)
+
+	for {
+
+		header, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if header.Typeflag != tar.TypeReg || !p.IncludeDirFile(header.Name) {
+			continue
+		}
+
+		err = writeLocalFile(dir, header.Name, r)
+		if err != nil {
+			return err
+		}
+
+	}
+
+}
+
+// pushDir uploads the local directory of the URL fragment
+func (p *Parameter) pushDir(ctx context.Context) error {
+
+	dir := p.GetFragment()
+
+	archive, err := p.isArchive()
+	if err != nil {
+		return err
+	}
+
+	relPaths, err := p.localDirFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	if archive {
+		return p.pushArchive(ctx, dir, relPaths)
+	}
+
+	for _, relPath := range relPaths {
+
+		file, err := p.dirFile(relPath)
+		if err != nil {
+			return err
+		}
+
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(relPath)))
+		if err != nil {
+			return err
+		}
+
+		file.GetFile().Write(content)
+
+		err = file.upload(ctx)
+		if err != nil {
+			return err
+		}
+
+	}
+
+	return p.pruneDir(ctx, relPaths)
+
+}
+
+// pruneDir removes stored files of the prefix missing in the local
+// directory, files not matching the include/exclude globs are kept
+func (p *Parameter) pruneDir(ctx context.Context, relPaths []string) error {
+
+	storedPaths, err := p.options.Lister.List(ctx, p)
+	if err != nil {
+		return err
+	}
+
+	local := make(map[string]bool)
+
+	for _, relPath := range relPaths {
+		local[relPath] = true
+	}
+
+	for _, relPath := range storedPaths {
+
+		if local[relPath] || !p.IncludeDirFile(relPath) {
+			continue
+		}
+
+		file, err := p.dirFile(relPath)
+		if err != nil {
+			return err
+		}
+
+		err = p.options.Remover.Remove(ctx, file)
+		if err != nil {
+			return err
+		}
+
+	}
+
+	return nil
+
+}
+
+func (p *Parameter) pushArchive(ctx context.Context, dir string, relPaths []string) error {
+
+	buf := bytes.NewBuffer(nil)
+
+	w := tar.NewWriter(buf)
+
+	for _, relPath := range relPaths {
+
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(relPath)))
+		if err != nil {
+			return err
+		}
+
+		err = w.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     relPath,
+			Mode:     0644,
+			Size:     int64(len(content)),
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = w.Write(content)
+		if err != nil {
+			return err
+		}
+
+	}
+
+	err := w.Close()
+	if err != nil {
+		return err
+	}
+
+	p.file.Reset()
+	p.file.Write(buf.Bytes())
+
+	return p.upload(ctx)
+
+}
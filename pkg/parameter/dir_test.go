@@ -0,0 +1,152 @@
+package parameter
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalPath(t *testing.T) {
+
+	dir := t.TempDir()
+
+	tests := []struct {
+		relPath string
+		path    string
+		err     error
+	}{
+		{"conf/envoy.lua", filepath.Join(dir, "conf", "envoy.lua"), nil},
+		// Paths escaping the directory are kept inside it
+		{"../../etc/passwd", filepath.Join(dir, "etc", "passwd"), nil},
+		{"conf/../../passwd", filepath.Join(dir, "passwd"), nil},
+		{"/etc/passwd", filepath.Join(dir, "etc", "passwd"), nil},
+		{"", "", InvalidDirPathErr},
+		{"..", "", InvalidDirPathErr},
+		{"/", "", InvalidDirPathErr},
+	}
+
+	for _, test := range tests {
+
+		filePath, err := localPath(dir, test.relPath)
+
+		if filePath != test.path || err != test.err {
+			t.Errorf("%q: expected %q (%v), got %q (%v)", test.relPath, test.path, test.err, filePath, err)
+		}
+
+	}
+
+}
+
+func TestLoadArchivePathEscape(t *testing.T) {
+
+	buf := bytes.NewBuffer(nil)
+
+	w := tar.NewWriter(buf)
+
+	for _, name := range []string{"../escape.lua", "/abs.lua", "conf/envoy.lua"} {
+
+		if err := w.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: 0644, Size: 2}); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := w.Write([]byte("ok")); err != nil {
+			t.Fatal(err)
+		}
+
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	storage := newMemStorage()
+	storage.set("bucket/lua.tar", buf.Bytes())
+
+	root := t.TempDir()
+	dir := filepath.Join(root, "lua")
+
+	param, err := newMemParameterOptions(storage).NewFromURLString("LUA", "dir://bucket/lua.tar?archive=tar#"+dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := param.LoadDir(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, relPath := range []string{"escape.lua", "abs.lua", "conf/envoy.lua"} {
+
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(relPath))); err != nil {
+			t.Errorf("%s not loaded inside the directory: %v", relPath, err)
+		}
+
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "escape.lua")); !os.IsNotExist(err) {
+		t.Fatal("archive file written outside the directory")
+	}
+
+}
+
+func TestPushDirPrune(t *testing.T) {
+
+	storage := newMemStorage()
+	storage.set("bucket/lua/filter.lua", []byte("old"))
+	storage.set("bucket/lua/removed.lua", []byte("removed"))
+	storage.set("bucket/lua/README.md", []byte("kept, not included"))
+	storage.set("bucket/other/removed.lua", []byte("kept, other prefix"))
+
+	dir := t.TempDir()
+
+	for relPath, content := range map[string]string{"filter.lua": "new", "lib/json.lua": "json", "notes.txt": "excluded"} {
+
+		filePath := filepath.Join(dir, filepath.FromSlash(relPath))
+
+		if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+	}
+
+	options := newMemParameterOptions(storage)
+	options.Writer = newMemStore(map[string]string{})
+
+	param, err := options.NewFromURLString("LUA", "dir://bucket/lua?include=*.lua#"+dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := param.Push(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path    string
+		content string
+	}{
+		{"bucket/lua/filter.lua", "new"},
+		{"bucket/lua/lib/json.lua", "json"},
+		{"bucket/lua/removed.lua", ""},
+		{"bucket/lua/notes.txt", ""},
+		{"bucket/lua/README.md", "kept, not included"},
+		{"bucket/other/removed.lua", "kept, other prefix"},
+	}
+
+	for _, test := range tests {
+
+		content, ok := storage.get(test.path)
+
+		if ok != (len(test.content) > 0) || string(content) != test.content {
+			t.Errorf("%s: expected %q, got %q (stored %v)", test.path, test.content, content, ok)
+		}
+
+	}
+
+}
@@ -0,0 +1,136 @@
+package parameter
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+)
+
+const (
+	// Query of file parameters with the name of the key provider, e.g.
+	// file://bucket/tls.key?encryption=kms&encryption_key=alias/hike#/etc/tls.key
+	EncryptionQuery = "encryption"
+	// Query of file parameters with the key reference inside the provider
+	EncryptionKeyQuery = "encryption_key"
+
+	DataKeySize = 32
+)
+
+// KeyProvider generates data keys wrapped by a key encryption key, the
+// wrapped data key is stored alongside the encrypted file
+type KeyProvider interface {
+	GenerateDataKey(ctx context.Context, keyId string) (plaintext []byte, wrapped []byte, err error)
+	DecryptDataKey(ctx context.Context, keyId string, wrapped []byte) ([]byte, error)
+}
+
+func (options *ParameterOptions) RegisterKeyProvider(name string, provider KeyProvider) {
+
+	if options.KeyProviders == nil {
+		options.KeyProviders = make(map[string]KeyProvider)
+	}
+
+	options.KeyProviders[name] = provider
+
+}
+
+// GetKeyProvider returns the key provider and key reference from the
+// parameter URL query, or a nil provider when the file isn't encrypted
+func (p *Parameter) GetKeyProvider() (KeyProvider, string, error) {
+
+	query := p.GetQuery()
+
+	name := query.Get(EncryptionQuery)
+
+	if len(name) == 0 {
+		return nil, "", nil
+	}
+
+	provider, ok := p.options.KeyProviders[name]
+	if !ok {
+		return nil, "", UnknownKeyProviderErr
+	}
+
+	return provider, query.Get(EncryptionKeyQuery), nil
+
+}
+
+// SealEnvelope encrypts the plaintext with a new data key, returns the
+// ciphertext and the wrapped data key
+func SealEnvelope(ctx context.Context, provider KeyProvider, keyId string, plaintext []byte) ([]byte, []byte, error) {
+
+	dataKey, wrappedKey, err := provider.GenerateDataKey(ctx, keyId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ciphertext, err := EncryptAESGCM(dataKey, plaintext)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ciphertext, wrappedKey, nil
+
+}
+
+func OpenEnvelope(ctx context.Context, provider KeyProvider, keyId string, wrappedKey []byte, ciphertext []byte) ([]byte, error) {
+
+	dataKey, err := provider.DecryptDataKey(ctx, keyId, wrappedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecryptAESGCM(dataKey, ciphertext)
+
+}
+
+// EncryptAESGCM returns the random nonce followed by the sealed plaintext
+func EncryptAESGCM(key []byte, plaintext []byte) ([]byte, error) {
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+
+}
+
+func DecryptAESGCM(key []byte, ciphertext []byte) ([]byte, error) {
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, InvalidEnvelopeErr
+	}
+
+	nonce := ciphertext[:gcm.NonceSize()]
+
+	plaintext, err := gcm.Open(nil, nonce, ciphertext[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, InvalidEnvelopeErr
+	}
+
+	return plaintext, nil
+
+}
@@ -8,4 +8,16 @@ var (
 	FileNotFoundErr         = errors.New("File not found for this key")
 	LoadOnlyFileTypeErr     = errors.New("Load method applies only for parameter type 'file'")
 	UnknownSchemeErr        = errors.New("Unknown parameter scheme")
+	StoreNotEnabledErr      = errors.New("No parameter store driver enabled")
+	StorageNotEnabledErr    = errors.New("No parameter storage driver enabled")
+	UnknownStoreSchemeErr   = errors.New("No parameter store driver enabled for scheme")
+	UnknownKeyProviderErr   = errors.New("No key provider enabled for the parameter encryption")
+	InvalidEnvelopeErr      = errors.New("Unable to decrypt file, invalid key or corrupted content")
+	MissingDataKeyErr       = errors.New("Encrypted file without wrapped data key")
+	LoadOnlyDirTypeErr      = errors.New("LoadDir method applies only for parameter type 'dir'")
+	UnknownArchiveErr       = errors.New("Unknown directory archive, use tar")
+	InvalidDirPathErr       = errors.New("Invalid file path inside directory parameter")
+	UnknownCompressionErr   = errors.New("Unknown file compression, use gzip or zstd")
+	DecompressedTooLargeErr = errors.New("Decompressed file is bigger than the maximum size")
+	MissingFileBucketErr    = errors.New("File keys or size threshold set without file bucket to import file parameters")
 )
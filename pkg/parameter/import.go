@@ -0,0 +1,342 @@
+package parameter
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	EnvFormat  = "env"
+	JSONFormat = "json"
+	YAMLFormat = "yaml"
+)
+
+type ImportOptions struct {
+	// Bucket to upload values imported as file parameters, empty imports
+	// every value as var parameter
+	FileBucket string
+	FilePrefix string
+	// Keys always imported as file parameters
+	FileKeys []string
+	// Values bigger than the threshold are imported as file parameters, 0 disables
+	FileSizeThreshold int
+}
+
+func FormatFromFileName(fileName string) string {
+
+	switch strings.ToLower(filepath.Ext(fileName)) {
+
+	case ".json":
+		return JSONFormat
+
+	case ".yaml", ".yml":
+		return YAMLFormat
+
+	}
+
+	return EnvFormat
+
+}
+
+func ParseImportFile(format string, data []byte) (map[string]string, error) {
+
+	switch format {
+
+	case EnvFormat:
+		return parseEnv(data)
+
+	case JSONFormat:
+		return parseJSON(data)
+
+	case YAMLFormat:
+		values := make(map[string]interface{})
+		if err := yaml.Unmarshal(data, &values); err != nil {
+			return nil, err
+		}
+		return stringValues(values)
+
+	}
+
+	return nil, fmt.Errorf("Unknown import format: %s", format)
+
+}
+
+// parseJSON keeps numbers as written, float64 would change 1000000 into
+// 1e+06 and round big integer IDs
+func parseJSON(data []byte) (map[string]string, error) {
+
+	values := make(map[string]interface{})
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&values); err != nil {
+		return nil, err
+	}
+
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("Unexpected data after the JSON object")
+	}
+
+	return stringValues(values)
+
+}
+
+// stringValues keeps scalars as they are and encodes lists and objects as JSON
+func stringValues(values map[string]interface{}) (map[string]string, error) {
+
+	result := make(map[string]string)
+
+	for key, value := range values {
+
+		switch v := value.(type) {
+
+		case string:
+			result[key] = v
+
+		case json.Number:
+			result[key] = v.String()
+
+		case nil:
+			result[key] = ""
+
+		case map[string]interface{}, []interface{}:
+			encoded, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = string(encoded)
+
+		default:
+			result[key] = fmt.Sprint(v)
+
+		}
+
+	}
+
+	return result, nil
+
+}
+
+func parseEnv(data []byte) (map[string]string, error) {
+
+	result := make(map[string]string)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	lineNumber := 0
+
+	for scanner.Scan() {
+
+		lineNumber++
+
+		line := strings.TrimSpace(scanner.Text())
+
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		line = strings.TrimPrefix(line, "export ")
+
+		sep := strings.IndexRune(line, '=')
+		if sep < 0 {
+			return nil, fmt.Errorf("%w (line %d)", SeparatorNotFoundErr, lineNumber)
+		}
+
+		key := strings.TrimSpace(line[:sep])
+		value := strings.TrimSpace(line[sep+1:])
+
+		switch {
+
+		case strings.HasPrefix(value, `"`):
+
+			// Double quoted values may span multiple lines
+			unquoted, rest, ok := unquoteEnv(value[1:])
+
+			for !ok {
+
+				if !scanner.Scan() {
+					return nil, fmt.Errorf("Unterminated quoted value for %s (line %d)", key, lineNumber)
+				}
+
+				lineNumber++
+				value += "\n" + scanner.Text()
+
+				unquoted, rest, ok = unquoteEnv(value[1:])
+
+			}
+
+			if !isEnvComment(rest) {
+				return nil, fmt.Errorf("Unexpected characters after quoted value for %s (line %d)", key, lineNumber)
+			}
+
+			value = unquoted
+
+		case strings.HasPrefix(value, "'"):
+
+			end := strings.IndexRune(value[1:], '\'')
+
+			if end < 0 {
+				return nil, fmt.Errorf("Unterminated quoted value for %s (line %d)", key, lineNumber)
+			}
+
+			if !isEnvComment(value[end+2:]) {
+				return nil, fmt.Errorf("Unexpected characters after quoted value for %s (line %d)", key, lineNumber)
+			}
+
+			value = value[1 : end+1]
+
+		default:
+			if comment := strings.Index(value, " #"); comment >= 0 {
+				value = strings.TrimSpace(value[:comment])
+			}
+
+		}
+
+		result[key] = value
+
+	}
+
+	return result, scanner.Err()
+
+}
+
+// unquoteEnv reads a double quoted value up to its closing quote, honouring
+// escapes, returns what follows the quote and false when not closed
+func unquoteEnv(value string) (string, string, bool) {
+
+	unquoted := strings.Builder{}
+
+	for i := 0; i < len(value); i++ {
+
+		c := value[i]
+
+		switch {
+
+		case c == '"':
+			return unquoted.String(), value[i+1:], true
+
+		case c == '\\' && i+1 < len(value):
+
+			i++
+
+			switch value[i] {
+			case 'n':
+				unquoted.WriteByte('\n')
+			case 'r':
+				unquoted.WriteByte('\r')
+			case 't':
+				unquoted.WriteByte('\t')
+			case '"', '\\':
+				unquoted.WriteByte(value[i])
+			default:
+				unquoted.WriteByte(c)
+				unquoted.WriteByte(value[i])
+			}
+
+		default:
+			unquoted.WriteByte(c)
+
+		}
+
+	}
+
+	return "", "", false
+
+}
+
+// isEnvComment reports whether only spaces or a comment follow a value
+func isEnvComment(rest string) bool {
+
+	rest = strings.TrimSpace(rest)
+
+	return len(rest) == 0 || strings.HasPrefix(rest, "#")
+
+}
+
+// validate fails when file options are set without bucket, the values would
+// be imported as var parameters
+func (options *ImportOptions) validate() error {
+
+	if len(options.FileBucket) == 0 && (len(options.FileKeys) > 0 || options.FileSizeThreshold > 0) {
+		return MissingFileBucketErr
+	}
+
+	return nil
+
+}
+
+func (options *ImportOptions) isFile(key string, value string) bool {
+
+	if len(options.FileBucket) == 0 {
+		return false
+	}
+
+	for _, fileKey := range options.FileKeys {
+		if fileKey == key {
+			return true
+		}
+	}
+
+	return options.FileSizeThreshold > 0 && len(value) > options.FileSizeThreshold
+
+}
+
+// NewImportPlan plans to write the values as parameters of the restored
+// target source, parameters not imported are kept
+func NewImportPlan(ctx context.Context, values map[string]string, target *Source, options *ImportOptions) (*Plan, error) {
+
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
+
+	desired := []*Parameter{}
+
+	for key, value := range values {
+
+		uri := &url.URL{
+			Scheme:   VarScheme,
+			Fragment: value,
+		}
+
+		isFile := options.isFile(key, value)
+
+		if isFile {
+			uri = &url.URL{
+				Scheme:   FileScheme,
+				Host:     options.FileBucket,
+				Path:     path.Join("/", options.FilePrefix, key),
+				Fragment: key,
+			}
+		}
+
+		param, err := target.options.ParameterOptions.NewFromURI(key, uri)
+		if err != nil {
+			return nil, err
+		}
+
+		param.Metadata.Set(PathPrefixMetadata, target.uri.Path)
+		param.Metadata.Set(SourceSchemeMetadata, target.uri.Scheme)
+
+		if isFile {
+			param.GetFile().WriteString(value)
+		}
+
+		desired = append(desired, param)
+
+	}
+
+	return NewPlan(ctx, desired, target, false)
+
+}
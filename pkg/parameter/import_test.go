@@ -0,0 +1,184 @@
+package parameter
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFormatFromFileName(t *testing.T) {
+
+	tests := map[string]string{
+		"values.json":   JSONFormat,
+		"values.YAML":   YAMLFormat,
+		"values.yml":    YAMLFormat,
+		".env":          EnvFormat,
+		"values.env":    EnvFormat,
+		"values":        EnvFormat,
+		"dir/prod.Json": JSONFormat,
+	}
+
+	for fileName, format := range tests {
+		if got := FormatFromFileName(fileName); got != format {
+			t.Errorf("%s: expected %s, got %s", fileName, format, got)
+		}
+	}
+
+}
+
+func TestParseImportFile(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		format string
+		data   string
+		values map[string]string
+	}{
+		{
+			name:   "env",
+			format: EnvFormat,
+			data: `
+# comment
+export A=1
+B = two words # trailing comment
+C="quoted # not a comment" # comment
+D='single "quoted"'
+E="multi
+line"
+F="escaped \"quote\"\n\\"
+G=
+H=a#b
+`,
+			values: map[string]string{
+				"A": "1",
+				"B": "two words",
+				"C": "quoted # not a comment",
+				"D": `single "quoted"`,
+				"E": "multi\nline",
+				"F": "escaped \"quote\"\n\\",
+				"G": "",
+				"H": "a#b",
+			},
+		},
+		{
+			name:   "json",
+			format: JSONFormat,
+			data:   `{"A": "text", "B": 1000000, "C": 12345678901234567890, "D": 1.5, "E": true, "F": null, "G": {"n": 1000000}, "H": [1, "x"]}`,
+			values: map[string]string{
+				"A": "text",
+				"B": "1000000",
+				"C": "12345678901234567890",
+				"D": "1.5",
+				"E": "true",
+				"F": "",
+				"G": `{"n":1000000}`,
+				"H": `[1,"x"]`,
+			},
+		},
+		{
+			name:   "yaml",
+			format: YAMLFormat,
+			data: `
+A: text
+B: 1000000
+C: "007"
+D: 1.5
+E: true
+F:
+G:
+  n: 1
+H: [1, x]
+I: |
+  multi
+  line
+`,
+			values: map[string]string{
+				"A": "text",
+				"B": "1000000",
+				"C": "007",
+				"D": "1.5",
+				"E": "true",
+				"F": "",
+				"G": `{"n":1}`,
+				"H": `[1,"x"]`,
+				"I": "multi\nline\n",
+			},
+		},
+	}
+
+	for _, test := range tests {
+
+		values, err := ParseImportFile(test.format, []byte(test.data))
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(values, test.values) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.values, values)
+		}
+
+	}
+
+}
+
+func TestParseImportFileErrors(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		format string
+		data   string
+	}{
+		{"env without separator", EnvFormat, "A=1\nB\n"},
+		{"env unterminated double quote", EnvFormat, "A=\"open\nB=2\n"},
+		{"env unterminated single quote", EnvFormat, "A='open\n"},
+		{"env characters after quotes", EnvFormat, "A=\"x\" y\n"},
+		{"json syntax", JSONFormat, `{"A": 1`},
+		{"json not an object", JSONFormat, `["A"]`},
+		{"json trailing data", JSONFormat, `{"A": 1} {"B": 2}`},
+		{"yaml syntax", YAMLFormat, "A: [1\n"},
+		{"unknown format", "toml", "A = 1"},
+	}
+
+	for _, test := range tests {
+		if _, err := ParseImportFile(test.format, []byte(test.data)); err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		}
+	}
+
+}
+
+func TestImportOptionsFile(t *testing.T) {
+
+	for _, options := range []*ImportOptions{
+		{FileKeys: []string{"CERT"}},
+		{FileSizeThreshold: 10},
+	} {
+		if err := options.validate(); !errors.Is(err, MissingFileBucketErr) {
+			t.Errorf("%+v: expected missing file bucket, got %v", options, err)
+		}
+	}
+
+	options := &ImportOptions{FileBucket: "bucket", FileKeys: []string{"CERT"}, FileSizeThreshold: 4}
+
+	if err := options.validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key    string
+		value  string
+		isFile bool
+	}{
+		{"CERT", "x", true},
+		{"SHORT", "1234", false},
+		{"LONG", "12345", true},
+	}
+
+	for _, test := range tests {
+		if options.isFile(test.key, test.value) != test.isFile {
+			t.Errorf("%s: expected file %v", test.key, test.isFile)
+		}
+	}
+
+}
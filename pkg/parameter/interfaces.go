@@ -19,9 +19,14 @@ type Writer interface {
 	Put(ctx context.Context, parameter *Parameter) error
 }
 
+type Deleter interface {
+	Delete(ctx context.Context, parameter *Parameter) error
+}
+
 type Store interface {
 	Reader
 	Writer
+	Deleter
 }
 
 type Downloader interface {
@@ -36,3 +41,21 @@ type Storage interface {
 	Downloader
 	Uploader
 }
+
+// Lister lists the files inside the prefix of a directory parameter, paths
+// are relative to the prefix
+type Lister interface {
+	List(ctx context.Context, parameter *Parameter) ([]string, error)
+}
+
+// Remover removes the stored file of a file parameter, used to prune files
+// of directory parameters removed locally
+type Remover interface {
+	Remove(ctx context.Context, parameter *Parameter) error
+}
+
+type DirStorage interface {
+	Storage
+	Lister
+	Remover
+}
@@ -0,0 +1,168 @@
+package parameter
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// memStore keeps the parameter URLs in memory by key
+type memStore struct {
+	mu   sync.Mutex
+	urls map[string]string
+}
+
+func newMemStore(urls map[string]string) *memStore {
+	return &memStore{urls: urls}
+}
+
+func (s *memStore) set(key string, urlStr string) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(urlStr) == 0 {
+		delete(s.urls, key)
+		return
+	}
+
+	s.urls[key] = urlStr
+
+}
+
+func (s *memStore) Pull(ctx context.Context, options *PullRequest) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	defer close(options.Result)
+
+	for key, urlStr := range s.urls {
+
+		param, err := options.ParameterOptions.NewFromURLString(key, urlStr)
+		if err != nil {
+			return err
+		}
+
+		options.Result <- param
+
+	}
+
+	return nil
+
+}
+
+func (s *memStore) Put(ctx context.Context, param *Parameter) error {
+
+	s.set(param.GetKey(), param.GetURLString())
+
+	return nil
+
+}
+
+func (s *memStore) Delete(ctx context.Context, param *Parameter) error {
+
+	s.set(param.GetKey(), "")
+
+	return nil
+
+}
+
+// memStorage keeps the stored files in memory by bucket and path
+type memStorage struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+func newMemStorage() *memStorage {
+	return &memStorage{files: make(map[string][]byte)}
+}
+
+func storagePath(param *Parameter) string {
+	return param.GetHost() + param.GetPath()
+}
+
+func (s *memStorage) get(path string) ([]byte, bool) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	content, ok := s.files[path]
+
+	return content, ok
+
+}
+
+func (s *memStorage) set(path string, content []byte) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.files[path] = content
+
+}
+
+func (s *memStorage) Download(ctx context.Context, param *Parameter) error {
+
+	content, ok := s.get(storagePath(param))
+	if !ok {
+		return FileNotFoundErr
+	}
+
+	_, err := param.GetFile().Write(content)
+
+	return err
+
+}
+
+func (s *memStorage) Upload(ctx context.Context, param *Parameter) error {
+
+	s.set(storagePath(param), append([]byte(nil), param.GetFile().Bytes()...))
+
+	return nil
+
+}
+
+func (s *memStorage) List(ctx context.Context, param *Parameter) ([]string, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prefix := strings.TrimSuffix(storagePath(param), "/") + "/"
+
+	relPaths := []string{}
+
+	for path := range s.files {
+		if strings.HasPrefix(path, prefix) {
+			relPaths = append(relPaths, strings.TrimPrefix(path, prefix))
+		}
+	}
+
+	sort.Strings(relPaths)
+
+	return relPaths, nil
+
+}
+
+func (s *memStorage) Remove(ctx context.Context, param *Parameter) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.files, storagePath(param))
+
+	return nil
+
+}
+
+func newMemParameterOptions(storage *memStorage) *ParameterOptions {
+
+	return &ParameterOptions{
+		Downloader: storage,
+		Uploader:   storage,
+		Lister:     storage,
+		Remover:    storage,
+	}
+
+}
@@ -0,0 +1,85 @@
+package parameter
+
+import (
+	"context"
+	"fmt"
+)
+
+// StoreMux routes store operations by the scheme of the source URI
+// (e.g. ssm:///prod/app), URIs without scheme use the default store
+type StoreMux struct {
+	defaultStore Store
+	stores       map[string]Store
+}
+
+func NewStoreMux() *StoreMux {
+	return &StoreMux{
+		stores: make(map[string]Store),
+	}
+}
+
+// Handle registers the store for the scheme, the first registered store is
+// also the default one
+func (m *StoreMux) Handle(scheme string, store Store) {
+
+	m.stores[scheme] = store
+
+	if m.defaultStore == nil {
+		m.defaultStore = store
+	}
+
+}
+
+func (m *StoreMux) storeFor(scheme string) (Store, error) {
+
+	if len(scheme) == 0 {
+
+		if m.defaultStore == nil {
+			return nil, StoreNotEnabledErr
+		}
+
+		return m.defaultStore, nil
+
+	}
+
+	store, ok := m.stores[scheme]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", UnknownStoreSchemeErr, scheme)
+	}
+
+	return store, nil
+
+}
+
+func (m *StoreMux) Pull(ctx context.Context, options *PullRequest) error {
+
+	store, err := m.storeFor(options.Url.Scheme)
+	if err != nil {
+		return err
+	}
+
+	return store.Pull(ctx, options)
+
+}
+
+func (m *StoreMux) Put(ctx context.Context, param *Parameter) error {
+
+	store, err := m.storeFor(param.Metadata.Get(SourceSchemeMetadata))
+	if err != nil {
+		return err
+	}
+
+	return store.Put(ctx, param)
+
+}
+
+func (m *StoreMux) Delete(ctx context.Context, param *Parameter) error {
+
+	store, err := m.storeFor(param.Metadata.Get(SourceSchemeMetadata))
+	if err != nil {
+		return err
+	}
+
+	return store.Delete(ctx, param)
+
+}
@@ -12,13 +12,24 @@ import (
 const (
 	VarScheme  = "var"
 	FileScheme = "file"
+	DirScheme  = "dir"
+
+	// Metadata key of the path where the parameter is stored in the store
+	PathPrefixMetadata = "path_prefix"
+	// Metadata key of the scheme of the source URI, used to pick the store
+	SourceSchemeMetadata = "source_scheme"
 )
 
 type ParameterOptions struct {
 	Downloader Downloader
 	Uploader   Uploader
+	Lister     Lister
+	Remover    Remover
 	Writer     Writer
-	Logger     *zap.SugaredLogger
+	Deleter    Deleter
+	// Key providers by name, see EncryptionQuery
+	KeyProviders map[string]KeyProvider
+	Logger       *zap.SugaredLogger
 }
 
 func (options *ParameterOptions) NewFromURLString(key string, urlStr string) (*Parameter, error) {
@@ -66,6 +77,10 @@ func (p *Parameter) GetFragment() string {
 	return p.uri.Fragment
 }
 
+func (p *Parameter) GetScheme() string {
+	return p.uri.Scheme
+}
+
 func (p *Parameter) GetHost() string {
 	return p.uri.Host
 }
@@ -92,6 +107,9 @@ func (p *Parameter) GetType() paramapi.ParameterType {
 	case FileScheme:
 		return paramapi.ParameterType_PT_FILE
 
+	case DirScheme:
+		return paramapi.ParameterType_PT_DIR
+
 	}
 
 	return paramapi.ParameterType_PT_UNKNOWN
@@ -106,7 +124,7 @@ func (p *Parameter) Load(ctx context.Context) error {
 
 	p.file.Reset()
 
-	err := p.options.Downloader.Download(ctx, p)
+	err := p.download(ctx)
 
 	return err
 
@@ -118,15 +136,56 @@ func (p *Parameter) Push(ctx context.Context) error {
 		return UnknownSchemeErr
 	}
 
+	// Checked before the write, the store must not point to content never
+	// uploaded
+	if !p.storageEnabled() {
+		return StorageNotEnabledErr
+	}
+
 	err := p.options.Writer.Put(ctx, p)
 	if err != nil {
 		return err
 	}
 
-	if p.GetType() == paramapi.ParameterType_PT_FILE {
-		err = p.options.Uploader.Upload(ctx, p)
+	switch p.GetType() {
+
+	case paramapi.ParameterType_PT_FILE:
+		err = p.upload(ctx)
+
+	case paramapi.ParameterType_PT_DIR:
+		err = p.pushDir(ctx)
+
 	}
 
 	return err
 
 }
+
+// storageEnabled checks the storage drivers needed to push the parameter,
+// directories kept as a prefix are listed and pruned too
+func (p *Parameter) storageEnabled() bool {
+
+	switch p.GetType() {
+
+	case paramapi.ParameterType_PT_FILE:
+		return p.options.Uploader != nil
+
+	case paramapi.ParameterType_PT_DIR:
+
+		if archive, _ := p.isArchive(); archive {
+			return p.options.Uploader != nil
+		}
+
+		return p.options.Uploader != nil && p.options.Lister != nil && p.options.Remover != nil
+
+	}
+
+	return true
+
+}
+
+func (p *Parameter) Delete(ctx context.Context) error {
+
+	return p.options.Deleter.Delete(ctx, p)
+
+}
@@ -0,0 +1,241 @@
+package parameter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+
+	paramapi "github.com/upper-institute/hike/proto/api/parameter"
+)
+
+type ChangeAction string
+
+const (
+	AddAction    ChangeAction = "+"
+	UpdateAction ChangeAction = "~"
+	DeleteAction ChangeAction = "-"
+	// Parameter only found in the current source, kept when not pruning
+	ExtraAction ChangeAction = "?"
+)
+
+type Change struct {
+	Action  ChangeAction
+	Key     string
+	Current *Parameter
+	Desired *Parameter
+}
+
+type Plan struct {
+	Changes []*Change
+}
+
+// NewPlan compares desired parameters with the restored current source,
+// file parameters with content are also compared by content when the URLs
+// are the same
+func NewPlan(ctx context.Context, desired []*Parameter, current *Source, prune bool) (*Plan, error) {
+
+	plan := &Plan{Changes: []*Change{}}
+
+	desiredKeys := make(map[string]bool)
+
+	for _, desiredParam := range desired {
+
+		desiredKeys[desiredParam.GetKey()] = true
+
+		change := &Change{
+			Key:     desiredParam.GetKey(),
+			Desired: desiredParam,
+		}
+
+		if !current.Has(desiredParam.GetKey()) {
+			change.Action = AddAction
+			plan.Changes = append(plan.Changes, change)
+			continue
+		}
+
+		change.Current = current.Get(desiredParam.GetKey())
+
+		equal, err := change.equal(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if !equal {
+			change.Action = UpdateAction
+			plan.Changes = append(plan.Changes, change)
+		}
+
+	}
+
+	for _, currentParam := range current.List() {
+
+		if desiredKeys[currentParam.GetKey()] {
+			continue
+		}
+
+		change := &Change{
+			Action:  ExtraAction,
+			Key:     currentParam.GetKey(),
+			Current: currentParam,
+		}
+
+		if prune {
+			change.Action = DeleteAction
+		}
+
+		plan.Changes = append(plan.Changes, change)
+
+	}
+
+	sort.Slice(plan.Changes, func(i, j int) bool {
+		return plan.Changes[i].Key < plan.Changes[j].Key
+	})
+
+	return plan, nil
+
+}
+
+func (c *Change) equal(ctx context.Context) (bool, error) {
+
+	if c.Current.GetURLString() != c.Desired.GetURLString() {
+		return false, nil
+	}
+
+	if c.Desired.GetType() != paramapi.ParameterType_PT_FILE || c.Desired.GetFile().Len() == 0 {
+		return true, nil
+	}
+
+	err := c.Current.Load(ctx)
+	if err == FileNotFoundErr {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return bytes.Equal(c.Current.GetFile().Bytes(), c.Desired.GetFile().Bytes()), nil
+
+}
+
+func (p *Plan) Count(action ChangeAction) int {
+
+	count := 0
+
+	for _, change := range p.Changes {
+		if change.Action == action {
+			count++
+		}
+	}
+
+	return count
+
+}
+
+func (p *Plan) HasChanges() bool {
+	return p.Count(AddAction)+p.Count(UpdateAction)+p.Count(DeleteAction) > 0
+}
+
+// RedactedURLString never shows var values, which are kept in the URL fragment
+func RedactedURLString(param *Parameter) string {
+
+	if param.GetType() == paramapi.ParameterType_PT_VAR {
+		return param.GetScheme() + ":#<redacted>"
+	}
+
+	u := &url.URL{
+		Scheme:   param.GetScheme(),
+		Host:     param.GetHost(),
+		Path:     param.GetPath(),
+		RawQuery: param.uri.RawQuery,
+		Fragment: param.GetFragment(),
+	}
+
+	return u.String()
+
+}
+
+func (p *Plan) Write(w io.Writer) {
+
+	for _, change := range p.Changes {
+
+		switch change.Action {
+
+		case AddAction:
+			fmt.Fprintf(w, "%s %s = %s\n", change.Action, change.Key, RedactedURLString(change.Desired))
+
+		case UpdateAction:
+			fmt.Fprintf(w, "%s %s = %s -> %s\n", change.Action, change.Key, RedactedURLString(change.Current), RedactedURLString(change.Desired))
+
+		case DeleteAction, ExtraAction:
+			fmt.Fprintf(w, "%s %s = %s\n", change.Action, change.Key, RedactedURLString(change.Current))
+
+		}
+
+	}
+
+	fmt.Fprintf(
+		w,
+		"Plan: %d to add, %d to change, %d to delete, %d extra kept\n",
+		p.Count(AddAction),
+		p.Count(UpdateAction),
+		p.Count(DeleteAction),
+		p.Count(ExtraAction),
+	)
+
+}
+
+// Apply writes added and updated parameters (uploading file content when
+// present) and deletes pruned ones
+func (p *Plan) Apply(ctx context.Context) error {
+
+	// Fail before any change is applied when the file content can't be
+	// uploaded
+	for _, change := range p.Changes {
+
+		if change.Action != AddAction && change.Action != UpdateAction {
+			continue
+		}
+
+		param := change.Desired
+
+		if param.GetType() == paramapi.ParameterType_PT_FILE && param.GetFile().Len() > 0 && param.options.Uploader == nil {
+			return fmt.Errorf("Unable to apply change to %s: %w", change.Key, StorageNotEnabledErr)
+		}
+
+	}
+
+	for _, change := range p.Changes {
+
+		var err error
+
+		switch change.Action {
+
+		case AddAction, UpdateAction:
+
+			param := change.Desired
+
+			if param.GetType() == paramapi.ParameterType_PT_FILE && param.GetFile().Len() > 0 {
+				err = param.Push(ctx)
+			} else if param.GetType() == paramapi.ParameterType_PT_UNKNOWN {
+				err = UnknownSchemeErr
+			} else {
+				err = param.options.Writer.Put(ctx, param)
+			}
+
+		case DeleteAction:
+			err = change.Current.Delete(ctx)
+
+		}
+
+		if err != nil {
+			return fmt.Errorf("Unable to apply change to %s: %w", change.Key, err)
+		}
+
+	}
+
+	return nil
+
+}
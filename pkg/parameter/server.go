@@ -0,0 +1,413 @@
+package parameter
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"time"
+
+	paramapi "github.com/upper-institute/hike/proto/api/parameter"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const DefaultWatchInterval = 30 * time.Second
+
+type ServerOptions struct {
+	SourceOptions    *SourceOptions
+	WatchMinInterval time.Duration
+}
+
+func (options *ServerOptions) NewServer(logger *zap.SugaredLogger) (*Server, error) {
+
+	return &Server{
+		options: options,
+		logger:  logger.With("part", "parameter/server"),
+	}, nil
+
+}
+
+type Server struct {
+	paramapi.UnimplementedParameterServiceServer
+
+	options *ServerOptions
+
+	logger *zap.SugaredLogger
+}
+
+func (s *Server) Register(registrar grpc.ServiceRegistrar) {
+
+	paramapi.RegisterParameterServiceServer(registrar, s)
+
+}
+
+func (s *Server) statusFromError(err error) error {
+
+	switch {
+
+	case errors.Is(err, FileNotFoundErr):
+		return status.Error(codes.NotFound, err.Error())
+
+	case errors.Is(err, LoadOnlyFileTypeErr), errors.Is(err, UnknownSchemeErr), errors.Is(err, InvalidParameterTypeErr):
+		return status.Error(codes.InvalidArgument, err.Error())
+
+	case errors.Is(err, StoreNotEnabledErr), errors.Is(err, StorageNotEnabledErr):
+		return status.Error(codes.FailedPrecondition, err.Error())
+
+	}
+
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	s.logger.Errorw("Parameter service error", "error", err)
+
+	return status.Error(codes.Internal, err.Error())
+
+}
+
+func (s *Server) restore(ctx context.Context, sourceUri string) (*Source, error) {
+
+	if s.options.SourceOptions.Store == nil {
+		return nil, StoreNotEnabledErr
+	}
+
+	source, err := s.options.SourceOptions.NewFromURLString(sourceUri)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	err = source.Restore(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return source, nil
+
+}
+
+func (s *Server) toMessage(ctx context.Context, param *Parameter, loadFile bool) (*paramapi.Parameter, error) {
+
+	msg := &paramapi.Parameter{
+		Key:  param.GetKey(),
+		Url:  param.GetURLString(),
+		Type: param.GetType(),
+	}
+
+	if loadFile && msg.Type == paramapi.ParameterType_PT_FILE {
+
+		if s.options.SourceOptions.Downloader == nil {
+			return nil, StorageNotEnabledErr
+		}
+
+		err := param.Load(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		msg.File = param.GetFile().Bytes()
+
+	}
+
+	return msg, nil
+
+}
+
+func (s *Server) Get(ctx context.Context, req *paramapi.GetRequest) (*paramapi.GetResponse, error) {
+
+	source, err := s.restore(ctx, req.SourceUri)
+	if err != nil {
+		return nil, s.statusFromError(err)
+	}
+
+	if !source.Has(req.Key) {
+		return nil, status.Errorf(codes.NotFound, "Parameter not found: %s", req.Key)
+	}
+
+	msg, err := s.toMessage(ctx, source.Get(req.Key), req.LoadFile)
+	if err != nil {
+		return nil, s.statusFromError(err)
+	}
+
+	return &paramapi.GetResponse{Parameter: msg}, nil
+
+}
+
+func (s *Server) List(ctx context.Context, req *paramapi.ListRequest) (*paramapi.ListResponse, error) {
+
+	source, err := s.restore(ctx, req.SourceUri)
+	if err != nil {
+		return nil, s.statusFromError(err)
+	}
+
+	res := &paramapi.ListResponse{
+		Parameters: []*paramapi.Parameter{},
+	}
+
+	for _, param := range source.List() {
+
+		msg, err := s.toMessage(ctx, param, req.LoadFiles)
+		if err != nil {
+			return nil, s.statusFromError(err)
+		}
+
+		res.Parameters = append(res.Parameters, msg)
+
+	}
+
+	return res, nil
+
+}
+
+func (s *Server) Put(ctx context.Context, req *paramapi.PutRequest) (*paramapi.PutResponse, error) {
+
+	msg := req.Parameter
+
+	if msg == nil || len(msg.Key) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Parameter key is required")
+	}
+
+	if s.options.SourceOptions.Writer == nil {
+		return nil, s.statusFromError(StoreNotEnabledErr)
+	}
+
+	sourceUri, err := url.Parse(req.SourceUri)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	param, err := s.options.SourceOptions.ParameterOptions.NewFromURLString(msg.Key, msg.Url)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	param.Metadata.Set(PathPrefixMetadata, sourceUri.Path)
+	param.Metadata.Set(SourceSchemeMetadata, sourceUri.Scheme)
+
+	s.logger.Infow("Put parameter", "key", msg.Key, "source_uri", req.SourceUri)
+
+	// Without file content, only the reference to the file is written
+	if param.GetType() != paramapi.ParameterType_PT_FILE || len(msg.File) == 0 {
+
+		if param.GetType() == paramapi.ParameterType_PT_UNKNOWN {
+			return nil, s.statusFromError(UnknownSchemeErr)
+		}
+
+		err = s.options.SourceOptions.Writer.Put(ctx, param)
+		if err != nil {
+			return nil, s.statusFromError(err)
+		}
+
+		return &paramapi.PutResponse{}, nil
+
+	}
+
+	if s.options.SourceOptions.Uploader == nil {
+		return nil, s.statusFromError(StorageNotEnabledErr)
+	}
+
+	param.GetFile().Write(msg.File)
+
+	err = param.Push(ctx)
+	if err != nil {
+		return nil, s.statusFromError(err)
+	}
+
+	return &paramapi.PutResponse{}, nil
+
+}
+
+func (s *Server) Delete(ctx context.Context, req *paramapi.DeleteRequest) (*paramapi.DeleteResponse, error) {
+
+	if s.options.SourceOptions.Deleter == nil {
+		return nil, s.statusFromError(StoreNotEnabledErr)
+	}
+
+	source, err := s.restore(ctx, req.SourceUri)
+	if err != nil {
+		return nil, s.statusFromError(err)
+	}
+
+	if !source.Has(req.Key) {
+		return nil, status.Errorf(codes.NotFound, "Parameter not found: %s", req.Key)
+	}
+
+	s.logger.Infow("Delete parameter", "key", req.Key, "source_uri", req.SourceUri)
+
+	err = source.Get(req.Key).Delete(ctx)
+	if err != nil {
+		return nil, s.statusFromError(err)
+	}
+
+	return &paramapi.DeleteResponse{}, nil
+
+}
+
+// watchState is the last sent state of a watched parameter, the checksum is
+// only set when files are loaded
+type watchState struct {
+	url      string
+	checksum string
+}
+
+// watchChecksum detects new content at the same URL, e.g. rotated
+// certificates, dirs are only hashed when the storage can list them
+func (s *Server) watchChecksum(ctx context.Context, param *Parameter, msg *paramapi.Parameter) (string, error) {
+
+	switch param.GetType() {
+
+	case paramapi.ParameterType_PT_FILE:
+
+		return contentChecksum(msg.File), nil
+
+	case paramapi.ParameterType_PT_DIR:
+
+		if s.options.SourceOptions.Downloader == nil {
+			return "", nil
+		}
+
+		checksum, err := param.dirChecksum(ctx)
+		if errors.Is(err, StorageNotEnabledErr) {
+			return "", nil
+		}
+
+		return checksum, err
+
+	}
+
+	return "", nil
+
+}
+
+func (s *Server) Watch(req *paramapi.WatchRequest, stream paramapi.ParameterService_WatchServer) error {
+
+	ctx := stream.Context()
+
+	interval := req.Interval.AsDuration()
+
+	if interval < s.options.WatchMinInterval {
+		interval = s.options.WatchMinInterval
+	}
+
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+
+	log := s.logger.With("source_uri", req.SourceUri, "interval", interval)
+
+	log.Infow("Start watching parameters")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// Parameter key to URL (and content checksum) of the last sent state
+	known := make(map[string]watchState)
+
+	for {
+
+		source, err := s.restore(ctx, req.SourceUri)
+
+		if err != nil {
+
+			if errors.Is(err, StoreNotEnabledErr) || status.Code(err) == codes.InvalidArgument {
+				return s.statusFromError(err)
+			}
+
+			// Transient errors (e.g. throttling) are retried on the next interval
+			log.Warnw("Unable to restore watched parameters, retrying", "error", err)
+
+			select {
+
+			case <-ctx.Done():
+				log.Infow("Stop watching parameters")
+				return nil
+
+			case <-ticker.C:
+				continue
+
+			}
+
+		}
+
+		current := make(map[string]watchState)
+
+		for _, param := range source.List() {
+
+			state := watchState{url: param.GetURLString()}
+
+			knownState, ok := known[param.GetKey()]
+
+			// Without content only the URL can change
+			if ok && knownState.url == state.url && !req.LoadFiles {
+				current[param.GetKey()] = knownState
+				continue
+			}
+
+			msg, err := s.toMessage(ctx, param, req.LoadFiles)
+
+			if err == nil && req.LoadFiles {
+				state.checksum, err = s.watchChecksum(ctx, param, msg)
+			}
+
+			if err != nil {
+
+				log.Warnw("Unable to load watched parameter, retrying", "key", param.GetKey(), "error", err)
+
+				// Keep the last sent state, the change is sent once loaded
+				if ok {
+					current[param.GetKey()] = knownState
+				}
+
+				continue
+
+			}
+
+			current[param.GetKey()] = state
+
+			if ok && knownState == state {
+				continue
+			}
+
+			err = stream.Send(&paramapi.WatchResponse{
+				EventType: paramapi.WatchResponse_ET_PUT,
+				Parameter: msg,
+			})
+			if err != nil {
+				return err
+			}
+
+		}
+
+		for key, state := range known {
+
+			if _, ok := current[key]; ok {
+				continue
+			}
+
+			err = stream.Send(&paramapi.WatchResponse{
+				EventType: paramapi.WatchResponse_ET_DELETE,
+				Parameter: &paramapi.Parameter{Key: key, Url: state.url},
+			})
+			if err != nil {
+				return err
+			}
+
+		}
+
+		known = current
+
+		select {
+
+		case <-ctx.Done():
+			log.Infow("Stop watching parameters")
+			return nil
+
+		case <-ticker.C:
+
+		}
+
+	}
+
+}
@@ -0,0 +1,116 @@
+package parameter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	paramapi "github.com/upper-institute/hike/proto/api/parameter"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+type testWatchServer struct {
+	grpc.ServerStream
+	ctx       context.Context
+	responses chan *paramapi.WatchResponse
+}
+
+func (s *testWatchServer) Context() context.Context {
+	return s.ctx
+}
+
+func (s *testWatchServer) Send(res *paramapi.WatchResponse) error {
+
+	s.responses <- res
+
+	return nil
+
+}
+
+func TestWatch(t *testing.T) {
+
+	storage := newMemStorage()
+	storage.set("bucket/cert.pem", []byte("first"))
+
+	store := newMemStore(map[string]string{
+		"CERT":  "file://bucket/cert.pem#/etc/cert.pem",
+		"TOKEN": "var://#token",
+	})
+
+	sourceOptions := &SourceOptions{ParameterOptions: newMemParameterOptions(storage)}
+	sourceOptions.RegisterStore("mem", store)
+
+	server, err := (&ServerOptions{SourceOptions: sourceOptions}).NewServer(zap.NewNop().Sugar())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	stream := &testWatchServer{ctx: ctx, responses: make(chan *paramapi.WatchResponse, 10)}
+
+	done := make(chan error)
+
+	go func() {
+		done <- server.Watch(&paramapi.WatchRequest{SourceUri: "mem:///app", Interval: durationpb.New(10 * time.Millisecond), LoadFiles: true}, stream)
+	}()
+
+	next := func() *paramapi.WatchResponse {
+
+		select {
+
+		case res := <-stream.responses:
+			return res
+
+		case <-time.After(5 * time.Second):
+			t.Fatal("no watch response")
+
+		}
+
+		return nil
+
+	}
+
+	initial := map[string]*paramapi.Parameter{}
+
+	for i := 0; i < 2; i++ {
+		res := next()
+		initial[res.Parameter.Key] = res.Parameter
+	}
+
+	if string(initial["CERT"].File) != "first" || initial["TOKEN"] == nil {
+		t.Fatalf("unexpected initial parameters %v", initial)
+	}
+
+	// Rotated content at the same URL
+	storage.set("bucket/cert.pem", []byte("second"))
+
+	if res := next(); res.EventType != paramapi.WatchResponse_ET_PUT || res.Parameter.Key != "CERT" || string(res.Parameter.File) != "second" {
+		t.Fatalf("unexpected rotation response %v", res)
+	}
+
+	store.set("TOKEN", "")
+
+	if res := next(); res.EventType != paramapi.WatchResponse_ET_DELETE || res.Parameter.Key != "TOKEN" || res.Parameter.Url != initial["TOKEN"].Url {
+		t.Fatalf("unexpected delete response %v", res)
+	}
+
+	// Unchanged parameters are not sent again
+	select {
+
+	case res := <-stream.responses:
+		t.Fatalf("unexpected response %v", res)
+
+	case <-time.After(50 * time.Millisecond):
+
+	}
+
+	cancel()
+
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/url"
 	"os"
+	"regexp"
 	"strings"
 
 	paramapi "github.com/upper-institute/hike/proto/api/parameter"
@@ -14,6 +15,23 @@ type SourceOptions struct {
 	Store Store
 }
 
+// RegisterStore makes the store available to source URIs with the scheme,
+// writes and deletes are routed to the store the parameter was restored from
+func (options *SourceOptions) RegisterStore(scheme string, store Store) {
+
+	mux, ok := options.Store.(*StoreMux)
+	if !ok {
+		mux = NewStoreMux()
+		options.Store = mux
+	}
+
+	mux.Handle(scheme, store)
+
+	options.ParameterOptions.Writer = mux
+	options.ParameterOptions.Deleter = mux
+
+}
+
 func (options *SourceOptions) NewFromURLString(urlStr string) (*Source, error) {
 
 	uri, err := url.Parse(urlStr)
@@ -64,6 +82,7 @@ func (c *Source) Restore(ctx context.Context) error {
 			if !ok {
 				return nil
 			}
+			param.Metadata.Set(SourceSchemeMetadata, c.uri.Scheme)
 			c.kv[param.key] = param
 
 		}
@@ -72,6 +91,10 @@ func (c *Source) Restore(ctx context.Context) error {
 
 }
 
+func (c *Source) GetURI() *url.URL {
+	return c.uri
+}
+
 func (c *Source) Has(key string) bool {
 	_, ok := c.kv[key]
 	return ok
@@ -101,7 +124,68 @@ func (c *Source) List() []*Parameter {
 
 }
 
-func (c *Source) RestoreFromProcessEnvs() error {
+type ProcessEnvOptions struct {
+	// When any include is set, only matching variables are restored
+	IncludePrefixes []string
+	IncludePatterns []*regexp.Regexp
+	ExcludePrefixes []string
+	ExcludePatterns []*regexp.Regexp
+	// Removed from the variable name to build the parameter key
+	StripPrefix string
+	// Keys (after stripping) which values are parsed as parameter URLs
+	// (e.g. file://bucket/cert.pem#/etc/cert.pem), "*" parses every value
+	ParseURLKeys []string
+}
+
+func matchEnv(name string, prefixes []string, patterns []*regexp.Regexp) bool {
+
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	for _, pattern := range patterns {
+		if pattern.MatchString(name) {
+			return true
+		}
+	}
+
+	return false
+
+}
+
+func (options *ProcessEnvOptions) restore(name string) bool {
+
+	hasInclude := len(options.IncludePrefixes) > 0 || len(options.IncludePatterns) > 0
+
+	if hasInclude && !matchEnv(name, options.IncludePrefixes, options.IncludePatterns) {
+		return false
+	}
+
+	return !matchEnv(name, options.ExcludePrefixes, options.ExcludePatterns)
+
+}
+
+func (options *ProcessEnvOptions) parseURL(key string) bool {
+
+	for _, parseKey := range options.ParseURLKeys {
+		if parseKey == "*" || parseKey == key {
+			return true
+		}
+	}
+
+	return false
+
+}
+
+// RestoreFromProcessEnvs restores process environment variables as var
+// parameters, nil options restores every variable
+func (c *Source) RestoreFromProcessEnvs(options *ProcessEnvOptions) error {
+
+	if options == nil {
+		options = &ProcessEnvOptions{}
+	}
 
 	envs := os.Environ()
 
@@ -109,13 +193,36 @@ func (c *Source) RestoreFromProcessEnvs() error {
 
 		sep := strings.IndexRune(env, '=')
 
-		key := env[:sep]
+		name := env[:sep]
 		value := env[sep+1:]
 
-		param, err := c.options.ParameterOptions.NewFromURI(key, &url.URL{
+		if !options.restore(name) {
+			continue
+		}
+
+		key := strings.TrimPrefix(name, options.StripPrefix)
+
+		if len(key) == 0 {
+			continue
+		}
+
+		uri := &url.URL{
 			Fragment: value,
 			Scheme:   VarScheme,
-		})
+		}
+
+		if options.parseURL(key) {
+
+			parsed, err := url.Parse(value)
+
+			// Values that aren't parameter URLs are kept as var parameters
+			if err == nil && (parsed.Scheme == VarScheme || parsed.Scheme == FileScheme) {
+				uri = parsed
+			}
+
+		}
+
+		param, err := c.options.ParameterOptions.NewFromURI(key, uri)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,144 @@
+package parameter
+
+import (
+	"regexp"
+	"testing"
+)
+
+func restoreTestEnvs(t *testing.T, envs map[string]string, options *ProcessEnvOptions) *Source {
+
+	for name, value := range envs {
+		t.Setenv(name, value)
+	}
+
+	source, err := (&SourceOptions{ParameterOptions: &ParameterOptions{}}).NewFromURLString("env://")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := source.RestoreFromProcessEnvs(options); err != nil {
+		t.Fatal(err)
+	}
+
+	return source
+
+}
+
+func TestRestoreFromProcessEnvsFilters(t *testing.T) {
+
+	envs := map[string]string{
+		"HIKETEST_APP_DB_URL":   "postgres://db",
+		"HIKETEST_APP_SECRET":   "s3cr3t",
+		"HIKETEST_APP_DEBUG":    "1",
+		"HIKETEST_OTHER_TOKEN":  "token",
+		"HIKETEST_OTHER_REGION": "us-east-1",
+	}
+
+	tests := []struct {
+		name    string
+		options *ProcessEnvOptions
+		keys    []string
+	}{
+		{
+			name:    "include prefix",
+			options: &ProcessEnvOptions{IncludePrefixes: []string{"HIKETEST_APP_"}},
+			keys:    []string{"HIKETEST_APP_DB_URL", "HIKETEST_APP_SECRET", "HIKETEST_APP_DEBUG"},
+		},
+		{
+			name:    "include pattern",
+			options: &ProcessEnvOptions{IncludePatterns: []*regexp.Regexp{regexp.MustCompile(`^HIKETEST_.*_(TOKEN|SECRET)$`)}},
+			keys:    []string{"HIKETEST_APP_SECRET", "HIKETEST_OTHER_TOKEN"},
+		},
+		{
+			name: "exclude prefix and pattern",
+			options: &ProcessEnvOptions{
+				IncludePrefixes: []string{"HIKETEST_"},
+				ExcludePrefixes: []string{"HIKETEST_OTHER_"},
+				ExcludePatterns: []*regexp.Regexp{regexp.MustCompile(`DEBUG$`)},
+			},
+			keys: []string{"HIKETEST_APP_DB_URL", "HIKETEST_APP_SECRET"},
+		},
+		{
+			name:    "strip prefix",
+			options: &ProcessEnvOptions{IncludePrefixes: []string{"HIKETEST_APP_"}, StripPrefix: "HIKETEST_APP_"},
+			keys:    []string{"DB_URL", "SECRET", "DEBUG"},
+		},
+	}
+
+	for _, test := range tests {
+
+		source := restoreTestEnvs(t, envs, test.options)
+
+		if len(source.List()) != len(test.keys) {
+			t.Errorf("%s: expected keys %v, got %d parameters", test.name, test.keys, len(source.List()))
+			continue
+		}
+
+		for _, key := range test.keys {
+			if !source.Has(key) {
+				t.Errorf("%s: missing key %s", test.name, key)
+			}
+		}
+
+	}
+
+	source := restoreTestEnvs(t, envs, &ProcessEnvOptions{IncludePrefixes: []string{"HIKETEST_APP_"}, StripPrefix: "HIKETEST_APP_"})
+
+	if value := source.Get("DB_URL").GetFragment(); value != "postgres://db" {
+		t.Fatalf("unexpected value %s", value)
+	}
+
+}
+
+func TestRestoreFromProcessEnvsParseURL(t *testing.T) {
+
+	envs := map[string]string{
+		"HIKETEST_CERT":    "file://bucket/cert.pem#/etc/cert.pem",
+		"HIKETEST_VAR":     "var://#value",
+		"HIKETEST_DB":      "postgres://user@db/app",
+		"HIKETEST_ESCAPES": "%zz",
+		"HIKETEST_PLAIN":   "file://bucket/plain",
+	}
+
+	source := restoreTestEnvs(t, envs, &ProcessEnvOptions{
+		IncludePrefixes: []string{"HIKETEST_"},
+		StripPrefix:     "HIKETEST_",
+		ParseURLKeys:    []string{"CERT", "VAR", "DB", "ESCAPES"},
+	})
+
+	tests := []struct {
+		key      string
+		scheme   string
+		fragment string
+	}{
+		{"CERT", FileScheme, "/etc/cert.pem"},
+		{"VAR", VarScheme, "value"},
+		// Values that aren't parameter URLs, or not even URLs, are vars
+		{"DB", VarScheme, "postgres://user@db/app"},
+		{"ESCAPES", VarScheme, "%zz"},
+		// Not parsed without the key
+		{"PLAIN", VarScheme, "file://bucket/plain"},
+	}
+
+	for _, test := range tests {
+
+		param := source.Get(test.key)
+
+		if param == nil {
+			t.Errorf("%s: not restored", test.key)
+			continue
+		}
+
+		if param.GetScheme() != test.scheme || param.GetFragment() != test.fragment {
+			t.Errorf("%s: expected %s with %q, got %s", test.key, test.scheme, test.fragment, param.GetURLString())
+		}
+
+	}
+
+	source = restoreTestEnvs(t, envs, &ProcessEnvOptions{IncludePrefixes: []string{"HIKETEST_"}, ParseURLKeys: []string{"*"}})
+
+	if param := source.Get("HIKETEST_PLAIN"); param.GetScheme() != FileScheme || param.GetHost() != "bucket" {
+		t.Fatalf("value not parsed with *: %s", param.GetURLString())
+	}
+
+}
@@ -0,0 +1,67 @@
+package parameter
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	paramapi "github.com/upper-institute/hike/proto/api/parameter"
+)
+
+type SyncOptions struct {
+	// File parameters in other buckets are copied to this one, keeping the
+	// object key, empty keeps the URL
+	TargetBucket string
+	Prune        bool
+}
+
+// NewSyncPlan plans to make the target source a copy of the source, both
+// must be restored
+func NewSyncPlan(ctx context.Context, source *Source, target *Source, options *SyncOptions) (*Plan, error) {
+
+	desired := []*Parameter{}
+
+	for _, param := range source.List() {
+
+		uri := *param.uri
+
+		desiredParam, err := target.options.ParameterOptions.NewFromURI(param.GetKey(), &uri)
+		if err != nil {
+			return nil, err
+		}
+
+		desiredParam.Metadata.Set(PathPrefixMetadata, rebasePath(param.Metadata.Get(PathPrefixMetadata), source.uri.Path, target.uri.Path))
+		desiredParam.Metadata.Set(SourceSchemeMetadata, target.uri.Scheme)
+
+		if param.GetType() == paramapi.ParameterType_PT_FILE && len(options.TargetBucket) > 0 && param.GetHost() != options.TargetBucket {
+
+			err = param.Load(ctx)
+			if err != nil {
+				return nil, err
+			}
+
+			desiredParam.uri.Host = options.TargetBucket
+			desiredParam.GetFile().Write(param.GetFile().Bytes())
+
+		}
+
+		desired = append(desired, desiredParam)
+
+	}
+
+	return NewPlan(ctx, desired, target, options.Prune)
+
+}
+
+// rebasePath keeps nested paths of the source under the target path
+func rebasePath(pathPrefix string, sourcePath string, targetPath string) string {
+
+	if len(pathPrefix) == 0 {
+		return targetPath
+	}
+
+	nested := strings.TrimPrefix(path.Clean(pathPrefix), path.Clean(sourcePath))
+
+	return path.Join(targetPath, nested)
+
+}
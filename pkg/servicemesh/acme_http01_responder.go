@@ -0,0 +1,281 @@
+package servicemesh
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	clusterv3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	endpointv3 "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+const (
+	AcmeHTTP01ChallengePath   = "/.well-known/acme-challenge/"
+	DefaultAcmeHTTP01Cluster  = "hike-acme-http01"
+	DefaultAcmeHTTP01Timeout  = 2 * time.Minute
+	DefaultAcmeHTTP01Delay    = 2 * time.Second
+	acmeHTTP01RouteNamePrefix = "hike-acme-http01/"
+)
+
+type HTTP01ResponderOptions struct {
+	// Bind address of the HTTP server answering the challenges
+	ListenAddr string
+	// Address (host:port) Envoy uses to reach the HTTP server
+	ClusterAddr string
+	ClusterName string
+	// Wakes up the discovery cycle, see EnvoyDiscoveryOptions.Refresh
+	Refresh func()
+	// Max time to wait for the challenge route to be in a snapshot
+	Timeout time.Duration
+	// Time given to Envoy to apply the snapshot with the challenge route
+	Delay time.Duration
+}
+
+func (options *HTTP01ResponderOptions) NewResponder(logger *zap.SugaredLogger) (*HTTP01Responder, error) {
+
+	host, portStr, err := net.SplitHostPort(options.ClusterAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	port, err := strconv.ParseUint(portStr, 10, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(host) == 0 {
+		host = "127.0.0.1"
+	}
+
+	return &HTTP01Responder{
+		options:     options,
+		logger:      logger.With("part", "service-mesh/acme-http01-responder"),
+		clusterHost: host,
+		clusterPort: uint32(port),
+		tokens:      make(map[string]*http01Challenge),
+		appliedCh:   make(chan struct{}),
+	}, nil
+
+}
+
+type http01Challenge struct {
+	domain  string
+	keyAuth string
+}
+
+// HTTP01Responder answers ACME HTTP-01 challenges routed by Envoy: while a
+// challenge is pending, a route to the hike cluster is injected first in the
+// virtual hosts of its domain
+type HTTP01Responder struct {
+	options *HTTP01ResponderOptions
+
+	logger *zap.SugaredLogger
+
+	clusterHost string
+	clusterPort uint32
+
+	mu         sync.Mutex
+	tokens     map[string]*http01Challenge
+	generation int64
+	applied    int64
+	appliedCh  chan struct{}
+}
+
+func (h *HTTP01Responder) StartServer() error {
+
+	lis, err := net.Listen("tcp", h.options.ListenAddr)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+
+	mux.Handle(AcmeHTTP01ChallengePath, h)
+
+	go func() {
+
+		err := http.Serve(lis, mux)
+		if err != nil {
+			h.logger.Errorw("ACME HTTP-01 server stopped", "error", err)
+		}
+
+	}()
+
+	h.logger.Infow("ACME HTTP-01 server listening", "address", lis.Addr())
+
+	return nil
+
+}
+
+func (h *HTTP01Responder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	token := strings.TrimPrefix(r.URL.Path, AcmeHTTP01ChallengePath)
+
+	host, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		host = r.Host
+	}
+
+	h.mu.Lock()
+	challenge, ok := h.tokens[token]
+	h.mu.Unlock()
+
+	if !ok || !strings.EqualFold(challenge.domain, host) {
+		h.logger.Warnw("Unknown ACME HTTP-01 challenge", "host", host, "token", token)
+		http.NotFound(w, r)
+		return
+	}
+
+	h.logger.Infow("Answering ACME HTTP-01 challenge", "domain", challenge.domain)
+
+	w.Header().Set("Content-Type", "text/plain")
+	w.Write([]byte(challenge.keyAuth))
+
+}
+
+// MutateResources injects the challenge routes and the hike cluster
+func (h *HTTP01Responder) MutateResources(ctx context.Context, res *Resources) {
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	res.AddResource(resource.ClusterType, h.cluster())
+
+	for token, challenge := range h.tokens {
+
+		count := res.GetVirtualHosts().PrependRoute(challenge.domain, h.route(token))
+
+		if count == 0 {
+			h.logger.Warnw("No virtual host found to route ACME HTTP-01 challenge", "domain", challenge.domain)
+		}
+
+	}
+
+	if h.applied < h.generation {
+		h.applied = h.generation
+		close(h.appliedCh)
+		h.appliedCh = make(chan struct{})
+	}
+
+}
+
+func (h *HTTP01Responder) cluster() *clusterv3.Cluster {
+
+	return &clusterv3.Cluster{
+		Name:                 h.options.ClusterName,
+		ConnectTimeout:       durationpb.New(5 * time.Second),
+		ClusterDiscoveryType: &clusterv3.Cluster_Type{Type: clusterv3.Cluster_STATIC},
+		LoadAssignment: &endpointv3.ClusterLoadAssignment{
+			ClusterName: h.options.ClusterName,
+			Endpoints: []*endpointv3.LocalityLbEndpoints{{
+				LbEndpoints: []*endpointv3.LbEndpoint{{
+					HostIdentifier: &endpointv3.LbEndpoint_Endpoint{
+						Endpoint: &endpointv3.Endpoint{
+							Address: &corev3.Address{
+								Address: &corev3.Address_SocketAddress{
+									SocketAddress: &corev3.SocketAddress{
+										Protocol: corev3.SocketAddress_TCP,
+										Address:  h.clusterHost,
+										PortSpecifier: &corev3.SocketAddress_PortValue{
+											PortValue: h.clusterPort,
+										},
+									},
+								},
+							},
+						},
+					},
+				}},
+			}},
+		},
+	}
+
+}
+
+func (h *HTTP01Responder) route(token string) *routev3.Route {
+
+	return &routev3.Route{
+		Name: acmeHTTP01RouteNamePrefix + token,
+		Match: &routev3.RouteMatch{
+			PathSpecifier: &routev3.RouteMatch_Path{
+				Path: AcmeHTTP01ChallengePath + token,
+			},
+		},
+		Action: &routev3.Route_Route{
+			Route: &routev3.RouteAction{
+				ClusterSpecifier: &routev3.RouteAction_Cluster{
+					Cluster: h.options.ClusterName,
+				},
+			},
+		},
+	}
+
+}
+
+// Present adds the challenge and waits for its route to be in a snapshot,
+// plus a delay for Envoy to apply it
+func (h *HTTP01Responder) Present(domain, token, keyAuth string) error {
+
+	h.mu.Lock()
+
+	h.generation++
+
+	generation := h.generation
+	appliedCh := h.appliedCh
+
+	h.tokens[token] = &http01Challenge{domain, keyAuth}
+
+	h.mu.Unlock()
+
+	h.logger.Infow("Routing ACME HTTP-01 challenge through Envoy", "domain", domain)
+
+	h.options.Refresh()
+
+	ctx, cancel := context.WithTimeout(context.Background(), h.options.Timeout)
+	defer cancel()
+
+	for {
+
+		select {
+
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case <-appliedCh:
+
+			h.mu.Lock()
+			applied := h.applied
+			appliedCh = h.appliedCh
+			h.mu.Unlock()
+
+			if applied >= generation {
+				time.Sleep(h.options.Delay)
+				return nil
+			}
+
+		}
+
+	}
+
+}
+
+// CleanUp removes the challenge, the route is removed by the next snapshot
+func (h *HTTP01Responder) CleanUp(domain, token, keyAuth string) error {
+
+	h.mu.Lock()
+	delete(h.tokens, token)
+	h.mu.Unlock()
+
+	h.options.Refresh()
+
+	return nil
+
+}
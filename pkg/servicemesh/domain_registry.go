@@ -0,0 +1,42 @@
+package servicemesh
+
+import (
+	"net"
+	"sort"
+
+	sdapi "github.com/upper-institute/hike/proto/api/service-discovery"
+)
+
+// EndpointAddresses returns the sorted IP addresses of the service
+// endpoints, IPv4 or IPv6 ones, e.g. the values of its A or AAAA records
+func EndpointAddresses(svc *sdapi.Service, ipv6 bool) []string {
+
+	addresses := make(map[string]bool)
+
+	for _, localityEndpoints := range svc.GetEnvoyClusterLoadAssignment().GetEndpoints() {
+
+		for _, lbEndpoint := range localityEndpoints.LbEndpoints {
+
+			ip := net.ParseIP(lbEndpoint.GetEndpoint().GetAddress().GetSocketAddress().GetAddress())
+
+			if ip == nil || (ip.To4() == nil) != ipv6 {
+				continue
+			}
+
+			addresses[ip.String()] = true
+
+		}
+
+	}
+
+	values := make([]string, 0, len(addresses))
+
+	for address := range addresses {
+		values = append(values, address)
+	}
+
+	sort.Strings(values)
+
+	return values
+
+}
@@ -8,7 +8,11 @@ import (
 
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/anypb"
 
+	tlsv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
 	clusterservice "github.com/envoyproxy/go-control-plane/envoy/service/cluster/v3"
 	discoverygrpc "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
 	endpointservice "github.com/envoyproxy/go-control-plane/envoy/service/endpoint/v3"
@@ -17,15 +21,51 @@ import (
 	runtimeservice "github.com/envoyproxy/go-control-plane/envoy/service/runtime/v3"
 	secretservice "github.com/envoyproxy/go-control-plane/envoy/service/secret/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/resource/v3"
 	serverv3 "github.com/envoyproxy/go-control-plane/pkg/server/v3"
 	sdapi "github.com/upper-institute/hike/proto/api/service-discovery"
 )
 
+var snapshotTypeUrls = []string{
+	resource.ClusterType,
+	resource.EndpointType,
+	resource.ListenerType,
+	resource.RouteType,
+	resource.VirtualHostType,
+	resource.SecretType,
+	resource.RuntimeType,
+}
+
 type EnvoyDiscoveryOptions struct {
 	NodeID                 string
 	Services               []EnvoyDiscoveryService
+	Observers              []ServiceObserver
+	Mutators               []ResourcesMutator
 	WatchInterval          time.Duration
 	ServiceDiscoverTimeout time.Duration
+
+	refreshOnce sync.Once
+	refreshCh   chan struct{}
+}
+
+func (options *EnvoyDiscoveryOptions) refreshChannel() chan struct{} {
+
+	options.refreshOnce.Do(func() {
+		options.refreshCh = make(chan struct{}, 1)
+	})
+
+	return options.refreshCh
+
+}
+
+// Refresh wakes up the discovery cycle before the watch interval
+func (options *EnvoyDiscoveryOptions) Refresh() {
+
+	select {
+	case options.refreshChannel() <- struct{}{}:
+	default:
+	}
+
 }
 
 func (options *EnvoyDiscoveryOptions) NewServer(ctx context.Context, logger *zap.SugaredLogger) (*EnvoyDiscoveryServer, error) {
@@ -44,6 +84,8 @@ func (options *EnvoyDiscoveryOptions) NewServer(ctx context.Context, logger *zap
 }
 
 type EnvoyDiscoveryServer struct {
+	sdapi.UnimplementedDiscoveryServiceServer
+
 	options *EnvoyDiscoveryOptions
 
 	logger *zap.SugaredLogger
@@ -65,6 +107,64 @@ func (e *EnvoyDiscoveryServer) Register(grpcServer *grpc.Server) {
 	runtimeservice.RegisterRuntimeDiscoveryServiceServer(grpcServer, e.server)
 	routeservice.RegisterVirtualHostDiscoveryServiceServer(grpcServer, e.server)
 
+	e.RegisterSnapshotService(grpcServer)
+
+}
+
+func (e *EnvoyDiscoveryServer) RegisterSnapshotService(registrar grpc.ServiceRegistrar) {
+
+	sdapi.RegisterDiscoveryServiceServer(registrar, e)
+
+}
+
+func (e *EnvoyDiscoveryServer) GetSnapshot(ctx context.Context, req *sdapi.GetSnapshotRequest) (*sdapi.GetSnapshotResponse, error) {
+
+	nodeId := req.NodeId
+
+	if len(nodeId) == 0 {
+		nodeId = e.options.NodeID
+	}
+
+	snapshot, err := e.cache.GetSnapshot(nodeId)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+
+	res := &sdapi.GetSnapshotResponse{
+		NodeId:    nodeId,
+		Resources: []*sdapi.SnapshotResources{},
+	}
+
+	for _, typeUrl := range snapshotTypeUrls {
+
+		snapshotResources := &sdapi.SnapshotResources{
+			TypeUrl:   typeUrl,
+			Version:   snapshot.GetVersion(typeUrl),
+			Resources: []*anypb.Any{},
+		}
+
+		for _, resource := range snapshot.GetResources(typeUrl) {
+
+			// Private keys never leave the control plane
+			if secret, ok := resource.(*tlsv3.Secret); ok {
+				resource = RedactSecret(secret)
+			}
+
+			resourceAny, err := anypb.New(resource)
+			if err != nil {
+				return nil, status.Error(codes.Internal, err.Error())
+			}
+
+			snapshotResources.Resources = append(snapshotResources.Resources, resourceAny)
+
+		}
+
+		res.Resources = append(res.Resources, snapshotResources)
+
+	}
+
+	return res, nil
+
 }
 
 func (e *EnvoyDiscoveryServer) discover() {
@@ -84,14 +184,19 @@ func (e *EnvoyDiscoveryServer) discover() {
 		}
 
 		applySvcCh := make(chan *sdapi.Service)
+		appliedCh := make(chan struct{})
 		res := NewResources(e.logger)
+		services := []*sdapi.Service{}
 
 		go func() {
 
 			for applySvc := range applySvcCh {
 				res.ApplyService(applySvc)
+				services = append(services, applySvc)
 			}
 
+			close(appliedCh)
+
 		}()
 
 		// Execute discovery services in parallel
@@ -122,6 +227,15 @@ func (e *EnvoyDiscoveryServer) discover() {
 
 		wg.Wait()
 		close(applySvcCh)
+		<-appliedCh
+
+		for _, observer := range e.options.Observers {
+			observer.ObserveServices(ctx, services)
+		}
+
+		for _, mutator := range e.options.Mutators {
+			mutator.MutateResources(ctx, res)
+		}
 
 		// Update cache if snapshot hash doesn't match
 
@@ -156,7 +270,10 @@ func (e *EnvoyDiscoveryServer) discover() {
 
 		e.logger.Infow("Sleeping before new discover cycle", "watch_interval", e.options.WatchInterval, "version", version)
 
-		time.Sleep(e.options.WatchInterval)
+		select {
+		case <-time.After(e.options.WatchInterval):
+		case <-e.options.refreshChannel():
+		}
 
 	}
 
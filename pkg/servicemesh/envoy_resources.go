@@ -31,6 +31,7 @@ import (
 )
 
 type Resources struct {
+	services     []*sdapi.Service
 	virtualHosts VirtualHostMap
 	resourceMap  map[string][]types.Resource
 	logger       *zap.SugaredLogger
@@ -56,6 +57,8 @@ func (r *Resources) ApplyService(svc *sdapi.Service) {
 
 	r.logger.Infow("Apply service resources", "service_name", svc.ServiceName)
 
+	r.services = append(r.services, svc)
+
 	cluster := svc.EnvoyCluster
 	loadAssignment := svc.EnvoyClusterLoadAssignment
 
@@ -110,35 +113,28 @@ func (r *Resources) ApplyService(svc *sdapi.Service) {
 			panic(err)
 		}
 
-		r.resourceMap[resource.ListenerType] = append(
-			r.resourceMap[resource.ListenerType],
-			&listenerv3.Listener{
-				Name: svc.ServiceName,
-				Address: &corev3.Address{
-					Address: &corev3.Address_SocketAddress{
-						SocketAddress: &corev3.SocketAddress{
-							Protocol: corev3.SocketAddress_TCP,
-							Address:  "0.0.0.0",
-							PortSpecifier: &corev3.SocketAddress_PortValue{
-								PortValue: svc.ListenPort,
-							},
-						},
-					},
+		filters := []*listenerv3.Filter{
+			{
+				Name: wellknown.HTTPConnectionManager,
+				ConfigType: &listenerv3.Filter_TypedConfig{
+					TypedConfig: httpConnManagerAny,
 				},
-				FilterChains: []*listenerv3.FilterChain{
-					{
-						Filters: []*listenerv3.Filter{
-							{
-								Name: wellknown.HTTPConnectionManager,
-								ConfigType: &listenerv3.Filter_TypedConfig{
-									TypedConfig: httpConnManagerAny,
-								},
-							},
-						},
-					},
+			},
+		}
+
+		listener := &listenerv3.Listener{
+			Name:    svc.ServiceName,
+			Address: listenerAddress(svc.ListenPort),
+			FilterChains: []*listenerv3.FilterChain{
+				{
+					Filters: filters,
 				},
 			},
-		)
+		}
+
+		// TLS is terminated by the secret loader, once the secrets of the
+		// service certificates are loaded
+		r.resourceMap[resource.ListenerType] = append(r.resourceMap[resource.ListenerType], listener)
 
 		r.resourceMap[resource.RouteType] = append(r.resourceMap[resource.RouteType], &routev3.RouteConfiguration{
 			Name:                     svc.ServiceName,
@@ -172,13 +168,53 @@ func (r *Resources) ApplyService(svc *sdapi.Service) {
 
 }
 
+func listenerAddress(port uint32) *corev3.Address {
+
+	return &corev3.Address{
+		Address: &corev3.Address_SocketAddress{
+			SocketAddress: &corev3.SocketAddress{
+				Protocol: corev3.SocketAddress_TCP,
+				Address:  "0.0.0.0",
+				PortSpecifier: &corev3.SocketAddress_PortValue{
+					PortValue: port,
+				},
+			},
+		},
+	}
+
+}
+
+func (r *Resources) GetServices() []*sdapi.Service {
+	return r.services
+}
+
+// AddResource adds a resource not built from services, e.g. a cluster served
+// by hike itself
+func (r *Resources) AddResource(typeUrl string, res types.Resource) {
+
+	r.resourceMap[typeUrl] = append(r.resourceMap[typeUrl], res)
+
+}
+
+// GetResources returns the resources of the type, e.g. to change the
+// listeners or clusters built from services
+func (r *Resources) GetResources(typeUrl string) []types.Resource {
+	return r.resourceMap[typeUrl]
+}
+
+func (r *Resources) GetVirtualHosts() VirtualHostMap {
+	return r.virtualHosts
+}
+
 func (r *Resources) Hash() []byte {
 
 	hash := sha256.New()
 
-	for _, resources := range r.resourceMap {
+	r.resourceMap[resource.VirtualHostType] = r.virtualHosts.ToResourceSlice()
+
+	for _, typeUrl := range snapshotTypeUrls {
 
-		for _, resource := range resources {
+		for _, resource := range r.resourceMap[typeUrl] {
 
 			msg, err := protojson.Marshal(resource)
 			if err != nil {
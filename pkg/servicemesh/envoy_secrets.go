@@ -0,0 +1,480 @@
+package servicemesh
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	listenerv3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	tls_inspectorv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/listener/tls_inspector/v3"
+	tlsv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"github.com/upper-institute/hike/pkg/helpers"
+	"github.com/upper-institute/hike/pkg/parameter"
+	paramapi "github.com/upper-institute/hike/proto/api/parameter"
+	sdapi "github.com/upper-institute/hike/proto/api/service-discovery"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const (
+	DefaultSecretReloadInterval = 5 * time.Minute
+
+	// Transport protocols detected by the TLS inspector listener filter
+	tlsTransportProtocol       = "tls"
+	rawBufferTransportProtocol = "raw_buffer"
+)
+
+// ServiceTlsCertificates returns the certificates served by the listeners of
+// the service, including the ones issued by ACME
+func ServiceTlsCertificates(svc *sdapi.Service) []*sdapi.TlsCertificate {
+
+	certs := append([]*sdapi.TlsCertificate{}, svc.TlsCertificates...)
+
+	for _, acmeCert := range svc.AcmeProtocolCertificates {
+
+		certs = append(certs, &sdapi.TlsCertificate{
+			ParameterFileSource:     acmeCert.ParameterFileSource,
+			CertificateParameterKey: acmeCert.CertificateParameterKey,
+			ServerNames:             acmeCert.Domains,
+		})
+
+	}
+
+	return certs
+
+}
+
+// TlsCertificateParameterKey is the file parameter with the certificate chain
+func TlsCertificateParameterKey(cert *sdapi.TlsCertificate) string {
+
+	if len(cert.CertificateParameterKey) > 0 {
+		return cert.CertificateParameterKey
+	}
+
+	return paramapi.WellKnown_WN_TLS_CERTIFICATE.String()
+
+}
+
+// TlsSecretName is the name of the SDS secret of the certificate
+func TlsSecretName(cert *sdapi.TlsCertificate) string {
+	return cert.ParameterFileSource + "#" + TlsCertificateParameterKey(cert)
+}
+
+// XdsConfigSource is the config source of resources served by hike through
+// the xDS cluster, e.g. SDS secrets
+func XdsConfigSource(xdsClusterName string) *corev3.ConfigSource {
+
+	return &corev3.ConfigSource{
+		ResourceApiVersion: resource.DefaultAPIVersion,
+		ConfigSourceSpecifier: &corev3.ConfigSource_ApiConfigSource{
+			ApiConfigSource: &corev3.ApiConfigSource{
+				ApiType:             corev3.ApiConfigSource_DELTA_GRPC,
+				TransportApiVersion: resource.DefaultAPIVersion,
+				GrpcServices: []*corev3.GrpcService{{
+					TargetSpecifier: &corev3.GrpcService_EnvoyGrpc_{
+						EnvoyGrpc: &corev3.GrpcService_EnvoyGrpc{
+							ClusterName: xdsClusterName,
+						},
+					},
+				}},
+			},
+		},
+	}
+
+}
+
+type tlsFilterChain struct {
+	serverNames []string
+	secretNames []string
+	hasSecret   map[string]bool
+}
+
+// tlsFilterChains returns a filter chain per set of server names, selected by
+// SNI, terminating TLS with the secrets served via SDS. Envoy rejects chains
+// with the same match, so certificates without server names share the
+// default chain and a server name is kept only in its first certificate.
+func tlsFilterChains(svc *sdapi.Service, certs []*sdapi.TlsCertificate, filters []*listenerv3.Filter, logger *zap.SugaredLogger) []*listenerv3.FilterChain {
+
+	chains := []*tlsFilterChain{}
+	chainsByNames := make(map[string]*tlsFilterChain)
+	claimedNames := make(map[string]string)
+
+	for _, cert := range certs {
+
+		secretName := TlsSecretName(cert)
+
+		serverNames := []string{}
+
+		for _, serverName := range cert.ServerNames {
+
+			name := strings.ToLower(serverName)
+
+			claimedBy, ok := claimedNames[name]
+
+			if ok && claimedBy != secretName {
+				logger.Warnw("Server name already served by other certificate, ignoring it", "service_name", svc.ServiceName, "server_name", serverName, "secret_name", secretName, "served_by", claimedBy)
+			}
+
+			if ok {
+				continue
+			}
+
+			claimedNames[name] = secretName
+			serverNames = append(serverNames, name)
+
+		}
+
+		if len(cert.ServerNames) > 0 && len(serverNames) == 0 {
+			continue
+		}
+
+		sort.Strings(serverNames)
+
+		key := strings.Join(serverNames, ",")
+
+		chain, ok := chainsByNames[key]
+
+		if !ok {
+			chain = &tlsFilterChain{serverNames: serverNames, hasSecret: make(map[string]bool)}
+			chainsByNames[key] = chain
+			chains = append(chains, chain)
+		}
+
+		if !chain.hasSecret[secretName] {
+			chain.hasSecret[secretName] = true
+			chain.secretNames = append(chain.secretNames, secretName)
+		}
+
+	}
+
+	filterChains := []*listenerv3.FilterChain{}
+
+	for _, chain := range chains {
+
+		sdsSecretConfigs := []*tlsv3.SdsSecretConfig{}
+
+		for _, secretName := range chain.secretNames {
+			sdsSecretConfigs = append(sdsSecretConfigs, &tlsv3.SdsSecretConfig{
+				Name:      secretName,
+				SdsConfig: XdsConfigSource(svc.XdsClusterName),
+			})
+		}
+
+		tlsContext := &tlsv3.DownstreamTlsContext{
+			CommonTlsContext: &tlsv3.CommonTlsContext{
+				AlpnProtocols:                  []string{"h2", "http/1.1"},
+				TlsCertificateSdsSecretConfigs: sdsSecretConfigs,
+			},
+		}
+
+		filterChain := &listenerv3.FilterChain{
+			Filters:         filters,
+			TransportSocket: TlsTransportSocket(tlsContext),
+		}
+
+		// Only TLS connections, set by the TLS inspector, plain HTTP may
+		// share the listener
+		filterChain.FilterChainMatch = &listenerv3.FilterChainMatch{
+			ServerNames:       chain.serverNames,
+			TransportProtocol: tlsTransportProtocol,
+		}
+
+		filterChains = append(filterChains, filterChain)
+
+	}
+
+	return filterChains
+
+}
+
+// RedactSecret returns a copy of the secret without private keys and
+// generic secrets, e.g. to expose snapshots
+func RedactSecret(secret *tlsv3.Secret) *tlsv3.Secret {
+
+	redacted := proto.Clone(secret).(*tlsv3.Secret)
+
+	switch secretType := redacted.Type.(type) {
+
+	case *tlsv3.Secret_TlsCertificate:
+		secretType.TlsCertificate.PrivateKey = nil
+		secretType.TlsCertificate.PrivateKeyProvider = nil
+		secretType.TlsCertificate.Password = nil
+		secretType.TlsCertificate.Pkcs12 = nil
+
+	case *tlsv3.Secret_SessionTicketKeys:
+		redacted.Type = nil
+
+	case *tlsv3.Secret_GenericSecret:
+		redacted.Type = nil
+
+	}
+
+	return redacted
+
+}
+
+// TlsTransportSocket wraps a downstream or upstream TLS context
+func TlsTransportSocket(tlsContext proto.Message) *corev3.TransportSocket {
+
+	tlsContextAny, err := anypb.New(tlsContext)
+	if err != nil {
+		panic(err)
+	}
+
+	return &corev3.TransportSocket{
+		Name: wellknown.TransportSocketTls,
+		ConfigType: &corev3.TransportSocket_TypedConfig{
+			TypedConfig: tlsContextAny,
+		},
+	}
+
+}
+
+func tlsInspectorListenerFilter() *listenerv3.ListenerFilter {
+
+	tlsInspectorAny, err := anypb.New(&tls_inspectorv3.TlsInspector{})
+	if err != nil {
+		panic(err)
+	}
+
+	return &listenerv3.ListenerFilter{
+		Name: wellknown.TlsInspector,
+		ConfigType: &listenerv3.ListenerFilter_TypedConfig{
+			TypedConfig: tlsInspectorAny,
+		},
+	}
+
+}
+
+type SecretLoaderOptions struct {
+	SourceOptions *parameter.SourceOptions
+	// Interval to load again the certificates, so rotations reach Envoy
+	ReloadInterval time.Duration
+}
+
+func (options *SecretLoaderOptions) NewSecretLoader(logger *zap.SugaredLogger) *SecretLoader {
+
+	return &SecretLoader{
+		options: options,
+		logger:  logger.With("part", "service-mesh/secret-loader"),
+		secrets: make(map[string]*loadedSecret),
+	}
+
+}
+
+type loadedSecret struct {
+	secret   *tlsv3.Secret
+	loadedAt time.Time
+}
+
+// SecretLoader loads the certificates of the services from file parameters
+// into SDS secrets
+type SecretLoader struct {
+	options *SecretLoaderOptions
+
+	logger *zap.SugaredLogger
+
+	mu      sync.Mutex
+	secrets map[string]*loadedSecret
+}
+
+func (l *SecretLoader) loadFile(ctx context.Context, source *parameter.Source, key string) ([]byte, error) {
+
+	if !source.Has(key) {
+		return nil, parameter.FileNotFoundErr
+	}
+
+	param := source.Get(key)
+
+	err := param.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return param.GetFile().Bytes(), nil
+
+}
+
+func (l *SecretLoader) load(ctx context.Context, cert *sdapi.TlsCertificate) (*tlsv3.Secret, error) {
+
+	source, err := l.options.SourceOptions.NewFromURLString(cert.ParameterFileSource)
+	if err != nil {
+		return nil, err
+	}
+
+	err = source.Restore(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	chain, err := l.loadFile(ctx, source, TlsCertificateParameterKey(cert))
+	if err != nil {
+		return nil, err
+	}
+
+	var key []byte
+
+	if len(cert.KeyParameterKey) > 0 {
+
+		key, err = l.loadFile(ctx, source, cert.KeyParameterKey)
+		if err != nil {
+			return nil, err
+		}
+
+	} else {
+
+		chain, key, err = helpers.SplitPEMBundle(chain)
+		if err != nil {
+			return nil, err
+		}
+
+	}
+
+	return &tlsv3.Secret{
+		Name: TlsSecretName(cert),
+		Type: &tlsv3.Secret_TlsCertificate{
+			TlsCertificate: &tlsv3.TlsCertificate{
+				CertificateChain: &corev3.DataSource{
+					Specifier: &corev3.DataSource_InlineBytes{InlineBytes: chain},
+				},
+				PrivateKey: &corev3.DataSource{
+					Specifier: &corev3.DataSource_InlineBytes{InlineBytes: key},
+				},
+			},
+		},
+	}, nil
+
+}
+
+// Invalidate loads the secret again in the next cycle, e.g. after a renewal
+func (l *SecretLoader) Invalidate(name string) {
+
+	l.mu.Lock()
+	delete(l.secrets, name)
+	l.mu.Unlock()
+
+}
+
+// MutateResources adds the secrets of the services certificates, loading the
+// ones not loaded within the reload interval
+func (l *SecretLoader) MutateResources(ctx context.Context, res *Resources) {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	secrets := make(map[string]*loadedSecret)
+
+	for _, svc := range res.GetServices() {
+
+		for _, cert := range ServiceTlsCertificates(svc) {
+
+			name := TlsSecretName(cert)
+
+			if _, ok := secrets[name]; ok {
+				continue
+			}
+
+			loaded, ok := l.secrets[name]
+
+			if !ok || time.Since(loaded.loadedAt) >= l.options.ReloadInterval {
+
+				secret, err := l.load(ctx, cert)
+
+				if err != nil {
+					l.logger.Errorw("Unable to load certificate secret", "secret_name", name, "error", err)
+				} else {
+					loaded = &loadedSecret{secret, time.Now()}
+				}
+
+			}
+
+			// Keeps serving the last loaded secret when a reload fails
+			if loaded != nil {
+				secrets[name] = loaded
+				res.AddResource(resource.SecretType, loaded.secret)
+			}
+
+		}
+
+	}
+
+	l.secrets = secrets
+
+	l.terminateTls(res)
+
+}
+
+// terminateTls adds the TLS filter chains of the certificates with loaded
+// secrets. Until one is loaded the listener stays in plain HTTP, e.g. so the
+// ACME HTTP-01 challenge of its first certificate can be answered, and without
+// a TLS listen port plain HTTP keeps being served next to TLS.
+func (l *SecretLoader) terminateTls(res *Resources) {
+
+	listeners := make(map[string]*listenerv3.Listener)
+
+	for _, r := range res.GetResources(resource.ListenerType) {
+		listener := r.(*listenerv3.Listener)
+		listeners[listener.Name] = listener
+	}
+
+	for _, svc := range res.GetServices() {
+
+		listener, ok := listeners[svc.ServiceName]
+		if !ok || len(listener.FilterChains) == 0 {
+			continue
+		}
+
+		certs := []*sdapi.TlsCertificate{}
+
+		for _, cert := range ServiceTlsCertificates(svc) {
+
+			if _, ok := l.secrets[TlsSecretName(cert)]; ok {
+				certs = append(certs, cert)
+			}
+
+		}
+
+		if len(certs) == 0 {
+			continue
+		}
+
+		filters := listener.FilterChains[0].Filters
+
+		// TLS is terminated on a second listener when the port is set,
+		// e.g. to keep plain HTTP for ACME HTTP-01 challenges
+		if svc.TlsListenPort > 0 {
+
+			listener = &listenerv3.Listener{
+				Name:    svc.ServiceName + "/tls",
+				Address: listenerAddress(svc.TlsListenPort),
+			}
+
+			res.AddResource(resource.ListenerType, listener)
+
+		}
+
+		filterChains := tlsFilterChains(svc, certs, filters, l.logger)
+
+		// Otherwise plain HTTP is kept on the same listener, the ACME HTTP-01
+		// challenges of the renewals must still be answered
+		if svc.TlsListenPort == 0 {
+			filterChains = append(filterChains, &listenerv3.FilterChain{
+				Filters: filters,
+				FilterChainMatch: &listenerv3.FilterChainMatch{
+					TransportProtocol: rawBufferTransportProtocol,
+				},
+			})
+		}
+
+		listener.ListenerFilters = []*listenerv3.ListenerFilter{tlsInspectorListenerFilter()}
+		listener.FilterChains = filterChains
+
+	}
+
+}
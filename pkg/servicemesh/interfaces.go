@@ -9,3 +9,21 @@ import (
 type EnvoyDiscoveryService interface {
 	Discover(ctx context.Context, svcCh chan *sdapi.Service)
 }
+
+// ServiceObserver receives the services found by each discovery cycle
+type ServiceObserver interface {
+	ObserveServices(ctx context.Context, services []*sdapi.Service)
+}
+
+// ResourcesMutator changes the resources of each discovery cycle after the
+// services are applied, e.g. to inject routes or secrets
+type ResourcesMutator interface {
+	MutateResources(ctx context.Context, res *Resources)
+}
+
+// DomainRegistry registers the DNS records of the services found by each
+// discovery cycle. When complete is false some services may be missing (e.g.
+// a discovery target failed), records of missing services must be kept.
+type DomainRegistry interface {
+	SyncServices(ctx context.Context, services []*sdapi.Service, complete bool)
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
+	"sort"
+	"strings"
 
 	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
 	matcherv3 "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
@@ -104,12 +106,63 @@ func (v VirtualHostMap) MergeRoute(routeCfg *routev3.RouteConfiguration) {
 
 }
 
+// matchDomain matches the domain against a virtual host domain, which may
+// have a leading or trailing wildcard (e.g. *.example.com or example.*)
+func matchDomain(vhDomain string, domain string) bool {
+
+	switch {
+
+	case vhDomain == "*" || vhDomain == domain:
+		return true
+
+	case strings.HasPrefix(vhDomain, "*"):
+		return strings.HasSuffix(domain, vhDomain[1:]) && len(domain) > len(vhDomain)-1
+
+	case strings.HasSuffix(vhDomain, "*"):
+		return strings.HasPrefix(domain, vhDomain[:len(vhDomain)-1]) && len(domain) > len(vhDomain)-1
+
+	}
+
+	return false
+
+}
+
+// PrependRoute adds the route before the others of the virtual hosts serving
+// the domain, returns the count of virtual hosts changed
+func (v VirtualHostMap) PrependRoute(domain string, route *routev3.Route) int {
+
+	count := 0
+
+	for vhDomain, vh := range v {
+
+		if !matchDomain(vhDomain, domain) {
+			continue
+		}
+
+		vh.Routes = append([]*routev3.Route{route}, vh.Routes...)
+
+		count++
+
+	}
+
+	return count
+
+}
+
 func (v VirtualHostMap) ToResourceSlice() []types.Resource {
 
+	domains := make([]string, 0, len(v))
+
+	for domain := range v {
+		domains = append(domains, domain)
+	}
+
+	sort.Strings(domains)
+
 	res := []types.Resource{}
 
-	for _, vh := range v {
-		res = append(res, vh.VirtualHost)
+	for _, domain := range domains {
+		res = append(res, v[domain].VirtualHost)
 	}
 
 	return res
@@ -0,0 +1,11 @@
+package tlsconfig
+
+import "errors"
+
+var (
+	UnknownModeErr          = errors.New("Unknown TLS mode, use plaintext, tls or mtls")
+	MissingCertificateErr   = errors.New("TLS mode without certificate file or parameter file source")
+	MissingClientCAErr      = errors.New("mTLS mode without client CA bundle")
+	InvalidClientCAErr      = errors.New("No certificate found in the client CA bundle")
+	MissingSourceOptionsErr = errors.New("No parameter source options to load the TLS certificate")
+)
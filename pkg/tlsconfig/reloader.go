@@ -0,0 +1,300 @@
+package tlsconfig
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/upper-institute/hike/pkg/helpers"
+	"github.com/upper-institute/hike/pkg/parameter"
+	paramapi "github.com/upper-institute/hike/proto/api/parameter"
+	"go.uber.org/zap"
+)
+
+const (
+	PlaintextMode = "plaintext"
+	TLSMode       = "tls"
+	MutualTLSMode = "mtls"
+
+	DefaultReloadInterval = 30 * time.Second
+)
+
+type ReloaderOptions struct {
+	// plaintext, tls (client certificates verified if given) or mtls
+	// (client certificates required)
+	Mode string
+	// PEM certificate chain and private key files
+	CertPath string
+	KeyPath  string
+	// Parameter file source with the certificate, used instead of the files
+	SourceOptions       *parameter.SourceOptions
+	ParameterFileSource string
+	// Default: WN_TLS_CERTIFICATE
+	CertificateParameterKey string
+	// Optional, the certificate parameter has the private key after the
+	// chain when empty
+	KeyParameterKey string
+	// PEM file with the CAs verifying client certificates, default is the
+	// system pool in tls mode
+	ClientCAPath   string
+	ReloadInterval time.Duration
+}
+
+func (options *ReloaderOptions) NewReloader(logger *zap.SugaredLogger) (*Reloader, error) {
+
+	switch options.Mode {
+
+	case TLSMode:
+
+	case MutualTLSMode:
+
+		if len(options.ClientCAPath) == 0 {
+			return nil, MissingClientCAErr
+		}
+
+	default:
+		return nil, UnknownModeErr
+
+	}
+
+	if len(options.ParameterFileSource) == 0 && len(options.CertPath) == 0 {
+		return nil, MissingCertificateErr
+	}
+
+	if len(options.ParameterFileSource) > 0 && options.SourceOptions == nil {
+		return nil, MissingSourceOptionsErr
+	}
+
+	r := &Reloader{
+		options: options,
+		logger:  logger.With("part", "tlsconfig/reloader", "mode", options.Mode),
+	}
+
+	err := r.load(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+
+}
+
+type loadedConfig struct {
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+}
+
+// Reloader serves the certificate and client CAs of a TLS server, loaded
+// again when they change without restarting the server
+type Reloader struct {
+	options *ReloaderOptions
+
+	logger *zap.SugaredLogger
+
+	config atomic.Value
+	hash   []byte
+}
+
+func (r *Reloader) certificateParameterKey() string {
+
+	if len(r.options.CertificateParameterKey) > 0 {
+		return r.options.CertificateParameterKey
+	}
+
+	return paramapi.WellKnown_WN_TLS_CERTIFICATE.String()
+
+}
+
+func (r *Reloader) loadParameter(ctx context.Context, source *parameter.Source, key string) ([]byte, error) {
+
+	if !source.Has(key) {
+		return nil, parameter.FileNotFoundErr
+	}
+
+	param := source.Get(key)
+
+	err := param.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return param.GetFile().Bytes(), nil
+
+}
+
+func (r *Reloader) loadCertificate(ctx context.Context) ([]byte, []byte, error) {
+
+	if len(r.options.ParameterFileSource) == 0 {
+
+		chain, err := os.ReadFile(r.options.CertPath)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		key, err := os.ReadFile(r.options.KeyPath)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return chain, key, nil
+
+	}
+
+	source, err := r.options.SourceOptions.NewFromURLString(r.options.ParameterFileSource)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = source.Restore(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	chain, err := r.loadParameter(ctx, source, r.certificateParameterKey())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(r.options.KeyParameterKey) == 0 {
+		return helpers.SplitPEMBundle(chain)
+	}
+
+	key, err := r.loadParameter(ctx, source, r.options.KeyParameterKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return chain, key, nil
+
+}
+
+func (r *Reloader) load(ctx context.Context) error {
+
+	chain, key, err := r.loadCertificate(ctx)
+	if err != nil {
+		return err
+	}
+
+	var clientCAData []byte
+
+	if len(r.options.ClientCAPath) > 0 {
+
+		clientCAData, err = os.ReadFile(r.options.ClientCAPath)
+		if err != nil {
+			return err
+		}
+
+	}
+
+	hash := sha256.New()
+
+	hash.Write(chain)
+	hash.Write(key)
+	hash.Write(clientCAData)
+
+	sum := hash.Sum(nil)
+
+	if bytes.Equal(r.hash, sum) {
+		return nil
+	}
+
+	cert, err := tls.X509KeyPair(chain, key)
+	if err != nil {
+		return err
+	}
+
+	config := &loadedConfig{cert: &cert}
+
+	if len(clientCAData) > 0 {
+
+		config.clientCAs = x509.NewCertPool()
+
+		if !config.clientCAs.AppendCertsFromPEM(clientCAData) {
+			return InvalidClientCAErr
+		}
+
+	}
+
+	leaf, err := helpers.ParseLeafCertificate(chain)
+	if err != nil {
+		return err
+	}
+
+	r.config.Store(config)
+	r.hash = sum
+
+	r.logger.Infow("TLS certificate loaded", "subject", leaf.Subject.String(), "not_after", leaf.NotAfter)
+
+	return nil
+
+}
+
+func (r *Reloader) reload() {
+
+	for {
+
+		time.Sleep(r.options.ReloadInterval)
+
+		err := r.load(context.Background())
+		if err != nil {
+			r.logger.Errorw("Unable to reload TLS certificate, keeping the previous one", "error", err)
+		}
+
+	}
+
+}
+
+func (r *Reloader) StartReloadCycle() error {
+
+	if r.options.ReloadInterval <= 0 {
+		return nil
+	}
+
+	go r.reload()
+
+	return nil
+
+}
+
+// GetCertificate returns the last loaded certificate
+func (r *Reloader) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+
+	return r.config.Load().(*loadedConfig).cert, nil
+
+}
+
+// TLSConfig returns a server configuration always using the last loaded
+// certificate and client CAs
+func (r *Reloader) TLSConfig() *tls.Config {
+
+	config := &tls.Config{
+		GetCertificate: r.GetCertificate,
+		ClientAuth:     tls.VerifyClientCertIfGiven,
+		NextProtos:     []string{"h2", "http/1.1"},
+	}
+
+	if r.options.Mode == MutualTLSMode {
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	base := config.Clone()
+
+	// Client CAs can't be set per handshake other than with a new config
+	config.GetConfigForClient = func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+
+		clientConfig := base.Clone()
+
+		clientConfig.ClientCAs = r.config.Load().(*loadedConfig).clientCAs
+
+		return clientConfig, nil
+
+	}
+
+	return config
+
+}
@@ -14,6 +14,7 @@ import (
 	v31 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	anypb "google.golang.org/protobuf/types/known/anypb"
 	durationpb "google.golang.org/protobuf/types/known/durationpb"
 	reflect "reflect"
 	sync "sync"
@@ -26,6 +27,105 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type DnsRecordType int32
+
+const (
+	// CNAME when cname_value is set, otherwise A
+	DnsRecordType_DRT_UNSPECIFIED DnsRecordType = 0
+	DnsRecordType_DRT_CNAME       DnsRecordType = 1
+	DnsRecordType_DRT_A           DnsRecordType = 2
+	DnsRecordType_DRT_AAAA        DnsRecordType = 3
+)
+
+// Enum value maps for DnsRecordType.
+var (
+	DnsRecordType_name = map[int32]string{
+		0: "DRT_UNSPECIFIED",
+		1: "DRT_CNAME",
+		2: "DRT_A",
+		3: "DRT_AAAA",
+	}
+	DnsRecordType_value = map[string]int32{
+		"DRT_UNSPECIFIED": 0,
+		"DRT_CNAME":       1,
+		"DRT_A":           2,
+		"DRT_AAAA":        3,
+	}
+)
+
+func (x DnsRecordType) Enum() *DnsRecordType {
+	p := new(DnsRecordType)
+	*p = x
+	return p
+}
+
+func (x DnsRecordType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (DnsRecordType) Descriptor() protoreflect.EnumDescriptor {
+	return file_api_service_discovery_service_discovery_proto_enumTypes[0].Descriptor()
+}
+
+func (DnsRecordType) Type() protoreflect.EnumType {
+	return &file_api_service_discovery_service_discovery_proto_enumTypes[0]
+}
+
+func (x DnsRecordType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use DnsRecordType.Descriptor instead.
+func (DnsRecordType) EnumDescriptor() ([]byte, []int) {
+	return file_api_service_discovery_service_discovery_proto_rawDescGZIP(), []int{0}
+}
+
+type DnsFailoverRole int32
+
+const (
+	DnsFailoverRole_DFR_PRIMARY   DnsFailoverRole = 0
+	DnsFailoverRole_DFR_SECONDARY DnsFailoverRole = 1
+)
+
+// Enum value maps for DnsFailoverRole.
+var (
+	DnsFailoverRole_name = map[int32]string{
+		0: "DFR_PRIMARY",
+		1: "DFR_SECONDARY",
+	}
+	DnsFailoverRole_value = map[string]int32{
+		"DFR_PRIMARY":   0,
+		"DFR_SECONDARY": 1,
+	}
+)
+
+func (x DnsFailoverRole) Enum() *DnsFailoverRole {
+	p := new(DnsFailoverRole)
+	*p = x
+	return p
+}
+
+func (x DnsFailoverRole) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (DnsFailoverRole) Descriptor() protoreflect.EnumDescriptor {
+	return file_api_service_discovery_service_discovery_proto_enumTypes[1].Descriptor()
+}
+
+func (DnsFailoverRole) Type() protoreflect.EnumType {
+	return &file_api_service_discovery_service_discovery_proto_enumTypes[1]
+}
+
+func (x DnsFailoverRole) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use DnsFailoverRole.Descriptor instead.
+func (DnsFailoverRole) EnumDescriptor() ([]byte, []int) {
+	return file_api_service_discovery_service_discovery_proto_rawDescGZIP(), []int{1}
+}
+
 type AcmeProtocolCertificate struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -138,6 +238,297 @@ func (x *AcmeProtocolCertificate) GetDomains() []string {
 	return nil
 }
 
+// Certificate and private key stored as file parameters, e.g. uploaded or
+// issued by ACME, served to Envoy listeners via SDS
+type TlsCertificate struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ParameterFileSource string `protobuf:"bytes,1,opt,name=parameter_file_source,json=parameterFileSource,proto3" json:"parameter_file_source,omitempty"`
+	// File parameter with the PEM certificate chain, followed by the private
+	// key when key_parameter_key is empty
+	// Default: WN_TLS_CERTIFICATE
+	CertificateParameterKey string `protobuf:"bytes,2,opt,name=certificate_parameter_key,json=certificateParameterKey,proto3" json:"certificate_parameter_key,omitempty"`
+	// Optional, file parameter with the PEM private key
+	KeyParameterKey string `protobuf:"bytes,3,opt,name=key_parameter_key,json=keyParameterKey,proto3" json:"key_parameter_key,omitempty"`
+	// Optional, SNI server names selecting this certificate, default is
+	// every server name
+	ServerNames []string `protobuf:"bytes,4,rep,name=server_names,json=serverNames,proto3" json:"server_names,omitempty"`
+}
+
+func (x *TlsCertificate) Reset() {
+	*x = TlsCertificate{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_service_discovery_service_discovery_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TlsCertificate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TlsCertificate) ProtoMessage() {}
+
+func (x *TlsCertificate) ProtoReflect() protoreflect.Message {
+	mi := &file_api_service_discovery_service_discovery_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TlsCertificate.ProtoReflect.Descriptor instead.
+func (*TlsCertificate) Descriptor() ([]byte, []int) {
+	return file_api_service_discovery_service_discovery_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *TlsCertificate) GetParameterFileSource() string {
+	if x != nil {
+		return x.ParameterFileSource
+	}
+	return ""
+}
+
+func (x *TlsCertificate) GetCertificateParameterKey() string {
+	if x != nil {
+		return x.CertificateParameterKey
+	}
+	return ""
+}
+
+func (x *TlsCertificate) GetKeyParameterKey() string {
+	if x != nil {
+		return x.KeyParameterKey
+	}
+	return ""
+}
+
+func (x *TlsCertificate) GetServerNames() []string {
+	if x != nil {
+		return x.ServerNames
+	}
+	return nil
+}
+
+// Alias to an AWS resource instead of record values, e.g. an ALB or a
+// CloudFront distribution
+type DnsAliasTarget struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Example: dualstack.my-alb-1234.us-east-1.elb.amazonaws.com
+	DnsName string `protobuf:"bytes,1,opt,name=dns_name,json=dnsName,proto3" json:"dns_name,omitempty"`
+	// Hosted zone of the target (not the record one), example:
+	// CloudFront: Z2FDTNDATAQYW2
+	// ALB in us-east-1: Z35SXDOTRQ7X7K
+	HostedZoneId         string `protobuf:"bytes,2,opt,name=hosted_zone_id,json=hostedZoneId,proto3" json:"hosted_zone_id,omitempty"`
+	EvaluateTargetHealth bool   `protobuf:"varint,3,opt,name=evaluate_target_health,json=evaluateTargetHealth,proto3" json:"evaluate_target_health,omitempty"`
+}
+
+func (x *DnsAliasTarget) Reset() {
+	*x = DnsAliasTarget{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_service_discovery_service_discovery_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DnsAliasTarget) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DnsAliasTarget) ProtoMessage() {}
+
+func (x *DnsAliasTarget) ProtoReflect() protoreflect.Message {
+	mi := &file_api_service_discovery_service_discovery_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DnsAliasTarget.ProtoReflect.Descriptor instead.
+func (*DnsAliasTarget) Descriptor() ([]byte, []int) {
+	return file_api_service_discovery_service_discovery_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *DnsAliasTarget) GetDnsName() string {
+	if x != nil {
+		return x.DnsName
+	}
+	return ""
+}
+
+func (x *DnsAliasTarget) GetHostedZoneId() string {
+	if x != nil {
+		return x.HostedZoneId
+	}
+	return ""
+}
+
+func (x *DnsAliasTarget) GetEvaluateTargetHealth() bool {
+	if x != nil {
+		return x.EvaluateTargetHealth
+	}
+	return false
+}
+
+type DnsWeightedRouting struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Proportion of the queries answered by this record set, 0 disables it
+	Weight uint32 `protobuf:"varint,1,opt,name=weight,proto3" json:"weight,omitempty"`
+}
+
+func (x *DnsWeightedRouting) Reset() {
+	*x = DnsWeightedRouting{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_service_discovery_service_discovery_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DnsWeightedRouting) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DnsWeightedRouting) ProtoMessage() {}
+
+func (x *DnsWeightedRouting) ProtoReflect() protoreflect.Message {
+	mi := &file_api_service_discovery_service_discovery_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DnsWeightedRouting.ProtoReflect.Descriptor instead.
+func (*DnsWeightedRouting) Descriptor() ([]byte, []int) {
+	return file_api_service_discovery_service_discovery_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *DnsWeightedRouting) GetWeight() uint32 {
+	if x != nil {
+		return x.Weight
+	}
+	return 0
+}
+
+type DnsLatencyRouting struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Region of the record set, example: us-east-1
+	Region string `protobuf:"bytes,1,opt,name=region,proto3" json:"region,omitempty"`
+}
+
+func (x *DnsLatencyRouting) Reset() {
+	*x = DnsLatencyRouting{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_service_discovery_service_discovery_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DnsLatencyRouting) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DnsLatencyRouting) ProtoMessage() {}
+
+func (x *DnsLatencyRouting) ProtoReflect() protoreflect.Message {
+	mi := &file_api_service_discovery_service_discovery_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DnsLatencyRouting.ProtoReflect.Descriptor instead.
+func (*DnsLatencyRouting) Descriptor() ([]byte, []int) {
+	return file_api_service_discovery_service_discovery_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *DnsLatencyRouting) GetRegion() string {
+	if x != nil {
+		return x.Region
+	}
+	return ""
+}
+
+type DnsFailoverRouting struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Role DnsFailoverRole `protobuf:"varint,1,opt,name=role,proto3,enum=opscontrol.api.servicediscovery.DnsFailoverRole" json:"role,omitempty"`
+}
+
+func (x *DnsFailoverRouting) Reset() {
+	*x = DnsFailoverRouting{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_service_discovery_service_discovery_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DnsFailoverRouting) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DnsFailoverRouting) ProtoMessage() {}
+
+func (x *DnsFailoverRouting) ProtoReflect() protoreflect.Message {
+	mi := &file_api_service_discovery_service_discovery_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DnsFailoverRouting.ProtoReflect.Descriptor instead.
+func (*DnsFailoverRouting) Descriptor() ([]byte, []int) {
+	return file_api_service_discovery_service_discovery_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *DnsFailoverRouting) GetRole() DnsFailoverRole {
+	if x != nil {
+		return x.Role
+	}
+	return DnsFailoverRole_DFR_PRIMARY
+}
+
 type DnsRecord struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -147,12 +538,30 @@ type DnsRecord struct {
 	RecordName string               `protobuf:"bytes,2,opt,name=record_name,json=recordName,proto3" json:"record_name,omitempty"`
 	Ttl        *durationpb.Duration `protobuf:"bytes,3,opt,name=ttl,proto3" json:"ttl,omitempty"`
 	CnameValue string               `protobuf:"bytes,4,opt,name=cname_value,json=cnameValue,proto3" json:"cname_value,omitempty"`
+	Type       DnsRecordType        `protobuf:"varint,5,opt,name=type,proto3,enum=opscontrol.api.servicediscovery.DnsRecordType" json:"type,omitempty"`
+	// Optional, values of A and AAAA records, default is the IP addresses of
+	// the service endpoints
+	Values []string `protobuf:"bytes,6,rep,name=values,proto3" json:"values,omitempty"`
+	// Optional, A and AAAA records alias an AWS resource when set
+	AliasTarget *DnsAliasTarget `protobuf:"bytes,7,opt,name=alias_target,json=aliasTarget,proto3" json:"alias_target,omitempty"`
+	// Optional, default is a simple record
+	//
+	// Types that are assignable to RoutingPolicy:
+	//	*DnsRecord_Weighted
+	//	*DnsRecord_Latency
+	//	*DnsRecord_Failover
+	RoutingPolicy isDnsRecord_RoutingPolicy `protobuf_oneof:"routing_policy"`
+	// Required by routing policies, identifies the record set among the ones
+	// of the same name and type
+	SetIdentifier string `protobuf:"bytes,11,opt,name=set_identifier,json=setIdentifier,proto3" json:"set_identifier,omitempty"`
+	// Optional, Route53 health check of the record set
+	HealthCheckId string `protobuf:"bytes,12,opt,name=health_check_id,json=healthCheckId,proto3" json:"health_check_id,omitempty"`
 }
 
 func (x *DnsRecord) Reset() {
 	*x = DnsRecord{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_api_service_discovery_service_discovery_proto_msgTypes[1]
+		mi := &file_api_service_discovery_service_discovery_proto_msgTypes[6]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -165,7 +574,7 @@ func (x *DnsRecord) String() string {
 func (*DnsRecord) ProtoMessage() {}
 
 func (x *DnsRecord) ProtoReflect() protoreflect.Message {
-	mi := &file_api_service_discovery_service_discovery_proto_msgTypes[1]
+	mi := &file_api_service_discovery_service_discovery_proto_msgTypes[6]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -176,39 +585,124 @@ func (x *DnsRecord) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DnsRecord.ProtoReflect.Descriptor instead.
-func (*DnsRecord) Descriptor() ([]byte, []int) {
-	return file_api_service_discovery_service_discovery_proto_rawDescGZIP(), []int{1}
+// Deprecated: Use DnsRecord.ProtoReflect.Descriptor instead.
+func (*DnsRecord) Descriptor() ([]byte, []int) {
+	return file_api_service_discovery_service_discovery_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *DnsRecord) GetZone() string {
+	if x != nil {
+		return x.Zone
+	}
+	return ""
+}
+
+func (x *DnsRecord) GetRecordName() string {
+	if x != nil {
+		return x.RecordName
+	}
+	return ""
+}
+
+func (x *DnsRecord) GetTtl() *durationpb.Duration {
+	if x != nil {
+		return x.Ttl
+	}
+	return nil
+}
+
+func (x *DnsRecord) GetCnameValue() string {
+	if x != nil {
+		return x.CnameValue
+	}
+	return ""
+}
+
+func (x *DnsRecord) GetType() DnsRecordType {
+	if x != nil {
+		return x.Type
+	}
+	return DnsRecordType_DRT_UNSPECIFIED
+}
+
+func (x *DnsRecord) GetValues() []string {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+func (x *DnsRecord) GetAliasTarget() *DnsAliasTarget {
+	if x != nil {
+		return x.AliasTarget
+	}
+	return nil
+}
+
+func (m *DnsRecord) GetRoutingPolicy() isDnsRecord_RoutingPolicy {
+	if m != nil {
+		return m.RoutingPolicy
+	}
+	return nil
+}
+
+func (x *DnsRecord) GetWeighted() *DnsWeightedRouting {
+	if x, ok := x.GetRoutingPolicy().(*DnsRecord_Weighted); ok {
+		return x.Weighted
+	}
+	return nil
+}
+
+func (x *DnsRecord) GetLatency() *DnsLatencyRouting {
+	if x, ok := x.GetRoutingPolicy().(*DnsRecord_Latency); ok {
+		return x.Latency
+	}
+	return nil
+}
+
+func (x *DnsRecord) GetFailover() *DnsFailoverRouting {
+	if x, ok := x.GetRoutingPolicy().(*DnsRecord_Failover); ok {
+		return x.Failover
+	}
+	return nil
 }
 
-func (x *DnsRecord) GetZone() string {
+func (x *DnsRecord) GetSetIdentifier() string {
 	if x != nil {
-		return x.Zone
+		return x.SetIdentifier
 	}
 	return ""
 }
 
-func (x *DnsRecord) GetRecordName() string {
+func (x *DnsRecord) GetHealthCheckId() string {
 	if x != nil {
-		return x.RecordName
+		return x.HealthCheckId
 	}
 	return ""
 }
 
-func (x *DnsRecord) GetTtl() *durationpb.Duration {
-	if x != nil {
-		return x.Ttl
-	}
-	return nil
+type isDnsRecord_RoutingPolicy interface {
+	isDnsRecord_RoutingPolicy()
 }
 
-func (x *DnsRecord) GetCnameValue() string {
-	if x != nil {
-		return x.CnameValue
-	}
-	return ""
+type DnsRecord_Weighted struct {
+	Weighted *DnsWeightedRouting `protobuf:"bytes,8,opt,name=weighted,proto3,oneof"`
+}
+
+type DnsRecord_Latency struct {
+	Latency *DnsLatencyRouting `protobuf:"bytes,9,opt,name=latency,proto3,oneof"`
+}
+
+type DnsRecord_Failover struct {
+	Failover *DnsFailoverRouting `protobuf:"bytes,10,opt,name=failover,proto3,oneof"`
 }
 
+func (*DnsRecord_Weighted) isDnsRecord_RoutingPolicy() {}
+
+func (*DnsRecord_Latency) isDnsRecord_RoutingPolicy() {}
+
+func (*DnsRecord_Failover) isDnsRecord_RoutingPolicy() {}
+
 type IngressGateway struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -218,7 +712,7 @@ type IngressGateway struct {
 func (x *IngressGateway) Reset() {
 	*x = IngressGateway{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_api_service_discovery_service_discovery_proto_msgTypes[2]
+		mi := &file_api_service_discovery_service_discovery_proto_msgTypes[7]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -231,7 +725,7 @@ func (x *IngressGateway) String() string {
 func (*IngressGateway) ProtoMessage() {}
 
 func (x *IngressGateway) ProtoReflect() protoreflect.Message {
-	mi := &file_api_service_discovery_service_discovery_proto_msgTypes[2]
+	mi := &file_api_service_discovery_service_discovery_proto_msgTypes[7]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -244,7 +738,7 @@ func (x *IngressGateway) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use IngressGateway.ProtoReflect.Descriptor instead.
 func (*IngressGateway) Descriptor() ([]byte, []int) {
-	return file_api_service_discovery_service_discovery_proto_rawDescGZIP(), []int{2}
+	return file_api_service_discovery_service_discovery_proto_rawDescGZIP(), []int{7}
 }
 
 type GrpcService struct {
@@ -256,7 +750,7 @@ type GrpcService struct {
 func (x *GrpcService) Reset() {
 	*x = GrpcService{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_api_service_discovery_service_discovery_proto_msgTypes[3]
+		mi := &file_api_service_discovery_service_discovery_proto_msgTypes[8]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -269,7 +763,7 @@ func (x *GrpcService) String() string {
 func (*GrpcService) ProtoMessage() {}
 
 func (x *GrpcService) ProtoReflect() protoreflect.Message {
-	mi := &file_api_service_discovery_service_discovery_proto_msgTypes[3]
+	mi := &file_api_service_discovery_service_discovery_proto_msgTypes[8]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -282,7 +776,7 @@ func (x *GrpcService) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GrpcService.ProtoReflect.Descriptor instead.
 func (*GrpcService) Descriptor() ([]byte, []int) {
-	return file_api_service_discovery_service_discovery_proto_rawDescGZIP(), []int{3}
+	return file_api_service_discovery_service_discovery_proto_rawDescGZIP(), []int{8}
 }
 
 type HttpService struct {
@@ -294,7 +788,7 @@ type HttpService struct {
 func (x *HttpService) Reset() {
 	*x = HttpService{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_api_service_discovery_service_discovery_proto_msgTypes[4]
+		mi := &file_api_service_discovery_service_discovery_proto_msgTypes[9]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -307,7 +801,7 @@ func (x *HttpService) String() string {
 func (*HttpService) ProtoMessage() {}
 
 func (x *HttpService) ProtoReflect() protoreflect.Message {
-	mi := &file_api_service_discovery_service_discovery_proto_msgTypes[4]
+	mi := &file_api_service_discovery_service_discovery_proto_msgTypes[9]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -320,7 +814,7 @@ func (x *HttpService) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use HttpService.ProtoReflect.Descriptor instead.
 func (*HttpService) Descriptor() ([]byte, []int) {
-	return file_api_service_discovery_service_discovery_proto_rawDescGZIP(), []int{4}
+	return file_api_service_discovery_service_discovery_proto_rawDescGZIP(), []int{9}
 }
 
 type Service struct {
@@ -328,12 +822,20 @@ type Service struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	ServiceName                string                     `protobuf:"bytes,1,opt,name=service_name,json=serviceName,proto3" json:"service_name,omitempty"`
-	ServiceId                  string                     `protobuf:"bytes,2,opt,name=service_id,json=serviceId,proto3" json:"service_id,omitempty"`
-	XdsClusterName             string                     `protobuf:"bytes,3,opt,name=xds_cluster_name,json=xdsClusterName,proto3" json:"xds_cluster_name,omitempty"`
-	ListenPort                 uint32                     `protobuf:"varint,4,opt,name=listen_port,json=listenPort,proto3" json:"listen_port,omitempty"`
-	DnsRecords                 []*DnsRecord               `protobuf:"bytes,6,rep,name=dns_records,json=dnsRecords,proto3" json:"dns_records,omitempty"`
-	AcmeProtocolCertificates   []*AcmeProtocolCertificate `protobuf:"bytes,7,rep,name=acme_protocol_certificates,json=acmeProtocolCertificates,proto3" json:"acme_protocol_certificates,omitempty"`
+	ServiceName              string                     `protobuf:"bytes,1,opt,name=service_name,json=serviceName,proto3" json:"service_name,omitempty"`
+	ServiceId                string                     `protobuf:"bytes,2,opt,name=service_id,json=serviceId,proto3" json:"service_id,omitempty"`
+	XdsClusterName           string                     `protobuf:"bytes,3,opt,name=xds_cluster_name,json=xdsClusterName,proto3" json:"xds_cluster_name,omitempty"`
+	ListenPort               uint32                     `protobuf:"varint,4,opt,name=listen_port,json=listenPort,proto3" json:"listen_port,omitempty"`
+	DnsRecords               []*DnsRecord               `protobuf:"bytes,6,rep,name=dns_records,json=dnsRecords,proto3" json:"dns_records,omitempty"`
+	AcmeProtocolCertificates []*AcmeProtocolCertificate `protobuf:"bytes,7,rep,name=acme_protocol_certificates,json=acmeProtocolCertificates,proto3" json:"acme_protocol_certificates,omitempty"`
+	// ACME certificates are also served, using their domains as server names
+	TlsCertificates []*TlsCertificate `protobuf:"bytes,12,rep,name=tls_certificates,json=tlsCertificates,proto3" json:"tls_certificates,omitempty"`
+	// Optional, port of a second listener terminating TLS with the
+	// certificates, otherwise TLS is terminated on listen_port
+	TlsListenPort uint32 `protobuf:"varint,14,opt,name=tls_listen_port,json=tlsListenPort,proto3" json:"tls_listen_port,omitempty"`
+	// Keeps the listener and cluster in plaintext when the internal CA
+	// requires mTLS, e.g. for a public ingress
+	DisableMutualTls           bool                       `protobuf:"varint,15,opt,name=disable_mutual_tls,json=disableMutualTls,proto3" json:"disable_mutual_tls,omitempty"`
 	EnvoyCluster               *v3.Cluster                `protobuf:"bytes,9,opt,name=envoy_cluster,json=envoyCluster,proto3" json:"envoy_cluster,omitempty"`
 	EnvoyHttpConnectionManager *v31.HttpConnectionManager `protobuf:"bytes,10,opt,name=envoy_http_connection_manager,json=envoyHttpConnectionManager,proto3" json:"envoy_http_connection_manager,omitempty"`
 	EnvoyRoutes                []*v32.RouteConfiguration  `protobuf:"bytes,8,rep,name=envoy_routes,json=envoyRoutes,proto3" json:"envoy_routes,omitempty"`
@@ -343,7 +845,7 @@ type Service struct {
 func (x *Service) Reset() {
 	*x = Service{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_api_service_discovery_service_discovery_proto_msgTypes[5]
+		mi := &file_api_service_discovery_service_discovery_proto_msgTypes[10]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -356,7 +858,7 @@ func (x *Service) String() string {
 func (*Service) ProtoMessage() {}
 
 func (x *Service) ProtoReflect() protoreflect.Message {
-	mi := &file_api_service_discovery_service_discovery_proto_msgTypes[5]
+	mi := &file_api_service_discovery_service_discovery_proto_msgTypes[10]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -369,7 +871,7 @@ func (x *Service) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Service.ProtoReflect.Descriptor instead.
 func (*Service) Descriptor() ([]byte, []int) {
-	return file_api_service_discovery_service_discovery_proto_rawDescGZIP(), []int{5}
+	return file_api_service_discovery_service_discovery_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *Service) GetServiceName() string {
@@ -414,6 +916,27 @@ func (x *Service) GetAcmeProtocolCertificates() []*AcmeProtocolCertificate {
 	return nil
 }
 
+func (x *Service) GetTlsCertificates() []*TlsCertificate {
+	if x != nil {
+		return x.TlsCertificates
+	}
+	return nil
+}
+
+func (x *Service) GetTlsListenPort() uint32 {
+	if x != nil {
+		return x.TlsListenPort
+	}
+	return 0
+}
+
+func (x *Service) GetDisableMutualTls() bool {
+	if x != nil {
+		return x.DisableMutualTls
+	}
+	return false
+}
+
 func (x *Service) GetEnvoyCluster() *v3.Cluster {
 	if x != nil {
 		return x.EnvoyCluster
@@ -442,6 +965,173 @@ func (x *Service) GetEnvoyClusterLoadAssignment() *v33.ClusterLoadAssignment {
 	return nil
 }
 
+type GetSnapshotRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Optional, default is the node id served by hike
+	NodeId string `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+}
+
+func (x *GetSnapshotRequest) Reset() {
+	*x = GetSnapshotRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_service_discovery_service_discovery_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetSnapshotRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSnapshotRequest) ProtoMessage() {}
+
+func (x *GetSnapshotRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_service_discovery_service_discovery_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSnapshotRequest.ProtoReflect.Descriptor instead.
+func (*GetSnapshotRequest) Descriptor() ([]byte, []int) {
+	return file_api_service_discovery_service_discovery_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetSnapshotRequest) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+type SnapshotResources struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Example: type.googleapis.com/envoy.config.cluster.v3.Cluster
+	TypeUrl   string       `protobuf:"bytes,1,opt,name=type_url,json=typeUrl,proto3" json:"type_url,omitempty"`
+	Version   string       `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	Resources []*anypb.Any `protobuf:"bytes,3,rep,name=resources,proto3" json:"resources,omitempty"`
+}
+
+func (x *SnapshotResources) Reset() {
+	*x = SnapshotResources{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_service_discovery_service_discovery_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SnapshotResources) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotResources) ProtoMessage() {}
+
+func (x *SnapshotResources) ProtoReflect() protoreflect.Message {
+	mi := &file_api_service_discovery_service_discovery_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotResources.ProtoReflect.Descriptor instead.
+func (*SnapshotResources) Descriptor() ([]byte, []int) {
+	return file_api_service_discovery_service_discovery_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *SnapshotResources) GetTypeUrl() string {
+	if x != nil {
+		return x.TypeUrl
+	}
+	return ""
+}
+
+func (x *SnapshotResources) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *SnapshotResources) GetResources() []*anypb.Any {
+	if x != nil {
+		return x.Resources
+	}
+	return nil
+}
+
+type GetSnapshotResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	NodeId    string               `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	Resources []*SnapshotResources `protobuf:"bytes,2,rep,name=resources,proto3" json:"resources,omitempty"`
+}
+
+func (x *GetSnapshotResponse) Reset() {
+	*x = GetSnapshotResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_service_discovery_service_discovery_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetSnapshotResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSnapshotResponse) ProtoMessage() {}
+
+func (x *GetSnapshotResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_service_discovery_service_discovery_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSnapshotResponse.ProtoReflect.Descriptor instead.
+func (*GetSnapshotResponse) Descriptor() ([]byte, []int) {
+	return file_api_service_discovery_service_discovery_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *GetSnapshotResponse) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *GetSnapshotResponse) GetResources() []*SnapshotResources {
+	if x != nil {
+		return x.Resources
+	}
+	return nil
+}
+
 var File_api_service_discovery_service_discovery_proto protoreflect.FileDescriptor
 
 var file_api_service_discovery_service_discovery_proto_rawDesc = []byte{
@@ -465,108 +1155,223 @@ var file_api_service_discovery_service_discovery_proto_rawDesc = []byte{
 	0x2f, 0x65, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a,
 	0x21, 0x65, 0x6e, 0x76, 0x6f, 0x79, 0x2f, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2f, 0x72, 0x6f,
 	0x75, 0x74, 0x65, 0x2f, 0x76, 0x33, 0x2f, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x2e, 0x70, 0x72, 0x6f,
-	0x74, 0x6f, 0x1a, 0x1e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x62, 0x75, 0x66, 0x2f, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f,
-	0x74, 0x6f, 0x22, 0xd4, 0x02, 0x0a, 0x17, 0x41, 0x63, 0x6d, 0x65, 0x50, 0x72, 0x6f, 0x74, 0x6f,
-	0x63, 0x6f, 0x6c, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x12, 0x1c,
-	0x0a, 0x0a, 0x63, 0x61, 0x5f, 0x64, 0x69, 0x72, 0x5f, 0x75, 0x72, 0x6c, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x08, 0x63, 0x61, 0x44, 0x69, 0x72, 0x55, 0x72, 0x6c, 0x12, 0x23, 0x0a, 0x0d,
-	0x61, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x5f, 0x65, 0x6d, 0x61, 0x69, 0x6c, 0x18, 0x02, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x0c, 0x61, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x45, 0x6d, 0x61, 0x69,
-	0x6c, 0x12, 0x3a, 0x0a, 0x19, 0x63, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65,
-	0x5f, 0x70, 0x61, 0x72, 0x61, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x03,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x17, 0x63, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74,
-	0x65, 0x50, 0x61, 0x72, 0x61, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x4b, 0x65, 0x79, 0x12, 0x32, 0x0a,
-	0x15, 0x61, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x5f, 0x70, 0x61, 0x72, 0x61, 0x6d, 0x65, 0x74,
-	0x65, 0x72, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x13, 0x61, 0x63,
-	0x63, 0x6f, 0x75, 0x6e, 0x74, 0x50, 0x61, 0x72, 0x61, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x4b, 0x65,
-	0x79, 0x12, 0x19, 0x0a, 0x08, 0x6b, 0x65, 0x79, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x05, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x07, 0x6b, 0x65, 0x79, 0x54, 0x79, 0x70, 0x65, 0x12, 0x32, 0x0a, 0x15,
-	0x70, 0x61, 0x72, 0x61, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x5f, 0x66, 0x69, 0x6c, 0x65, 0x5f, 0x73,
-	0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x13, 0x70, 0x61, 0x72,
-	0x61, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x46, 0x69, 0x6c, 0x65, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65,
-	0x12, 0x1d, 0x0a, 0x0a, 0x75, 0x73, 0x65, 0x72, 0x5f, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x18, 0x07,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x75, 0x73, 0x65, 0x72, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x12,
-	0x18, 0x0a, 0x07, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x73, 0x18, 0x08, 0x20, 0x03, 0x28, 0x09,
-	0x52, 0x07, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x73, 0x22, 0x8e, 0x01, 0x0a, 0x09, 0x44, 0x6e,
-	0x73, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x7a, 0x6f, 0x6e, 0x65, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x7a, 0x6f, 0x6e, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x72,
-	0x65, 0x63, 0x6f, 0x72, 0x64, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x0a, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x2b, 0x0a, 0x03,
-	0x74, 0x74, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
-	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61,
-	0x74, 0x69, 0x6f, 0x6e, 0x52, 0x03, 0x74, 0x74, 0x6c, 0x12, 0x1f, 0x0a, 0x0b, 0x63, 0x6e, 0x61,
-	0x6d, 0x65, 0x5f, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a,
-	0x63, 0x6e, 0x61, 0x6d, 0x65, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x22, 0x10, 0x0a, 0x0e, 0x49, 0x6e,
-	0x67, 0x72, 0x65, 0x73, 0x73, 0x47, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x22, 0x0d, 0x0a, 0x0b,
-	0x47, 0x72, 0x70, 0x63, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x22, 0x0d, 0x0a, 0x0b, 0x48,
-	0x74, 0x74, 0x70, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x22, 0xfc, 0x05, 0x0a, 0x07, 0x53,
-	0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63,
-	0x65, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x73, 0x65,
-	0x72, 0x76, 0x69, 0x63, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x65, 0x72,
-	0x76, 0x69, 0x63, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x73,
-	0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x49, 0x64, 0x12, 0x28, 0x0a, 0x10, 0x78, 0x64, 0x73, 0x5f,
-	0x63, 0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x0e, 0x78, 0x64, 0x73, 0x43, 0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x4e, 0x61,
-	0x6d, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x6c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x5f, 0x70, 0x6f, 0x72,
-	0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0a, 0x6c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x50,
-	0x6f, 0x72, 0x74, 0x12, 0x4b, 0x0a, 0x0b, 0x64, 0x6e, 0x73, 0x5f, 0x72, 0x65, 0x63, 0x6f, 0x72,
-	0x64, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x2a, 0x2e, 0x6f, 0x70, 0x73, 0x63, 0x6f,
-	0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63,
-	0x65, 0x64, 0x69, 0x73, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x79, 0x2e, 0x44, 0x6e, 0x73, 0x52, 0x65,
-	0x63, 0x6f, 0x72, 0x64, 0x52, 0x0a, 0x64, 0x6e, 0x73, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73,
-	0x12, 0x76, 0x0a, 0x1a, 0x61, 0x63, 0x6d, 0x65, 0x5f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f,
-	0x6c, 0x5f, 0x63, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x73, 0x18, 0x07,
-	0x20, 0x03, 0x28, 0x0b, 0x32, 0x38, 0x2e, 0x6f, 0x70, 0x73, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x6f,
-	0x6c, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x64, 0x69, 0x73,
-	0x63, 0x6f, 0x76, 0x65, 0x72, 0x79, 0x2e, 0x41, 0x63, 0x6d, 0x65, 0x50, 0x72, 0x6f, 0x74, 0x6f,
-	0x63, 0x6f, 0x6c, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x52, 0x18,
-	0x61, 0x63, 0x6d, 0x65, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x43, 0x65, 0x72, 0x74,
-	0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x73, 0x12, 0x45, 0x0a, 0x0d, 0x65, 0x6e, 0x76, 0x6f,
-	0x79, 0x5f, 0x63, 0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32,
-	0x20, 0x2e, 0x65, 0x6e, 0x76, 0x6f, 0x79, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x63,
-	0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x2e, 0x76, 0x33, 0x2e, 0x43, 0x6c, 0x75, 0x73, 0x74, 0x65,
-	0x72, 0x52, 0x0c, 0x65, 0x6e, 0x76, 0x6f, 0x79, 0x43, 0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x12,
-	0x95, 0x01, 0x0a, 0x1d, 0x65, 0x6e, 0x76, 0x6f, 0x79, 0x5f, 0x68, 0x74, 0x74, 0x70, 0x5f, 0x63,
-	0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x6d, 0x61, 0x6e, 0x61, 0x67, 0x65,
-	0x72, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x52, 0x2e, 0x65, 0x6e, 0x76, 0x6f, 0x79, 0x2e,
-	0x65, 0x78, 0x74, 0x65, 0x6e, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x2e, 0x66, 0x69, 0x6c, 0x74, 0x65,
-	0x72, 0x73, 0x2e, 0x6e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x2e, 0x68, 0x74, 0x74, 0x70, 0x5f,
-	0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x6d, 0x61, 0x6e, 0x61, 0x67,
-	0x65, 0x72, 0x2e, 0x76, 0x33, 0x2e, 0x48, 0x74, 0x74, 0x70, 0x43, 0x6f, 0x6e, 0x6e, 0x65, 0x63,
-	0x74, 0x69, 0x6f, 0x6e, 0x4d, 0x61, 0x6e, 0x61, 0x67, 0x65, 0x72, 0x52, 0x1a, 0x65, 0x6e, 0x76,
-	0x6f, 0x79, 0x48, 0x74, 0x74, 0x70, 0x43, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e,
-	0x4d, 0x61, 0x6e, 0x61, 0x67, 0x65, 0x72, 0x12, 0x4c, 0x0a, 0x0c, 0x65, 0x6e, 0x76, 0x6f, 0x79,
-	0x5f, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x73, 0x18, 0x08, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x29, 0x2e,
-	0x65, 0x6e, 0x76, 0x6f, 0x79, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x72, 0x6f, 0x75,
-	0x74, 0x65, 0x2e, 0x76, 0x33, 0x2e, 0x52, 0x6f, 0x75, 0x74, 0x65, 0x43, 0x6f, 0x6e, 0x66, 0x69,
-	0x67, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0b, 0x65, 0x6e, 0x76, 0x6f, 0x79, 0x52,
-	0x6f, 0x75, 0x74, 0x65, 0x73, 0x12, 0x72, 0x0a, 0x1d, 0x65, 0x6e, 0x76, 0x6f, 0x79, 0x5f, 0x63,
-	0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x5f, 0x6c, 0x6f, 0x61, 0x64, 0x5f, 0x61, 0x73, 0x73, 0x69,
-	0x67, 0x6e, 0x6d, 0x65, 0x6e, 0x74, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x2f, 0x2e, 0x65,
-	0x6e, 0x76, 0x6f, 0x79, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x65, 0x6e, 0x64, 0x70,
-	0x6f, 0x69, 0x6e, 0x74, 0x2e, 0x76, 0x33, 0x2e, 0x43, 0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x4c,
-	0x6f, 0x61, 0x64, 0x41, 0x73, 0x73, 0x69, 0x67, 0x6e, 0x6d, 0x65, 0x6e, 0x74, 0x52, 0x1a, 0x65,
-	0x6e, 0x76, 0x6f, 0x79, 0x43, 0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x4c, 0x6f, 0x61, 0x64, 0x41,
-	0x73, 0x73, 0x69, 0x67, 0x6e, 0x6d, 0x65, 0x6e, 0x74, 0x42, 0x9b, 0x02, 0x0a, 0x23, 0x63, 0x6f,
-	0x6d, 0x2e, 0x6f, 0x70, 0x73, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x2e, 0x61, 0x70, 0x69,
+	0x74, 0x6f, 0x1a, 0x19, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2f, 0x61, 0x6e, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x1e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x64,
+	0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xd4, 0x02,
+	0x0a, 0x17, 0x41, 0x63, 0x6d, 0x65, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x43, 0x65,
+	0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x12, 0x1c, 0x0a, 0x0a, 0x63, 0x61, 0x5f,
+	0x64, 0x69, 0x72, 0x5f, 0x75, 0x72, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x63,
+	0x61, 0x44, 0x69, 0x72, 0x55, 0x72, 0x6c, 0x12, 0x23, 0x0a, 0x0d, 0x61, 0x63, 0x63, 0x6f, 0x75,
+	0x6e, 0x74, 0x5f, 0x65, 0x6d, 0x61, 0x69, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c,
+	0x61, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x45, 0x6d, 0x61, 0x69, 0x6c, 0x12, 0x3a, 0x0a, 0x19,
+	0x63, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x5f, 0x70, 0x61, 0x72, 0x61,
+	0x6d, 0x65, 0x74, 0x65, 0x72, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x17, 0x63, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x50, 0x61, 0x72, 0x61,
+	0x6d, 0x65, 0x74, 0x65, 0x72, 0x4b, 0x65, 0x79, 0x12, 0x32, 0x0a, 0x15, 0x61, 0x63, 0x63, 0x6f,
+	0x75, 0x6e, 0x74, 0x5f, 0x70, 0x61, 0x72, 0x61, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x5f, 0x6b, 0x65,
+	0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x13, 0x61, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74,
+	0x50, 0x61, 0x72, 0x61, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x4b, 0x65, 0x79, 0x12, 0x19, 0x0a, 0x08,
+	0x6b, 0x65, 0x79, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07,
+	0x6b, 0x65, 0x79, 0x54, 0x79, 0x70, 0x65, 0x12, 0x32, 0x0a, 0x15, 0x70, 0x61, 0x72, 0x61, 0x6d,
+	0x65, 0x74, 0x65, 0x72, 0x5f, 0x66, 0x69, 0x6c, 0x65, 0x5f, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65,
+	0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x13, 0x70, 0x61, 0x72, 0x61, 0x6d, 0x65, 0x74, 0x65,
+	0x72, 0x46, 0x69, 0x6c, 0x65, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x75,
+	0x73, 0x65, 0x72, 0x5f, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x09, 0x75, 0x73, 0x65, 0x72, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x64, 0x6f,
+	0x6d, 0x61, 0x69, 0x6e, 0x73, 0x18, 0x08, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x64, 0x6f, 0x6d,
+	0x61, 0x69, 0x6e, 0x73, 0x22, 0xcf, 0x01, 0x0a, 0x0e, 0x54, 0x6c, 0x73, 0x43, 0x65, 0x72, 0x74,
+	0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x12, 0x32, 0x0a, 0x15, 0x70, 0x61, 0x72, 0x61, 0x6d,
+	0x65, 0x74, 0x65, 0x72, 0x5f, 0x66, 0x69, 0x6c, 0x65, 0x5f, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x13, 0x70, 0x61, 0x72, 0x61, 0x6d, 0x65, 0x74, 0x65,
+	0x72, 0x46, 0x69, 0x6c, 0x65, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x3a, 0x0a, 0x19, 0x63,
+	0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x5f, 0x70, 0x61, 0x72, 0x61, 0x6d,
+	0x65, 0x74, 0x65, 0x72, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x17,
+	0x63, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x50, 0x61, 0x72, 0x61, 0x6d,
+	0x65, 0x74, 0x65, 0x72, 0x4b, 0x65, 0x79, 0x12, 0x2a, 0x0a, 0x11, 0x6b, 0x65, 0x79, 0x5f, 0x70,
+	0x61, 0x72, 0x61, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0f, 0x6b, 0x65, 0x79, 0x50, 0x61, 0x72, 0x61, 0x6d, 0x65, 0x74, 0x65, 0x72,
+	0x4b, 0x65, 0x79, 0x12, 0x21, 0x0a, 0x0c, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x5f, 0x6e, 0x61,
+	0x6d, 0x65, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0b, 0x73, 0x65, 0x72, 0x76, 0x65,
+	0x72, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x22, 0x87, 0x01, 0x0a, 0x0e, 0x44, 0x6e, 0x73, 0x41, 0x6c,
+	0x69, 0x61, 0x73, 0x54, 0x61, 0x72, 0x67, 0x65, 0x74, 0x12, 0x19, 0x0a, 0x08, 0x64, 0x6e, 0x73,
+	0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x64, 0x6e, 0x73,
+	0x4e, 0x61, 0x6d, 0x65, 0x12, 0x24, 0x0a, 0x0e, 0x68, 0x6f, 0x73, 0x74, 0x65, 0x64, 0x5f, 0x7a,
+	0x6f, 0x6e, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x68, 0x6f,
+	0x73, 0x74, 0x65, 0x64, 0x5a, 0x6f, 0x6e, 0x65, 0x49, 0x64, 0x12, 0x34, 0x0a, 0x16, 0x65, 0x76,
+	0x61, 0x6c, 0x75, 0x61, 0x74, 0x65, 0x5f, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x5f, 0x68, 0x65,
+	0x61, 0x6c, 0x74, 0x68, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x14, 0x65, 0x76, 0x61, 0x6c,
+	0x75, 0x61, 0x74, 0x65, 0x54, 0x61, 0x72, 0x67, 0x65, 0x74, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68,
+	0x22, 0x2c, 0x0a, 0x12, 0x44, 0x6e, 0x73, 0x57, 0x65, 0x69, 0x67, 0x68, 0x74, 0x65, 0x64, 0x52,
+	0x6f, 0x75, 0x74, 0x69, 0x6e, 0x67, 0x12, 0x16, 0x0a, 0x06, 0x77, 0x65, 0x69, 0x67, 0x68, 0x74,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x77, 0x65, 0x69, 0x67, 0x68, 0x74, 0x22, 0x2b,
+	0x0a, 0x11, 0x44, 0x6e, 0x73, 0x4c, 0x61, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x52, 0x6f, 0x75, 0x74,
+	0x69, 0x6e, 0x67, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x67, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x06, 0x72, 0x65, 0x67, 0x69, 0x6f, 0x6e, 0x22, 0x5a, 0x0a, 0x12, 0x44,
+	0x6e, 0x73, 0x46, 0x61, 0x69, 0x6c, 0x6f, 0x76, 0x65, 0x72, 0x52, 0x6f, 0x75, 0x74, 0x69, 0x6e,
+	0x67, 0x12, 0x44, 0x0a, 0x04, 0x72, 0x6f, 0x6c, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32,
+	0x30, 0x2e, 0x6f, 0x70, 0x73, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x2e, 0x61, 0x70, 0x69,
 	0x2e, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x64, 0x69, 0x73, 0x63, 0x6f, 0x76, 0x65, 0x72,
-	0x79, 0x42, 0x15, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x44, 0x69, 0x73, 0x63, 0x6f, 0x76,
-	0x65, 0x72, 0x79, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x50, 0x01, 0x5a, 0x3f, 0x67, 0x69, 0x74, 0x68,
-	0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x75, 0x70, 0x70, 0x65, 0x72, 0x2d, 0x69, 0x6e, 0x73,
-	0x74, 0x69, 0x74, 0x75, 0x74, 0x65, 0x2f, 0x68, 0x69, 0x6b, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63,
-	0x65, 0x2d, 0x64, 0x69, 0x73, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x79, 0xa2, 0x02, 0x03, 0x4f, 0x41,
-	0x53, 0xaa, 0x02, 0x1f, 0x4f, 0x70, 0x73, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x2e, 0x41,
-	0x70, 0x69, 0x2e, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x64, 0x69, 0x73, 0x63, 0x6f, 0x76,
-	0x65, 0x72, 0x79, 0xca, 0x02, 0x1f, 0x4f, 0x70, 0x73, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c,
-	0x5c, 0x41, 0x70, 0x69, 0x5c, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x64, 0x69, 0x73, 0x63,
-	0x6f, 0x76, 0x65, 0x72, 0x79, 0xe2, 0x02, 0x2b, 0x4f, 0x70, 0x73, 0x63, 0x6f, 0x6e, 0x74, 0x72,
-	0x6f, 0x6c, 0x5c, 0x41, 0x70, 0x69, 0x5c, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x64, 0x69,
-	0x73, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x79, 0x5c, 0x47, 0x50, 0x42, 0x4d, 0x65, 0x74, 0x61, 0x64,
-	0x61, 0x74, 0x61, 0xea, 0x02, 0x21, 0x4f, 0x70, 0x73, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c,
-	0x3a, 0x3a, 0x41, 0x70, 0x69, 0x3a, 0x3a, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x64, 0x69,
-	0x73, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x79, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x79, 0x2e, 0x44, 0x6e, 0x73, 0x46, 0x61, 0x69, 0x6c, 0x6f, 0x76, 0x65, 0x72, 0x52, 0x6f, 0x6c,
+	0x65, 0x52, 0x04, 0x72, 0x6f, 0x6c, 0x65, 0x22, 0x95, 0x05, 0x0a, 0x09, 0x44, 0x6e, 0x73, 0x52,
+	0x65, 0x63, 0x6f, 0x72, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x7a, 0x6f, 0x6e, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x7a, 0x6f, 0x6e, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x72, 0x65, 0x63,
+	0x6f, 0x72, 0x64, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a,
+	0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x2b, 0x0a, 0x03, 0x74, 0x74,
+	0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x52, 0x03, 0x74, 0x74, 0x6c, 0x12, 0x1f, 0x0a, 0x0b, 0x63, 0x6e, 0x61, 0x6d, 0x65,
+	0x5f, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x63, 0x6e,
+	0x61, 0x6d, 0x65, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x42, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x2e, 0x2e, 0x6f, 0x70, 0x73, 0x63, 0x6f, 0x6e, 0x74,
+	0x72, 0x6f, 0x6c, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x64,
+	0x69, 0x73, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x79, 0x2e, 0x44, 0x6e, 0x73, 0x52, 0x65, 0x63, 0x6f,
+	0x72, 0x64, 0x54, 0x79, 0x70, 0x65, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x16, 0x0a, 0x06,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x73, 0x12, 0x52, 0x0a, 0x0c, 0x61, 0x6c, 0x69, 0x61, 0x73, 0x5f, 0x74, 0x61,
+	0x72, 0x67, 0x65, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x2f, 0x2e, 0x6f, 0x70, 0x73,
+	0x63, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x73, 0x65, 0x72, 0x76,
+	0x69, 0x63, 0x65, 0x64, 0x69, 0x73, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x79, 0x2e, 0x44, 0x6e, 0x73,
+	0x41, 0x6c, 0x69, 0x61, 0x73, 0x54, 0x61, 0x72, 0x67, 0x65, 0x74, 0x52, 0x0b, 0x61, 0x6c, 0x69,
+	0x61, 0x73, 0x54, 0x61, 0x72, 0x67, 0x65, 0x74, 0x12, 0x51, 0x0a, 0x08, 0x77, 0x65, 0x69, 0x67,
+	0x68, 0x74, 0x65, 0x64, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x33, 0x2e, 0x6f, 0x70, 0x73,
+	0x63, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x73, 0x65, 0x72, 0x76,
+	0x69, 0x63, 0x65, 0x64, 0x69, 0x73, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x79, 0x2e, 0x44, 0x6e, 0x73,
+	0x57, 0x65, 0x69, 0x67, 0x68, 0x74, 0x65, 0x64, 0x52, 0x6f, 0x75, 0x74, 0x69, 0x6e, 0x67, 0x48,
+	0x00, 0x52, 0x08, 0x77, 0x65, 0x69, 0x67, 0x68, 0x74, 0x65, 0x64, 0x12, 0x4e, 0x0a, 0x07, 0x6c,
+	0x61, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x32, 0x2e, 0x6f,
+	0x70, 0x73, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x73, 0x65,
+	0x72, 0x76, 0x69, 0x63, 0x65, 0x64, 0x69, 0x73, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x79, 0x2e, 0x44,
+	0x6e, 0x73, 0x4c, 0x61, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x52, 0x6f, 0x75, 0x74, 0x69, 0x6e, 0x67,
+	0x48, 0x00, 0x52, 0x07, 0x6c, 0x61, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x12, 0x51, 0x0a, 0x08, 0x66,
+	0x61, 0x69, 0x6c, 0x6f, 0x76, 0x65, 0x72, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x33, 0x2e,
+	0x6f, 0x70, 0x73, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x73,
+	0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x64, 0x69, 0x73, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x79, 0x2e,
+	0x44, 0x6e, 0x73, 0x46, 0x61, 0x69, 0x6c, 0x6f, 0x76, 0x65, 0x72, 0x52, 0x6f, 0x75, 0x74, 0x69,
+	0x6e, 0x67, 0x48, 0x00, 0x52, 0x08, 0x66, 0x61, 0x69, 0x6c, 0x6f, 0x76, 0x65, 0x72, 0x12, 0x25,
+	0x0a, 0x0e, 0x73, 0x65, 0x74, 0x5f, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x66, 0x69, 0x65, 0x72,
+	0x18, 0x0b, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x73, 0x65, 0x74, 0x49, 0x64, 0x65, 0x6e, 0x74,
+	0x69, 0x66, 0x69, 0x65, 0x72, 0x12, 0x26, 0x0a, 0x0f, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x5f,
+	0x63, 0x68, 0x65, 0x63, 0x6b, 0x5f, 0x69, 0x64, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d,
+	0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x49, 0x64, 0x42, 0x10, 0x0a,
+	0x0e, 0x72, 0x6f, 0x75, 0x74, 0x69, 0x6e, 0x67, 0x5f, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x22,
+	0x10, 0x0a, 0x0e, 0x49, 0x6e, 0x67, 0x72, 0x65, 0x73, 0x73, 0x47, 0x61, 0x74, 0x65, 0x77, 0x61,
+	0x79, 0x22, 0x0d, 0x0a, 0x0b, 0x47, 0x72, 0x70, 0x63, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65,
+	0x22, 0x0d, 0x0a, 0x0b, 0x48, 0x74, 0x74, 0x70, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x22,
+	0xae, 0x07, 0x0a, 0x07, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x73,
+	0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0b, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1d,
+	0x0a, 0x0a, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x09, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x49, 0x64, 0x12, 0x28, 0x0a,
+	0x10, 0x78, 0x64, 0x73, 0x5f, 0x63, 0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x5f, 0x6e, 0x61, 0x6d,
+	0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x78, 0x64, 0x73, 0x43, 0x6c, 0x75, 0x73,
+	0x74, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x6c, 0x69, 0x73, 0x74, 0x65,
+	0x6e, 0x5f, 0x70, 0x6f, 0x72, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0a, 0x6c, 0x69,
+	0x73, 0x74, 0x65, 0x6e, 0x50, 0x6f, 0x72, 0x74, 0x12, 0x4b, 0x0a, 0x0b, 0x64, 0x6e, 0x73, 0x5f,
+	0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x2a, 0x2e,
+	0x6f, 0x70, 0x73, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x73,
+	0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x64, 0x69, 0x73, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x79, 0x2e,
+	0x44, 0x6e, 0x73, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x52, 0x0a, 0x64, 0x6e, 0x73, 0x52, 0x65,
+	0x63, 0x6f, 0x72, 0x64, 0x73, 0x12, 0x76, 0x0a, 0x1a, 0x61, 0x63, 0x6d, 0x65, 0x5f, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x5f, 0x63, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61,
+	0x74, 0x65, 0x73, 0x18, 0x07, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x38, 0x2e, 0x6f, 0x70, 0x73, 0x63,
+	0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x69,
+	0x63, 0x65, 0x64, 0x69, 0x73, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x79, 0x2e, 0x41, 0x63, 0x6d, 0x65,
+	0x50, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63,
+	0x61, 0x74, 0x65, 0x52, 0x18, 0x61, 0x63, 0x6d, 0x65, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f,
+	0x6c, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x73, 0x12, 0x5a, 0x0a,
+	0x10, 0x74, 0x6c, 0x73, 0x5f, 0x63, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65,
+	0x73, 0x18, 0x0c, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x2f, 0x2e, 0x6f, 0x70, 0x73, 0x63, 0x6f, 0x6e,
+	0x74, 0x72, 0x6f, 0x6c, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65,
+	0x64, 0x69, 0x73, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x79, 0x2e, 0x54, 0x6c, 0x73, 0x43, 0x65, 0x72,
+	0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x52, 0x0f, 0x74, 0x6c, 0x73, 0x43, 0x65, 0x72,
+	0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x73, 0x12, 0x26, 0x0a, 0x0f, 0x74, 0x6c, 0x73,
+	0x5f, 0x6c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x5f, 0x70, 0x6f, 0x72, 0x74, 0x18, 0x0e, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x0d, 0x74, 0x6c, 0x73, 0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x50, 0x6f, 0x72,
+	0x74, 0x12, 0x2c, 0x0a, 0x12, 0x64, 0x69, 0x73, 0x61, 0x62, 0x6c, 0x65, 0x5f, 0x6d, 0x75, 0x74,
+	0x75, 0x61, 0x6c, 0x5f, 0x74, 0x6c, 0x73, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x08, 0x52, 0x10, 0x64,
+	0x69, 0x73, 0x61, 0x62, 0x6c, 0x65, 0x4d, 0x75, 0x74, 0x75, 0x61, 0x6c, 0x54, 0x6c, 0x73, 0x12,
+	0x45, 0x0a, 0x0d, 0x65, 0x6e, 0x76, 0x6f, 0x79, 0x5f, 0x63, 0x6c, 0x75, 0x73, 0x74, 0x65, 0x72,
+	0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x65, 0x6e, 0x76, 0x6f, 0x79, 0x2e, 0x63,
+	0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x63, 0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x2e, 0x76, 0x33,
+	0x2e, 0x43, 0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x52, 0x0c, 0x65, 0x6e, 0x76, 0x6f, 0x79, 0x43,
+	0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x12, 0x95, 0x01, 0x0a, 0x1d, 0x65, 0x6e, 0x76, 0x6f, 0x79,
+	0x5f, 0x68, 0x74, 0x74, 0x70, 0x5f, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e,
+	0x5f, 0x6d, 0x61, 0x6e, 0x61, 0x67, 0x65, 0x72, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x52,
+	0x2e, 0x65, 0x6e, 0x76, 0x6f, 0x79, 0x2e, 0x65, 0x78, 0x74, 0x65, 0x6e, 0x73, 0x69, 0x6f, 0x6e,
+	0x73, 0x2e, 0x66, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x73, 0x2e, 0x6e, 0x65, 0x74, 0x77, 0x6f, 0x72,
+	0x6b, 0x2e, 0x68, 0x74, 0x74, 0x70, 0x5f, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f,
+	0x6e, 0x5f, 0x6d, 0x61, 0x6e, 0x61, 0x67, 0x65, 0x72, 0x2e, 0x76, 0x33, 0x2e, 0x48, 0x74, 0x74,
+	0x70, 0x43, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x4d, 0x61, 0x6e, 0x61, 0x67,
+	0x65, 0x72, 0x52, 0x1a, 0x65, 0x6e, 0x76, 0x6f, 0x79, 0x48, 0x74, 0x74, 0x70, 0x43, 0x6f, 0x6e,
+	0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x4d, 0x61, 0x6e, 0x61, 0x67, 0x65, 0x72, 0x12, 0x4c,
+	0x0a, 0x0c, 0x65, 0x6e, 0x76, 0x6f, 0x79, 0x5f, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x73, 0x18, 0x08,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x29, 0x2e, 0x65, 0x6e, 0x76, 0x6f, 0x79, 0x2e, 0x63, 0x6f, 0x6e,
+	0x66, 0x69, 0x67, 0x2e, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x2e, 0x76, 0x33, 0x2e, 0x52, 0x6f, 0x75,
+	0x74, 0x65, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52,
+	0x0b, 0x65, 0x6e, 0x76, 0x6f, 0x79, 0x52, 0x6f, 0x75, 0x74, 0x65, 0x73, 0x12, 0x72, 0x0a, 0x1d,
+	0x65, 0x6e, 0x76, 0x6f, 0x79, 0x5f, 0x63, 0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x5f, 0x6c, 0x6f,
+	0x61, 0x64, 0x5f, 0x61, 0x73, 0x73, 0x69, 0x67, 0x6e, 0x6d, 0x65, 0x6e, 0x74, 0x18, 0x0b, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x2f, 0x2e, 0x65, 0x6e, 0x76, 0x6f, 0x79, 0x2e, 0x63, 0x6f, 0x6e, 0x66,
+	0x69, 0x67, 0x2e, 0x65, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x2e, 0x76, 0x33, 0x2e, 0x43,
+	0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x4c, 0x6f, 0x61, 0x64, 0x41, 0x73, 0x73, 0x69, 0x67, 0x6e,
+	0x6d, 0x65, 0x6e, 0x74, 0x52, 0x1a, 0x65, 0x6e, 0x76, 0x6f, 0x79, 0x43, 0x6c, 0x75, 0x73, 0x74,
+	0x65, 0x72, 0x4c, 0x6f, 0x61, 0x64, 0x41, 0x73, 0x73, 0x69, 0x67, 0x6e, 0x6d, 0x65, 0x6e, 0x74,
+	0x22, 0x2d, 0x0a, 0x12, 0x47, 0x65, 0x74, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x17, 0x0a, 0x07, 0x6e, 0x6f, 0x64, 0x65, 0x5f, 0x69,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x6e, 0x6f, 0x64, 0x65, 0x49, 0x64, 0x22,
+	0x7c, 0x0a, 0x11, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x52, 0x65, 0x73, 0x6f, 0x75,
+	0x72, 0x63, 0x65, 0x73, 0x12, 0x19, 0x0a, 0x08, 0x74, 0x79, 0x70, 0x65, 0x5f, 0x75, 0x72, 0x6c,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x74, 0x79, 0x70, 0x65, 0x55, 0x72, 0x6c, 0x12,
+	0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x32, 0x0a, 0x09, 0x72, 0x65, 0x73,
+	0x6f, 0x75, 0x72, 0x63, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x41,
+	0x6e, 0x79, 0x52, 0x09, 0x72, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x73, 0x22, 0x80, 0x01,
+	0x0a, 0x13, 0x47, 0x65, 0x74, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x17, 0x0a, 0x07, 0x6e, 0x6f, 0x64, 0x65, 0x5f, 0x69, 0x64,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x6e, 0x6f, 0x64, 0x65, 0x49, 0x64, 0x12, 0x50,
+	0x0a, 0x09, 0x72, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x32, 0x2e, 0x6f, 0x70, 0x73, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x2e, 0x61,
+	0x70, 0x69, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x64, 0x69, 0x73, 0x63, 0x6f, 0x76,
+	0x65, 0x72, 0x79, 0x2e, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x52, 0x65, 0x73, 0x6f,
+	0x75, 0x72, 0x63, 0x65, 0x73, 0x52, 0x09, 0x72, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x73,
+	0x2a, 0x4c, 0x0a, 0x0d, 0x44, 0x6e, 0x73, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x54, 0x79, 0x70,
+	0x65, 0x12, 0x13, 0x0a, 0x0f, 0x44, 0x52, 0x54, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49,
+	0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x0d, 0x0a, 0x09, 0x44, 0x52, 0x54, 0x5f, 0x43, 0x4e,
+	0x41, 0x4d, 0x45, 0x10, 0x01, 0x12, 0x09, 0x0a, 0x05, 0x44, 0x52, 0x54, 0x5f, 0x41, 0x10, 0x02,
+	0x12, 0x0c, 0x0a, 0x08, 0x44, 0x52, 0x54, 0x5f, 0x41, 0x41, 0x41, 0x41, 0x10, 0x03, 0x2a, 0x35,
+	0x0a, 0x0f, 0x44, 0x6e, 0x73, 0x46, 0x61, 0x69, 0x6c, 0x6f, 0x76, 0x65, 0x72, 0x52, 0x6f, 0x6c,
+	0x65, 0x12, 0x0f, 0x0a, 0x0b, 0x44, 0x46, 0x52, 0x5f, 0x50, 0x52, 0x49, 0x4d, 0x41, 0x52, 0x59,
+	0x10, 0x00, 0x12, 0x11, 0x0a, 0x0d, 0x44, 0x46, 0x52, 0x5f, 0x53, 0x45, 0x43, 0x4f, 0x4e, 0x44,
+	0x41, 0x52, 0x59, 0x10, 0x01, 0x32, 0x8c, 0x01, 0x0a, 0x10, 0x44, 0x69, 0x73, 0x63, 0x6f, 0x76,
+	0x65, 0x72, 0x79, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x78, 0x0a, 0x0b, 0x47, 0x65,
+	0x74, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x12, 0x33, 0x2e, 0x6f, 0x70, 0x73, 0x63,
+	0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x69,
+	0x63, 0x65, 0x64, 0x69, 0x73, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x79, 0x2e, 0x47, 0x65, 0x74, 0x53,
+	0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x34,
+	0x2e, 0x6f, 0x70, 0x73, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x2e, 0x61, 0x70, 0x69, 0x2e,
+	0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x64, 0x69, 0x73, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x79,
+	0x2e, 0x47, 0x65, 0x74, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x42, 0x9b, 0x02, 0x0a, 0x23, 0x63, 0x6f, 0x6d, 0x2e, 0x6f, 0x70, 0x73,
+	0x63, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x73, 0x65, 0x72, 0x76,
+	0x69, 0x63, 0x65, 0x64, 0x69, 0x73, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x79, 0x42, 0x15, 0x53, 0x65,
+	0x72, 0x76, 0x69, 0x63, 0x65, 0x44, 0x69, 0x73, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x79, 0x50, 0x72,
+	0x6f, 0x74, 0x6f, 0x50, 0x01, 0x5a, 0x3f, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f,
+	0x6d, 0x2f, 0x75, 0x70, 0x70, 0x65, 0x72, 0x2d, 0x69, 0x6e, 0x73, 0x74, 0x69, 0x74, 0x75, 0x74,
+	0x65, 0x2f, 0x68, 0x69, 0x6b, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x61, 0x70, 0x69,
+	0x2f, 0x61, 0x70, 0x69, 0x2f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2d, 0x64, 0x69, 0x73,
+	0x63, 0x6f, 0x76, 0x65, 0x72, 0x79, 0xa2, 0x02, 0x03, 0x4f, 0x41, 0x53, 0xaa, 0x02, 0x1f, 0x4f,
+	0x70, 0x73, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x2e, 0x41, 0x70, 0x69, 0x2e, 0x53, 0x65,
+	0x72, 0x76, 0x69, 0x63, 0x65, 0x64, 0x69, 0x73, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x79, 0xca, 0x02,
+	0x1f, 0x4f, 0x70, 0x73, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x5c, 0x41, 0x70, 0x69, 0x5c,
+	0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x64, 0x69, 0x73, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x79,
+	0xe2, 0x02, 0x2b, 0x4f, 0x70, 0x73, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x5c, 0x41, 0x70,
+	0x69, 0x5c, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x64, 0x69, 0x73, 0x63, 0x6f, 0x76, 0x65,
+	0x72, 0x79, 0x5c, 0x47, 0x50, 0x42, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0xea, 0x02,
+	0x21, 0x4f, 0x70, 0x73, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x3a, 0x3a, 0x41, 0x70, 0x69,
+	0x3a, 0x3a, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x64, 0x69, 0x73, 0x63, 0x6f, 0x76, 0x65,
+	0x72, 0x79, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -581,33 +1386,56 @@ func file_api_service_discovery_service_discovery_proto_rawDescGZIP() []byte {
 	return file_api_service_discovery_service_discovery_proto_rawDescData
 }
 
-var file_api_service_discovery_service_discovery_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_api_service_discovery_service_discovery_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_api_service_discovery_service_discovery_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
 var file_api_service_discovery_service_discovery_proto_goTypes = []interface{}{
-	(*AcmeProtocolCertificate)(nil),   // 0: opscontrol.api.servicediscovery.AcmeProtocolCertificate
-	(*DnsRecord)(nil),                 // 1: opscontrol.api.servicediscovery.DnsRecord
-	(*IngressGateway)(nil),            // 2: opscontrol.api.servicediscovery.IngressGateway
-	(*GrpcService)(nil),               // 3: opscontrol.api.servicediscovery.GrpcService
-	(*HttpService)(nil),               // 4: opscontrol.api.servicediscovery.HttpService
-	(*Service)(nil),                   // 5: opscontrol.api.servicediscovery.Service
-	(*durationpb.Duration)(nil),       // 6: google.protobuf.Duration
-	(*v3.Cluster)(nil),                // 7: envoy.config.cluster.v3.Cluster
-	(*v31.HttpConnectionManager)(nil), // 8: envoy.extensions.filters.network.http_connection_manager.v3.HttpConnectionManager
-	(*v32.RouteConfiguration)(nil),    // 9: envoy.config.route.v3.RouteConfiguration
-	(*v33.ClusterLoadAssignment)(nil), // 10: envoy.config.endpoint.v3.ClusterLoadAssignment
+	(DnsRecordType)(0),                // 0: opscontrol.api.servicediscovery.DnsRecordType
+	(DnsFailoverRole)(0),              // 1: opscontrol.api.servicediscovery.DnsFailoverRole
+	(*AcmeProtocolCertificate)(nil),   // 2: opscontrol.api.servicediscovery.AcmeProtocolCertificate
+	(*TlsCertificate)(nil),            // 3: opscontrol.api.servicediscovery.TlsCertificate
+	(*DnsAliasTarget)(nil),            // 4: opscontrol.api.servicediscovery.DnsAliasTarget
+	(*DnsWeightedRouting)(nil),        // 5: opscontrol.api.servicediscovery.DnsWeightedRouting
+	(*DnsLatencyRouting)(nil),         // 6: opscontrol.api.servicediscovery.DnsLatencyRouting
+	(*DnsFailoverRouting)(nil),        // 7: opscontrol.api.servicediscovery.DnsFailoverRouting
+	(*DnsRecord)(nil),                 // 8: opscontrol.api.servicediscovery.DnsRecord
+	(*IngressGateway)(nil),            // 9: opscontrol.api.servicediscovery.IngressGateway
+	(*GrpcService)(nil),               // 10: opscontrol.api.servicediscovery.GrpcService
+	(*HttpService)(nil),               // 11: opscontrol.api.servicediscovery.HttpService
+	(*Service)(nil),                   // 12: opscontrol.api.servicediscovery.Service
+	(*GetSnapshotRequest)(nil),        // 13: opscontrol.api.servicediscovery.GetSnapshotRequest
+	(*SnapshotResources)(nil),         // 14: opscontrol.api.servicediscovery.SnapshotResources
+	(*GetSnapshotResponse)(nil),       // 15: opscontrol.api.servicediscovery.GetSnapshotResponse
+	(*durationpb.Duration)(nil),       // 16: google.protobuf.Duration
+	(*v3.Cluster)(nil),                // 17: envoy.config.cluster.v3.Cluster
+	(*v31.HttpConnectionManager)(nil), // 18: envoy.extensions.filters.network.http_connection_manager.v3.HttpConnectionManager
+	(*v32.RouteConfiguration)(nil),    // 19: envoy.config.route.v3.RouteConfiguration
+	(*v33.ClusterLoadAssignment)(nil), // 20: envoy.config.endpoint.v3.ClusterLoadAssignment
+	(*anypb.Any)(nil),                 // 21: google.protobuf.Any
 }
 var file_api_service_discovery_service_discovery_proto_depIdxs = []int32{
-	6,  // 0: opscontrol.api.servicediscovery.DnsRecord.ttl:type_name -> google.protobuf.Duration
-	1,  // 1: opscontrol.api.servicediscovery.Service.dns_records:type_name -> opscontrol.api.servicediscovery.DnsRecord
-	0,  // 2: opscontrol.api.servicediscovery.Service.acme_protocol_certificates:type_name -> opscontrol.api.servicediscovery.AcmeProtocolCertificate
-	7,  // 3: opscontrol.api.servicediscovery.Service.envoy_cluster:type_name -> envoy.config.cluster.v3.Cluster
-	8,  // 4: opscontrol.api.servicediscovery.Service.envoy_http_connection_manager:type_name -> envoy.extensions.filters.network.http_connection_manager.v3.HttpConnectionManager
-	9,  // 5: opscontrol.api.servicediscovery.Service.envoy_routes:type_name -> envoy.config.route.v3.RouteConfiguration
-	10, // 6: opscontrol.api.servicediscovery.Service.envoy_cluster_load_assignment:type_name -> envoy.config.endpoint.v3.ClusterLoadAssignment
-	7,  // [7:7] is the sub-list for method output_type
-	7,  // [7:7] is the sub-list for method input_type
-	7,  // [7:7] is the sub-list for extension type_name
-	7,  // [7:7] is the sub-list for extension extendee
-	0,  // [0:7] is the sub-list for field type_name
+	1,  // 0: opscontrol.api.servicediscovery.DnsFailoverRouting.role:type_name -> opscontrol.api.servicediscovery.DnsFailoverRole
+	16, // 1: opscontrol.api.servicediscovery.DnsRecord.ttl:type_name -> google.protobuf.Duration
+	0,  // 2: opscontrol.api.servicediscovery.DnsRecord.type:type_name -> opscontrol.api.servicediscovery.DnsRecordType
+	4,  // 3: opscontrol.api.servicediscovery.DnsRecord.alias_target:type_name -> opscontrol.api.servicediscovery.DnsAliasTarget
+	5,  // 4: opscontrol.api.servicediscovery.DnsRecord.weighted:type_name -> opscontrol.api.servicediscovery.DnsWeightedRouting
+	6,  // 5: opscontrol.api.servicediscovery.DnsRecord.latency:type_name -> opscontrol.api.servicediscovery.DnsLatencyRouting
+	7,  // 6: opscontrol.api.servicediscovery.DnsRecord.failover:type_name -> opscontrol.api.servicediscovery.DnsFailoverRouting
+	8,  // 7: opscontrol.api.servicediscovery.Service.dns_records:type_name -> opscontrol.api.servicediscovery.DnsRecord
+	2,  // 8: opscontrol.api.servicediscovery.Service.acme_protocol_certificates:type_name -> opscontrol.api.servicediscovery.AcmeProtocolCertificate
+	3,  // 9: opscontrol.api.servicediscovery.Service.tls_certificates:type_name -> opscontrol.api.servicediscovery.TlsCertificate
+	17, // 10: opscontrol.api.servicediscovery.Service.envoy_cluster:type_name -> envoy.config.cluster.v3.Cluster
+	18, // 11: opscontrol.api.servicediscovery.Service.envoy_http_connection_manager:type_name -> envoy.extensions.filters.network.http_connection_manager.v3.HttpConnectionManager
+	19, // 12: opscontrol.api.servicediscovery.Service.envoy_routes:type_name -> envoy.config.route.v3.RouteConfiguration
+	20, // 13: opscontrol.api.servicediscovery.Service.envoy_cluster_load_assignment:type_name -> envoy.config.endpoint.v3.ClusterLoadAssignment
+	21, // 14: opscontrol.api.servicediscovery.SnapshotResources.resources:type_name -> google.protobuf.Any
+	14, // 15: opscontrol.api.servicediscovery.GetSnapshotResponse.resources:type_name -> opscontrol.api.servicediscovery.SnapshotResources
+	13, // 16: opscontrol.api.servicediscovery.DiscoveryService.GetSnapshot:input_type -> opscontrol.api.servicediscovery.GetSnapshotRequest
+	15, // 17: opscontrol.api.servicediscovery.DiscoveryService.GetSnapshot:output_type -> opscontrol.api.servicediscovery.GetSnapshotResponse
+	17, // [17:18] is the sub-list for method output_type
+	16, // [16:17] is the sub-list for method input_type
+	16, // [16:16] is the sub-list for extension type_name
+	16, // [16:16] is the sub-list for extension extendee
+	0,  // [0:16] is the sub-list for field type_name
 }
 
 func init() { file_api_service_discovery_service_discovery_proto_init() }
@@ -629,7 +1457,7 @@ func file_api_service_discovery_service_discovery_proto_init() {
 			}
 		}
 		file_api_service_discovery_service_discovery_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*DnsRecord); i {
+			switch v := v.(*TlsCertificate); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -641,7 +1469,7 @@ func file_api_service_discovery_service_discovery_proto_init() {
 			}
 		}
 		file_api_service_discovery_service_discovery_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*IngressGateway); i {
+			switch v := v.(*DnsAliasTarget); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -653,7 +1481,7 @@ func file_api_service_discovery_service_discovery_proto_init() {
 			}
 		}
 		file_api_service_discovery_service_discovery_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*GrpcService); i {
+			switch v := v.(*DnsWeightedRouting); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -665,7 +1493,7 @@ func file_api_service_discovery_service_discovery_proto_init() {
 			}
 		}
 		file_api_service_discovery_service_discovery_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*HttpService); i {
+			switch v := v.(*DnsLatencyRouting); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -677,6 +1505,66 @@ func file_api_service_discovery_service_discovery_proto_init() {
 			}
 		}
 		file_api_service_discovery_service_discovery_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DnsFailoverRouting); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_service_discovery_service_discovery_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DnsRecord); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_service_discovery_service_discovery_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*IngressGateway); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_service_discovery_service_discovery_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GrpcService); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_service_discovery_service_discovery_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*HttpService); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_service_discovery_service_discovery_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Service); i {
 			case 0:
 				return &v.state
@@ -688,19 +1576,61 @@ func file_api_service_discovery_service_discovery_proto_init() {
 				return nil
 			}
 		}
+		file_api_service_discovery_service_discovery_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetSnapshotRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_service_discovery_service_discovery_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SnapshotResources); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_service_discovery_service_discovery_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetSnapshotResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_api_service_discovery_service_discovery_proto_msgTypes[6].OneofWrappers = []interface{}{
+		(*DnsRecord_Weighted)(nil),
+		(*DnsRecord_Latency)(nil),
+		(*DnsRecord_Failover)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_api_service_discovery_service_discovery_proto_rawDesc,
-			NumEnums:      0,
-			NumMessages:   6,
+			NumEnums:      2,
+			NumMessages:   14,
 			NumExtensions: 0,
-			NumServices:   0,
+			NumServices:   1,
 		},
 		GoTypes:           file_api_service_discovery_service_discovery_proto_goTypes,
 		DependencyIndexes: file_api_service_discovery_service_discovery_proto_depIdxs,
+		EnumInfos:         file_api_service_discovery_service_discovery_proto_enumTypes,
 		MessageInfos:      file_api_service_discovery_service_discovery_proto_msgTypes,
 	}.Build()
 	File_api_service_discovery_service_discovery_proto = out.File